@@ -72,7 +72,7 @@ func EnsureHttps(endpoint string) (string, error) {
 func NewUnsafeClient(endpoint string) (Client, error) {
 	return &internalClient{
 		endpoint: endpoint,
-		client:   &http.Client{Transport: settings.Global.ConfiguredHttpTransport()},
+		client:   &http.Client{Transport: settings.Global.RemoteHttpTransport()},
 		tracing:  false,
 		critical: true,
 	}, nil
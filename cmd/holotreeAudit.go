@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/conda"
+	"github.com/robocorp/rcc/htfs"
+	"github.com/robocorp/rcc/operations"
+	"github.com/robocorp/rcc/pretty"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditDatabase string
+	auditFailOn   string
+)
+
+func loadAuditDatabase() *conda.VulnerabilityDatabase {
+	pretty.Guard(operations.ValidFailOn(auditFailOn), 2, "Unknown severity %q for --fail-on. Use low, medium, high, or critical.", auditFailOn)
+	database, err := conda.LoadVulnerabilityDatabase(operations.OsvDatabaseLocation(auditDatabase))
+	pretty.Guard(err == nil, 3, "%v", err)
+	return database
+}
+
+func reportAudit(report *conda.AuditReport) {
+	err := operations.PrintAuditReport(report, jsonFlag)
+	pretty.Guard(err == nil, 4, "Could not show audit report, reason: %v", err)
+	operations.GuardAuditReport(report, auditFailOn)
+}
+
+var holotreeAuditCmd = &cobra.Command{
+	Use:   "audit <catalog|space|conda.yaml>",
+	Short: "Audit holotree environment dependencies against offline OSV database.",
+	Long: `Audit holotree environment dependencies against offline OSV database.
+
+Target can be a catalog (name or path), a holotree space (name or path), or
+conda.yaml file whose environment is already built into hololib. Dependencies
+are matched against OSV advisories stored locally (by default in "osv" folder
+under ROBOCORP_HOME). Database can be a folder with OSV JSON files or zip
+dumps (like PyPI all.zip), and optional conda-mapping.yaml file, which maps
+conda package names to PyPI names (empty name disables matching).`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if common.DebugFlag() {
+			defer common.Stopwatch("Holotree audit command lasted").Report()
+		}
+		database := loadAuditDatabase()
+		root, err := htfs.ResolveSbomSource(args[0])
+		pretty.Guard(err == nil, 5, "Could not find %q, reason: %v", args[0], err)
+		golden, err := root.GoldenMaster(htfs.LibraryBlob)
+		pretty.Guard(err == nil, 6, "Could not read dependencies, reason: %v", err)
+		reportAudit(conda.ParseWantedDependencies(golden).Audit(database))
+		pretty.Ok()
+	},
+}
+
+func init() {
+	holotreeCmd.AddCommand(holotreeAuditCmd)
+	holotreeAuditCmd.Flags().StringVarP(&auditDatabase, "database", "", "", "Location of OSV database (folder, zip, or json file). Default is 'osv' under ROBOCORP_HOME.")
+	holotreeAuditCmd.Flags().StringVarP(&auditFailOn, "fail-on", "", "", "Fail when vulnerabilities with this or higher severity are found (low, medium, high, critical).")
+	holotreeAuditCmd.Flags().BoolVarP(&jsonFlag, "json", "j", false, "Output audit report in JSON format.")
+}
@@ -28,9 +28,9 @@ func holotreeExpandBlueprint(userFiles []string, packfile string) map[string]int
 }
 
 var holotreeBlueprintCmd = &cobra.Command{
-	Use:     "blueprint conda.yaml+",
+	Use:     "blueprint <conda.yaml|pyproject.toml|requirements.txt>+",
 	Short:   "Verify that resulting blueprint is in hololibrary.",
-	Long:    "Verify that resulting blueprint is in hololibrary.",
+	Long:    "Verify that resulting blueprint is in hololibrary.\n\nEnvironment files can be conda.yaml, package.yaml, pyproject.toml, or\nrequirements.txt (any \"*requirements*.txt\") files.",
 	Aliases: []string{"bp"},
 	Run: func(cmd *cobra.Command, args []string) {
 		if common.DebugFlag() {
@@ -133,7 +133,7 @@ func dumpTopN(stats map[string]int64, total float64, tabbed *tabwriter.Writer) {
 func listCatalogDetails(roots []*htfs.Root, topN int) {
 	used := catalogUsedStats()
 	tabbed := tabwriter.NewWriter(os.Stderr, 2, 4, 2, ' ', 0)
-	tabbed.Write([]byte("Blueprint\tPlatform\tDirs  \tFiles  \tSize   \tRelocate\tidentity.yaml (gzipped blob inside hololib)\tHolotree path\tAge (days)\tIdle (days)\n"))
+	tabbed.Write([]byte("Blueprint\tPlatform\tDirs  \tFiles  \tSize   \tRelocate\tidentity.yaml (compressed blob inside hololib)\tHolotree path\tAge (days)\tIdle (days)\n"))
 	tabbed.Write([]byte("---------\t--------\t------\t-------\t-------\t--------\t-------------------------------------------\t-------------\t----------\t-----------\n"))
 	for _, catalog := range roots {
 		lastUse, ok := used[catalog.Blueprint]
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/conda"
+	"github.com/robocorp/rcc/pretty"
+	"github.com/spf13/cobra"
+)
+
+var (
+	convertOutput string
+)
+
+var holotreeConvertCmd = &cobra.Command{
+	Use:   "convert <pyproject.toml|requirements.txt|package.yaml>",
+	Short: "Convert environment descriptor into equivalent conda.yaml.",
+	Long: `Convert environment descriptor into equivalent conda.yaml.
+
+Source can be pyproject.toml (PEP 621 "dependencies" and "requires-python"),
+requirements.txt (any "*requirements*.txt" file), package.yaml or conda.yaml.
+Result is same environment definition that rcc uses internally when given
+file is used as condaConfigFile, environmentConfigs entry, or argument to
+holotree commands.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if common.DebugFlag() {
+			defer common.Stopwatch("Holotree convert command lasted").Report()
+		}
+		environment, err := conda.ReadPackageCondaYaml(args[0])
+		pretty.Guard(err == nil, 1, "Could not read %q, reason: %v", args[0], err)
+		if len(convertOutput) == 0 {
+			content, err := environment.AsYaml()
+			pretty.Guard(err == nil, 2, "Could not create conda yaml, reason: %v", err)
+			common.Stdout("%s", content)
+			return
+		}
+		err = environment.SaveAs(convertOutput)
+		pretty.Guard(err == nil, 3, "Could not write %q, reason: %v", convertOutput, err)
+		common.Log("Converted %q into %q.", args[0], convertOutput)
+		pretty.Ok()
+	},
+}
+
+func init() {
+	holotreeCmd.AddCommand(holotreeConvertCmd)
+	holotreeConvertCmd.Flags().StringVarP(&convertOutput, "output", "o", "", "Write conda.yaml to this file instead of stdout.")
+}
@@ -18,12 +18,17 @@ func deleteByPartialIdentity(partials []string) {
 	if dryFlag {
 		note = "[dry run] "
 	}
+	pinned := roots.PinnedSpaces()
 	for _, label := range roots.FindEnvironments(partials) {
+		if pinned[label] && !includePinned {
+			pretty.Warning("%sSkipping pinned space %v (use --include-pinned to remove it anyway).", note, label)
+			continue
+		}
 		common.Log("%sRemoving %v", note, label)
 		if dryFlag {
 			continue
 		}
-		err := roots.RemoveHolotreeSpace(label)
+		err := roots.RemoveHolotreeSpace(label, includePinned)
 		pretty.Guard(err == nil, 1, "Error: %v", err)
 	}
 }
@@ -50,5 +55,6 @@ var holotreeDeleteCmd = &cobra.Command{
 func init() {
 	holotreeCmd.AddCommand(holotreeDeleteCmd)
 	holotreeDeleteCmd.Flags().BoolVarP(&dryFlag, "dryrun", "d", false, "Don't delete environments, just show what would happen.")
+	holotreeDeleteCmd.Flags().BoolVarP(&includePinned, "include-pinned", "", false, "Delete also pinned spaces.")
 	holotreeDeleteCmd.Flags().StringVarP(&deleteSpace, "space", "s", "", "Client specific name to identify environment to delete.")
 }
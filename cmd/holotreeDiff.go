@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"encoding/json"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/htfs"
+	"github.com/robocorp/rcc/pretty"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffFilesLimit int
+)
+
+func showFileChanges(title, marker string, changes []*htfs.FileChange) {
+	if len(changes) == 0 {
+		return
+	}
+	common.Log("%s%s files: %d%s", pretty.Bold, title, len(changes), pretty.Reset)
+	for at, change := range changes {
+		if diffFilesLimit > 0 && at >= diffFilesLimit {
+			common.Log("  ... and %d more", len(changes)-at)
+			break
+		}
+		switch {
+		case change.Before == nil:
+			common.Log("%s %s  %s", marker, change.Path, change.After)
+		case change.After == nil:
+			common.Log("%s %s  %s", marker, change.Path, change.Before)
+		default:
+			common.Log("%s %s  %s => %s", marker, change.Path, change.Before, change.After)
+		}
+	}
+}
+
+func showPackageChanges(title string, changes []*htfs.PackageChange) {
+	if len(changes) == 0 {
+		common.Log("%s%s packages: no changes%s", pretty.Bold, title, pretty.Reset)
+		return
+	}
+	common.Log("%s%s packages: %d changes%s", pretty.Bold, title, len(changes), pretty.Reset)
+	for _, change := range changes {
+		switch change.Kind() {
+		case "added":
+			common.Log("+ %s %s", change.Name, change.After)
+		case "removed":
+			common.Log("- %s %s", change.Name, change.Before)
+		default:
+			common.Log("~ %s %s => %s", change.Name, change.Before, change.After)
+		}
+	}
+}
+
+var holotreeDiffCmd = &cobra.Command{
+	Use:   "diff <catalogA> <catalogB>",
+	Short: "Show differences between two holotree catalogs.",
+	Long: `Show differences between two holotree catalogs.
+
+Catalogs can be given as catalog names, blueprint hashes, or full paths to
+catalog files. Files are compared by digest, size and mode, and Python and
+conda package changes are summarized from dist-info and conda-meta entries.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if common.DebugFlag() {
+			defer common.Stopwatch("Holotree diff command lasted").Report()
+		}
+		diff, err := htfs.DiffCatalogs(args[0], args[1])
+		pretty.Guard(err == nil, 1, "%s", err)
+		if jsonFlag {
+			nice, err := json.MarshalIndent(diff, "", "  ")
+			pretty.Guard(err == nil, 2, "%s", err)
+			common.Stdout("%s\n", nice)
+			return
+		}
+		common.Log("Comparing %q to %q.", diff.Left, diff.Right)
+		showFileChanges("Added", "+", diff.Added)
+		showFileChanges("Removed", "-", diff.Removed)
+		showFileChanges("Changed", "~", diff.Changed)
+		if diff.Identical() {
+			common.Log("Catalogs have identical files.")
+		}
+		showPackageChanges("Python", diff.Python)
+		showPackageChanges("Conda", diff.Conda)
+		pretty.Ok()
+	},
+}
+
+func init() {
+	holotreeCmd.AddCommand(holotreeDiffCmd)
+	holotreeDiffCmd.Flags().BoolVarP(&jsonFlag, "json", "j", false, "Output differences in JSON format.")
+	holotreeDiffCmd.Flags().IntVarP(&diffFilesLimit, "limit", "l", 100, "Maximum number of files to show per change type in text output (0 is unlimited).")
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"encoding/json"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/htfs"
+	"github.com/robocorp/rcc/pretty"
+	"github.com/spf13/cobra"
+)
+
+var holotreeGcCmd = &cobra.Command{
+	Use:     "gc",
+	Short:   "Remove hololib library parts that no catalog references anymore.",
+	Long:    "Remove hololib library parts that no catalog references anymore (mark and sweep).",
+	Aliases: []string{"sweep"},
+	Run: func(cmd *cobra.Command, args []string) {
+		if common.DebugFlag() {
+			defer common.Stopwatch("Holotree gc command lasted").Report()
+		}
+		report, err := htfs.CollectGarbage(dryFlag)
+		pretty.Guard(err == nil, 1, "Garbage collection failed, reason: %v", err)
+		if jsonFlag {
+			nice, err := json.MarshalIndent(report, "", "  ")
+			pretty.Guard(err == nil, 2, "%s", err)
+			common.Stdout("%s\n", nice)
+		} else {
+			var note string
+			if report.DryRun {
+				note = "[dry run] "
+			}
+			common.Log("%sLive digests: %d from %d catalogs.", note, report.Live, report.Catalogs)
+			common.Log("%sOrphan parts: %d of %d library files.", note, report.Orphans, report.Examined)
+			common.Log("%sReclaimed: %s (%d bytes).", note, report.Humane(), report.Reclaimed)
+		}
+		pretty.Guard(report.Failures == 0, 3, "Failed to remove %d orphan parts. See debug output for details.", report.Failures)
+		pretty.Ok()
+	},
+}
+
+func init() {
+	holotreeCmd.AddCommand(holotreeGcCmd)
+	holotreeGcCmd.Flags().BoolVarP(&dryFlag, "dryrun", "d", false, "Don't remove anything, just show what would be reclaimed.")
+	holotreeGcCmd.Flags().BoolVarP(&jsonFlag, "json", "j", false, "Output report in JSON format.")
+}
@@ -1,17 +1,24 @@
 package cmd
 
 import (
+	"bytes"
+
 	"github.com/robocorp/rcc/common"
 	"github.com/robocorp/rcc/htfs"
 	"github.com/robocorp/rcc/pretty"
+	"github.com/robocorp/rcc/settings"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	explainHash bool
+)
+
 var holotreeHashCmd = &cobra.Command{
-	Use:   "hash <conda.yaml+>",
-	Short: "Calculates a blueprint hash for managed holotree virtual environment from conda.yaml files.",
-	Long:  "Calculates a blueprint hash for managed holotree virtual environment from conda.yaml files.",
+	Use:   "hash <conda.yaml|pyproject.toml|requirements.txt>+",
+	Short: "Calculates a blueprint hash for managed holotree virtual environment from environment files.",
+	Long:  "Calculates a blueprint hash for managed holotree virtual environment from environment files.\n\nEnvironment files can be conda.yaml, package.yaml, pyproject.toml, or\nrequirements.txt (any \"*requirements*.txt\") files.",
 	Args:  cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		if common.DebugFlag() {
@@ -20,6 +27,9 @@ var holotreeHashCmd = &cobra.Command{
 		_, holotreeBlueprint, err := htfs.ComposeFinalBlueprint(args, "")
 		pretty.Guard(err == nil, 1, "Blueprint calculation failed: %v", err)
 		hash := common.BlueprintHash(holotreeBlueprint)
+		if explainHash {
+			explainBlueprint(args, holotreeBlueprint)
+		}
 		common.Log("Blueprint hash for %v is %v.", args, hash)
 		if common.Silent() {
 			common.Stdout("%s\n", hash)
@@ -27,6 +37,34 @@ var holotreeHashCmd = &cobra.Command{
 	},
 }
 
+func explainBlueprint(args []string, selected []byte) {
+	_, environment, err := htfs.ComposeFinalEnvironment(args, "")
+	pretty.Guard(err == nil, 1, "Blueprint calculation failed: %v", err)
+	legacy, err := htfs.LegacyBlueprint(environment)
+	pretty.Guard(err == nil, 2, "Legacy blueprint failed: %v", err)
+	canonical, err := htfs.CanonicalBlueprint(environment)
+	pretty.Guard(err == nil, 3, "Canonical blueprint failed: %v", err)
+	mode := "legacy"
+	if settings.Global.CanonicalBlueprints() {
+		mode = "canonical"
+	}
+	common.Log("Blueprint mode is %s (option 'canonical-blueprints' or RCC_CANONICAL_BLUEPRINTS).", mode)
+	common.Log("- legacy blueprint hash:    %s", common.BlueprintHash(legacy))
+	common.Log("- canonical blueprint hash: %s", common.BlueprintHash(canonical))
+	if mode == "canonical" && bytes.Equal(selected, legacy) && !bytes.Equal(legacy, canonical) {
+		common.Log("Existing catalog from legacy blueprint is used, since there is no catalog for canonical blueprint.")
+	}
+	common.Log("Text being hashed:")
+	common.WaitLogs()
+	common.Stdout("---\n%s\n---\n", selected)
+	if !bytes.Equal(selected, canonical) {
+		common.Log("Canonical form of same environment would be:")
+		common.WaitLogs()
+		common.Stdout("---\n%s\n---\n", canonical)
+	}
+}
+
 func init() {
 	holotreeCmd.AddCommand(holotreeHashCmd)
+	holotreeHashCmd.Flags().BoolVarP(&explainHash, "explain", "", false, "Show blueprint text being hashed, and both legacy and canonical hashes.")
 }
@@ -28,12 +28,16 @@ func whatUsage(space string) (string, string, int) {
 
 func humaneHolotreeSpaceListing() {
 	tabbed := tabwriter.NewWriter(os.Stderr, 2, 4, 2, ' ', 0)
-	tabbed.Write([]byte("Identity\tController\tSpace\tBlueprint\tFull path\tLast used\tUse count\n"))
-	tabbed.Write([]byte("--------\t----------\t-----\t---------\t---------\t---------\t---------\n"))
+	tabbed.Write([]byte("Identity\tController\tSpace\tBlueprint\tFull path\tLast used\tUse count\tPinned\n"))
+	tabbed.Write([]byte("--------\t----------\t-----\t---------\t---------\t---------\t---------\t------\n"))
 	_, roots := htfs.LoadCatalogs()
 	for _, space := range roots.Spaces() {
 		when, times, _ := whatUsage(space.Path)
-		data := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\n", space.Identity, space.Controller, space.Space, space.Blueprint, space.Path, when, times)
+		pinned := "no"
+		if htfs.IsPinned(space.Path) {
+			pinned = "yes"
+		}
+		data := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", space.Identity, space.Controller, space.Space, space.Blueprint, space.Path, when, times, pinned)
 		tabbed.Write([]byte(data))
 	}
 	tabbed.Flush()
@@ -59,6 +63,7 @@ func jsonicHolotreeSpaceListing() {
 			hold["last-used"] = when
 			hold["idle-days"] = idle
 			hold["use-count"] = times
+			hold["pinned"] = htfs.IsPinned(space.Path)
 		}
 	}
 	body, err := json.MarshalIndent(details, "", "  ")
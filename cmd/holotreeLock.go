@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/conda"
+	"github.com/robocorp/rcc/htfs"
+	"github.com/robocorp/rcc/operations"
+	"github.com/robocorp/rcc/pretty"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lockOutput    string
+	lockPlatforms []string
+)
+
+var holotreeLockCmd = &cobra.Command{
+	Use:   "lock <conda.yaml>",
+	Short: "Create cross-platform lock file from conda.yaml.",
+	Long: `Create cross-platform lock file from conda.yaml.
+
+Conda dependencies are solved for each target platform using micromamba
+dry-run solving against that platform's subdir, and pip dependencies are
+resolved with pip dry-run reports against matching binary wheels. Result
+has exact conda package URLs with md5/sha256 and exact pip versions with
+hashes for every platform.
+
+Target platforms come from --platform flags, or from "platforms:" list in
+conda.yaml, or default to current platform. Resolving pip dependencies uses
+python from holotree environment built from given conda.yaml on this host.
+
+Lock file can be used anywhere conda.yaml is used, for example as entry in
+robot.yaml environmentConfigs, and environments are then built from it
+without re-solving.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if common.DebugFlag() {
+			defer common.Stopwatch("Holotree lock command lasted").Report()
+		}
+		environment, err := conda.ReadPackageCondaYaml(args[0])
+		pretty.Guard(err == nil, 1, "Could not read %q, reason: %v", args[0], err)
+		pretty.Guard(!environment.IsExplicit(), 2, "File %q is already a lock file.", args[0])
+		platforms := environment.LockPlatforms(lockPlatforms)
+		for _, platform := range platforms {
+			_, ok := conda.LockablePlatform(platform)
+			pretty.Guard(ok, 2, "Platform %q cannot be locked, supported platforms are %q.", platform, conda.LockablePlatforms())
+		}
+		pipNeeded := false
+		for _, platform := range platforms {
+			selected, err := conda.ReadPackageCondaYamlFor(args[0], platform)
+			pretty.Guard(err == nil, 2, "Could not read %q for platform %q, reason: %v", args[0], platform, err)
+			pipNeeded = pipNeeded || selected.HasPipRequirements()
+		}
+		python := ""
+		if pipNeeded {
+			label, _, err := htfs.NewEnvironment(args[0], "", true, false, operations.PullCatalog)
+			pretty.Guard(err == nil, 3, "Could not build host environment for pip resolution, reason: %v", err)
+			found, ok := conda.FindPython(label)
+			pretty.Guard(ok, 3, "Could not find python from host environment %q.", label)
+			python = found
+		}
+		lock, err := conda.SolveLockfile(environment, args[0], python, platforms)
+		pretty.Guard(err == nil, 4, "Could not lock %q, reason: %v", args[0], err)
+		if len(lockOutput) == 0 {
+			content, err := lock.AsYaml()
+			pretty.Guard(err == nil, 5, "Could not create lock yaml, reason: %v", err)
+			common.Stdout("%s", content)
+			return
+		}
+		err = lock.SaveAs(lockOutput)
+		pretty.Guard(err == nil, 5, "Could not write %q, reason: %v", lockOutput, err)
+		common.Log("Wrote lock file %q for platforms %q.", lockOutput, lock.PlatformNames())
+		pretty.Ok()
+	},
+}
+
+func init() {
+	holotreeCmd.AddCommand(holotreeLockCmd)
+	holotreeLockCmd.Flags().StringVarP(&lockOutput, "output", "o", "", "Write lock file to this file instead of stdout.")
+	holotreeLockCmd.Flags().StringArrayVarP(&lockPlatforms, "platform", "p", []string{}, "Target platform to lock, like linux_amd64 (repeatable).")
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/htfs"
+	"github.com/robocorp/rcc/pretty"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	includePinned bool
+	pinSpace      string
+)
+
+func pinnedLabels(args []string) (htfs.Roots, []string) {
+	partials := make([]string, 0, len(args)+1)
+	partials = append(partials, args...)
+	if len(pinSpace) > 0 {
+		partials = append(partials, htfs.ControllerSpaceName([]byte(common.ControllerIdentity()), []byte(pinSpace)))
+	}
+	pretty.Guard(len(partials) > 0, 1, "Must provide either --space flag, or partial environment identity!")
+	_, roots := htfs.LoadCatalogs()
+	labels := roots.FindEnvironments(partials)
+	pretty.Guard(len(labels) > 0, 2, "No holotree spaces matched %q.", partials)
+	return roots, labels
+}
+
+var holotreePinCmd = &cobra.Command{
+	Use:   "pin <partial identity>*",
+	Short: "Pin holotree spaces, to protect them from cleanup, deletion, and eviction.",
+	Long: `Pin holotree spaces, to protect them from cleanup, deletion, and eviction.
+
+Pinned spaces are kept by "rcc configuration cleanup", "rcc holotree delete",
+"rcc holotree remove" (for catalogs used by pinned spaces), and disk budget
+eviction. Those commands have --include-pinned option to override this.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		roots, labels := pinnedLabels(args)
+		for _, label := range labels {
+			err := roots.PinHolotreeSpace(label)
+			pretty.Guard(err == nil, 3, "Error: %v", err)
+			common.Log("Pinned %v", label)
+		}
+		pretty.Ok()
+	},
+}
+
+var holotreeUnpinCmd = &cobra.Command{
+	Use:   "unpin <partial identity>*",
+	Short: "Unpin holotree spaces, so that they can be removed again.",
+	Long:  "Unpin holotree spaces, so that they can be removed again.",
+	Run: func(cmd *cobra.Command, args []string) {
+		roots, labels := pinnedLabels(args)
+		for _, label := range labels {
+			err := roots.UnpinHolotreeSpace(label)
+			pretty.Guard(err == nil, 3, "Error: %v", err)
+			common.Log("Unpinned %v", label)
+		}
+		pretty.Ok()
+	},
+}
+
+func init() {
+	holotreeCmd.AddCommand(holotreePinCmd)
+	holotreeCmd.AddCommand(holotreeUnpinCmd)
+	holotreePinCmd.Flags().StringVarP(&pinSpace, "space", "s", "", "Client specific name to identify environment to pin.")
+	holotreeUnpinCmd.Flags().StringVarP(&pinSpace, "space", "s", "", "Client specific name to identify environment to unpin.")
+}
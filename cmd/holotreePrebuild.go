@@ -5,11 +5,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/robocorp/rcc/cloud"
 	"github.com/robocorp/rcc/common"
 	"github.com/robocorp/rcc/conda"
 	"github.com/robocorp/rcc/htfs"
+	"github.com/robocorp/rcc/journal"
 	"github.com/robocorp/rcc/operations"
 	"github.com/robocorp/rcc/pathlib"
 	"github.com/robocorp/rcc/pretty"
@@ -18,9 +20,13 @@ import (
 )
 
 var (
-	metafileFlag bool
-	forceBuild   bool
-	exportFile   string
+	metafileFlag        bool
+	forceBuild          bool
+	exportFile          string
+	prebuildParallel    int
+	prebuildJsonReport  string
+	prebuildJunitReport string
+	prebuildReportTo    string
 )
 
 func conditionalExpand(filename string) string {
@@ -98,11 +104,50 @@ func metafileExpansion(links []string, expand bool) []string {
 	return result
 }
 
+func prebuildConfig(at, total int, configfile string) *operations.PrebuildResult {
+	started := time.Now()
+	result := &operations.PrebuildResult{
+		Config:   configfile,
+		Layers:   []*journal.LayerTiming{},
+		Catalogs: []string{},
+	}
+	environment, err := conda.ReadPackageCondaYaml(configfile)
+	if err != nil {
+		pretty.Warning("%d/%d: Failed to load %q, reason: %v (ignored)", at+1, total, configfile, err)
+		result.Skipped, result.Error = true, err.Error()
+		return result
+	}
+	pretty.Note("%d/%d: Now building config %q", at+1, total, configfile)
+	event := journal.ResetBuildEvent()
+	common.EnvironmentHash = ""
+	_, _, err = htfs.NewEnvironment(configfile, "", false, forceBuild, operations.PullCatalog)
+	result.Seconds = time.Since(started).Seconds()
+	result.Blueprint = common.EnvironmentHash
+	result.Layers = event.LayerTimings()
+	if err != nil {
+		pretty.Warning("%d/%d: Holotree recording error: %v", at+1, total, err)
+		result.Error = err.Error()
+		return result
+	}
+	result.Success = true
+	for _, hash := range environment.FingerprintLayers() {
+		key := htfs.CatalogName(hash)
+		if !set.Member(result.Catalogs, key) {
+			result.Catalogs = append(result.Catalogs, key)
+		}
+	}
+	return result
+}
+
 var holotreePrebuildCmd = &cobra.Command{
 	Use:   "prebuild",
 	Short: "Prebuild hololib from given set of environment descriptors.",
-	Long:  "Prebuild hololib from given set of environment descriptors. Requires shared holotree to be enabled and active.",
-	Args:  cobra.MinimumNArgs(1),
+	Long: `Prebuild hololib from given set of environment descriptors. Requires shared holotree to be enabled and active.
+
+With --parallel option, several environments are built at once, each in its own
+rcc process and isolated holotree stage. Results can be written as JSON report
+(--json-report) and JUnit XML report (--junit-report) for CI systems.`,
+	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		if common.DebugFlag() {
 			defer common.Stopwatch("Holotree prebuild lasted").Report()
@@ -111,34 +156,33 @@ var holotreePrebuildCmd = &cobra.Command{
 		pretty.Guard(common.SharedHolotree, 1, "Shared holotree must be enabled and in use for prebuild environments to work correctly.")
 
 		configurations := metafileExpansion(args, metafileFlag)
-		total, failed := len(configurations), 0
-		success := make([]string, 0, total)
-		exporting := len(exportFile) > 0
-		for at, configfile := range configurations {
-			environment, err := conda.ReadPackageCondaYaml(configfile)
-			if err != nil {
-				pretty.Warning("%d/%d: Failed to load %q, reason: %v (ignored)", at+1, total, configfile, err)
-				continue
-			}
-			pretty.Note("%d/%d: Now building config %q", at+1, total, configfile)
-			_, _, err = htfs.NewEnvironment(configfile, "", false, forceBuild, operations.PullCatalog)
-			if err != nil {
-				failed += 1
-				pretty.Warning("%d/%d: Holotree recording error: %v", at+1, total, err)
-			} else {
-				for _, hash := range environment.FingerprintLayers() {
-					key := htfs.CatalogName(hash)
-					if exporting && !set.Member(success, key) {
-						success = append(success, key)
-						pretty.Note("Added catalog %q to be exported.", key)
-					}
-				}
+		total, started := len(configurations), time.Now()
+		reporting := len(prebuildJsonReport) > 0 || len(prebuildJunitReport) > 0
+		var report *operations.PrebuildReport
+		if len(prebuildReportTo) == 0 && (prebuildParallel > 1 || reporting) {
+			pretty.Note("Prebuilding %d configurations with %d parallel workers.", total, prebuildParallel)
+			report = operations.ParallelPrebuild(configurations, prebuildParallel, forceBuild)
+		} else {
+			report = operations.NewPrebuildReport(1)
+			for at, configfile := range configurations {
+				report.Add(prebuildConfig(at, total, configfile))
 			}
+			report.Seconds = time.Since(started).Seconds()
+		}
+		if len(prebuildReportTo) > 0 {
+			err := report.WriteJson(prebuildReportTo)
+			pretty.Guard(err == nil, 3, "Could not write report %q, reason: %v", prebuildReportTo, err)
 		}
-		if exporting && len(success) > 0 {
+		err := operations.WritePrebuildReports(report, prebuildJsonReport, prebuildJunitReport)
+		pretty.Guard(err == nil, 3, "%v", err)
+		success := report.Catalogs()
+		if len(exportFile) > 0 && len(success) > 0 {
+			for _, key := range success {
+				pretty.Note("Added catalog %q to be exported.", key)
+			}
 			holotreeExport(selectCatalogs(success), nil, exportFile)
 		}
-		pretty.Guard(failed == 0, 2, "%d out of %d environment builds failed! See output above for details.", failed, total)
+		pretty.Guard(report.Failed == 0, 2, "%d out of %d environment builds failed! See output above for details.", report.Failed, total)
 		pretty.Ok()
 	},
 }
@@ -148,4 +192,9 @@ func init() {
 	holotreePrebuildCmd.Flags().BoolVarP(&metafileFlag, "metafile", "m", false, "Input arguments are actually files containing links/filenames of environment descriptors.")
 	holotreePrebuildCmd.Flags().BoolVarP(&forceBuild, "force", "f", false, "Force environment builds, even when blueprint is already present.")
 	holotreePrebuildCmd.Flags().StringVarP(&exportFile, "export", "e", "", "Optional filename to export new, successfully build catalogs.")
+	holotreePrebuildCmd.Flags().IntVarP(&prebuildParallel, "parallel", "p", 1, "Number of environments to build in parallel (each in separate rcc process).")
+	holotreePrebuildCmd.Flags().StringVarP(&prebuildJsonReport, "json-report", "", "", "Optional filename to write JSON build report into.")
+	holotreePrebuildCmd.Flags().StringVarP(&prebuildJunitReport, "junit-report", "", "", "Optional filename to write JUnit XML build report into.")
+	holotreePrebuildCmd.Flags().StringVarP(&prebuildReportTo, "report-to", "", "", "internal, DO NOT USE (used by parallel prebuild workers)")
+	holotreePrebuildCmd.Flags().MarkHidden("report-to")
 }
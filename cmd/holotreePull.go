@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"encoding/json"
+
 	"github.com/robocorp/rcc/common"
 	"github.com/robocorp/rcc/htfs"
 	"github.com/robocorp/rcc/operations"
 	"github.com/robocorp/rcc/pretty"
+	"github.com/robocorp/rcc/settings"
 	"github.com/spf13/cobra"
 )
 
@@ -12,39 +15,71 @@ var (
 	remoteOriginOption string
 	pullRobot          string
 	forcePull          bool
+	fastestPull        bool
 )
 
+type pullReport struct {
+	Catalog string   `json:"catalog"`
+	Origins []string `json:"origins"`
+	Origin  string   `json:"origin"`
+	Pulled  bool     `json:"pulled"`
+}
+
 var holotreePullCmd = &cobra.Command{
 	Use:   "pull",
 	Short: "Try to pull existing holotree catalog from remote source.",
-	Long:  "Try to pull existing holotree catalog from remote source.",
+	Long: `Try to pull existing holotree catalog from remote source.
+
+Origin can be list of rccremote URLs (separated by commas), and they are
+tried in given order until one of them succeeds. With --fastest option all
+origins are probed first, and then tried in order of their response time.
+
+Missing parts are downloaded in batches (in parallel), and each batch is
+verified and imported as soon as it completes. If pull is interrupted, next
+pull continues from where previous one stopped.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if common.DebugFlag() {
 			defer common.Stopwatch("Holotree pull command lasted").Report()
 		}
+		origins := common.SplitOrigins(remoteOriginOption)
+		if len(origins) == 0 {
+			origins = settings.Global.RemoteOrigins()
+		}
+		pretty.Guard(len(origins) > 0, 4, "No remote origins given. Use --origin option, RCC_REMOTE_ORIGIN, or settings.yaml.")
 		_, holotreeBlueprint, err := htfs.ComposeFinalBlueprint(nil, pullRobot)
 		pretty.Guard(err == nil, 1, "Blueprint calculation failed: %v", err)
 		hash := common.BlueprintHash(holotreeBlueprint)
 		tree, err := htfs.New()
 		pretty.Guard(err == nil, 2, "%s", err)
 
+		report := &pullReport{
+			Catalog: htfs.CatalogName(hash),
+			Origins: origins,
+		}
 		present := tree.HasBlueprint(holotreeBlueprint)
 		if !present || forcePull {
-			catalog := htfs.CatalogName(hash)
-			err = operations.PullCatalog(remoteOriginOption, catalog, true)
+			fastest := fastestPull || settings.Global.FastestRemoteOrigin()
+			report.Origin, err = operations.PullCatalogFrom(origins, report.Catalog, true, fastest)
 			pretty.Guard(err == nil, 3, "%s", err)
+			report.Pulled = true
+			common.Log("Pulled %q from %q.", report.Catalog, report.Origin)
+		}
+		if jsonFlag {
+			nice, err := json.MarshalIndent(report, "", "  ")
+			pretty.Guard(err == nil, 5, "%s", err)
+			common.Stdout("%s\n", nice)
 		}
 		pretty.Ok()
 	},
 }
 
 func init() {
-	origin := common.RccRemoteOrigin()
 	holotreeCmd.AddCommand(holotreePullCmd)
 	holotreePullCmd.Flags().BoolVarP(&forcePull, "force", "", false, "Force pull check, even when blueprint is already present.")
-	holotreePullCmd.Flags().StringVarP(&remoteOriginOption, "origin", "o", origin, "URL of remote origin to pull environment from.")
+	holotreePullCmd.Flags().BoolVarP(&fastestPull, "fastest", "", false, "Probe all origins first, and pull from fastest one that has the catalog.")
+	holotreePullCmd.Flags().StringVarP(&remoteOriginOption, "origin", "o", common.RccRemoteOrigin(), "URL(s) of remote origin to pull environment from.")
+	holotreePullCmd.Flags().IntVarP(&operations.PullBatchSize, "batch", "", operations.PullBatchSize, "Maximum number of hololib parts to download in one batch.")
+	holotreePullCmd.Flags().IntVarP(&operations.PullWorkers, "workers", "", operations.PullWorkers, "Number of batches to download in parallel.")
 	holotreePullCmd.Flags().StringVarP(&pullRobot, "robot", "r", "robot.yaml", "Full path to 'robot.yaml' configuration file to export as catalog. <optional>")
-	if len(origin) == 0 {
-		holotreePullCmd.MarkFlagRequired("origin")
-	}
+	holotreePullCmd.Flags().BoolVarP(&jsonFlag, "json", "j", false, "Output pull report in JSON format.")
 }
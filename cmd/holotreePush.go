@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/htfs"
+	"github.com/robocorp/rcc/operations"
+	"github.com/robocorp/rcc/pathlib"
+	"github.com/robocorp/rcc/pretty"
+	"github.com/robocorp/rcc/settings"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pushOrigin  string
+	pushZipfile string
+	pushRobot   string
+)
+
+var holotreePushCmd = &cobra.Command{
+	Use:   "push catalog*",
+	Short: "Push holotree catalogs and their library parts to rccremote.",
+	Long: `Push holotree catalogs and their library parts to rccremote.
+
+Selected catalogs are exported into temporary hololib.zip and uploaded to
+first rccremote given with --origin (default is RCC_REMOTE_ORIGIN).
+Alternatively already exported hololib.zip can be pushed using --zipfile
+option. Value of RCC_REMOTE_AUTHORIZATION is used as authorization token.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if common.DebugFlag() {
+			defer common.Stopwatch("Holotree push command lasted").Report()
+		}
+		origins := common.SplitOrigins(pushOrigin)
+		if len(origins) == 0 {
+			origins = settings.Global.RemoteOrigins()
+		}
+		pretty.Guard(len(origins) > 0, 1, "No origin given. Use --origin option, RCC_REMOTE_ORIGIN, or settings.yaml.")
+		origin := origins[0]
+		if len(pushRobot) > 0 {
+			_, holotreeBlueprint, err := htfs.ComposeFinalBlueprint(nil, pushRobot)
+			pretty.Guard(err == nil, 2, "Blueprint calculation failed: %v", err)
+			hash := common.BlueprintHash(holotreeBlueprint)
+			args = append(args, htfs.CatalogName(hash))
+		}
+		zipfile := pushZipfile
+		if len(zipfile) == 0 {
+			pretty.Guard(len(args) > 0, 3, "Give catalogs to push, or use --robot or --zipfile options.")
+			catalogs := selectCatalogs(args)
+			pretty.Guard(len(catalogs) > 0, 4, "None of given catalogs %q are in hololib.", args)
+			zipfile = filepath.Join(pathlib.TempDir(), fmt.Sprintf("hololib_push_%x.zip", os.Getpid()))
+			defer pathlib.TryRemove("push", zipfile)
+			holotreeExport(catalogs, nil, zipfile)
+		}
+		pushed, err := operations.PushHololibZip(origin, zipfile)
+		pretty.Guard(err == nil, 5, "Push to %q failed, reason: %v", origin, err)
+		if jsonFlag {
+			nice, err := json.MarshalIndent(pushed, "", "  ")
+			pretty.Guard(err == nil, 6, "%s", err)
+			common.Stdout("%s\n", nice)
+		} else {
+			common.Log("Pushed catalogs to %q:", origin)
+			for _, catalog := range pushed {
+				common.Log("- %s", catalog)
+			}
+		}
+		pretty.Ok()
+	},
+}
+
+func init() {
+	holotreeCmd.AddCommand(holotreePushCmd)
+	holotreePushCmd.Flags().StringVarP(&pushOrigin, "origin", "o", common.RccRemoteOrigin(), "URL of rccremote to push catalogs to.")
+	holotreePushCmd.Flags().StringVarP(&pushZipfile, "zipfile", "z", "", "Push existing hololib.zip instead of exporting catalogs. <optional>")
+	holotreePushCmd.Flags().StringVarP(&pushRobot, "robot", "r", "", "Full path to 'robot.yaml' configuration file to push as catalog. <optional>")
+	holotreePushCmd.Flags().BoolVarP(&jsonFlag, "json", "j", false, "Output pushed catalogs in JSON format.")
+}
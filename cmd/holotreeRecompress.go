@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"encoding/json"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/htfs"
+	"github.com/robocorp/rcc/pathlib"
+	"github.com/robocorp/rcc/pretty"
+	"github.com/spf13/cobra"
+)
+
+var holotreeRecompressCmd = &cobra.Command{
+	Use:   "recompress",
+	Short: "Convert hololib library parts to currently configured compression format.",
+	Long: `Convert hololib library parts to currently configured compression format.
+
+New parts are compressed using zstd by default (or gzip, if configured so in
+settings.yaml holotree/compression). Reading works with mixed libraries, so
+this command is optional, but converting old gzip parts makes restores faster.
+Libraries without compression are converted into uncompressed form.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if common.DebugFlag() {
+			defer common.Stopwatch("Holotree recompress command lasted").Report()
+		}
+		report, err := htfs.Recompress(dryFlag)
+		pretty.Guard(err == nil, 1, "Recompression failed, reason: %v", err)
+		if jsonFlag {
+			nice, err := json.MarshalIndent(report, "", "  ")
+			pretty.Guard(err == nil, 2, "%s", err)
+			common.Stdout("%s\n", nice)
+		} else {
+			var note string
+			if report.DryRun {
+				note = "[dry run] "
+			}
+			common.Log("%sLibrary parts: %d [raw: %d, gzip: %d, zstd: %d].", note, report.Examined, report.Formats[htfs.BlobRaw], report.Formats[htfs.BlobGzip], report.Formats[htfs.BlobZstd])
+			common.Log("%sConverted to %s: %d parts.", note, report.Target, report.Converted)
+			if !report.DryRun {
+				value, suffix := pathlib.HumaneSizer(report.Saved())
+				common.Log("Size change: %d -> %d bytes (saved %3.1f%s).", report.Before, report.After, value, suffix)
+			}
+		}
+		pretty.Guard(report.Failures == 0, 3, "Failed to recompress %d parts. See debug output for details.", report.Failures)
+		pretty.Ok()
+	},
+}
+
+func init() {
+	holotreeCmd.AddCommand(holotreeRecompressCmd)
+	holotreeRecompressCmd.Flags().BoolVarP(&dryFlag, "dryrun", "d", false, "Don't convert anything, just show what would be converted.")
+	holotreeRecompressCmd.Flags().BoolVarP(&jsonFlag, "json", "j", false, "Output report in JSON format.")
+}
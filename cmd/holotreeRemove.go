@@ -12,6 +12,26 @@ var (
 	unusedDays         int
 )
 
+func withoutPinnedCatalogs(catalogs []string) []string {
+	if includePinned {
+		return catalogs
+	}
+	_, roots := htfs.LoadCatalogs()
+	pinned := make(map[string]bool)
+	for blueprint := range roots.PinnedBlueprints() {
+		pinned[htfs.CatalogName(blueprint)] = true
+	}
+	result := make([]string, 0, len(catalogs))
+	for _, catalog := range catalogs {
+		if pinned[catalog] {
+			pretty.Warning("Skipping catalog %s, since it is used by pinned space (use --include-pinned to remove it anyway).", catalog)
+			continue
+		}
+		result = append(result, catalog)
+	}
+	return result
+}
+
 func holotreeRemove(catalogs []string) {
 	if len(catalogs) == 0 {
 		pretty.Warning("No catalogs given, so nothing to do. Quitting!")
@@ -52,7 +72,7 @@ var holotreeRemoveCmd = &cobra.Command{
 		if unusedDays > 0 {
 			args = append(args, allUnusedCatalogs(unusedDays)...)
 		}
-		holotreeRemove(selectCatalogs(args))
+		holotreeRemove(withoutPinnedCatalogs(selectCatalogs(args)))
 		if removeCheckRetries > 0 {
 			checkLoop(removeCheckRetries)
 		} else {
@@ -64,6 +84,7 @@ var holotreeRemoveCmd = &cobra.Command{
 
 func init() {
 	holotreeRemoveCmd.Flags().IntVarP(&removeCheckRetries, "check", "c", 0, "Additionally run holotree check with this many times.")
+	holotreeRemoveCmd.Flags().BoolVarP(&includePinned, "include-pinned", "", false, "Remove also catalogs that are used by pinned spaces.")
 	holotreeRemoveCmd.Flags().IntVarP(&unusedDays, "unused", "", 0, "Remove idle/unused catalog entries based on idle days when value is above given limit.")
 	holotreeCmd.AddCommand(holotreeRemoveCmd)
 }
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"encoding/json"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/htfs"
+	"github.com/robocorp/rcc/pathlib"
+	"github.com/robocorp/rcc/pretty"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sbomFormat       string
+	sbomOutput       string
+	sbomPackagesOnly bool
+)
+
+var holotreeSbomCmd = &cobra.Command{
+	Use:   "sbom <catalog|space|conda.yaml>",
+	Short: "Create software bill of materials from holotree catalog or space.",
+	Long: `Create software bill of materials from holotree catalog or space.
+
+Target can be a catalog (name or path), a holotree space (name or path), or
+conda.yaml file whose environment is already built into hololib. Packages are
+collected from golden-ee.yaml, conda-meta records and pip dist-info metadata.
+File hashes are taken from catalog digests.
+
+Output formats are "cyclonedx" (CycloneDX 1.5 JSON) and "spdx" (SPDX 2.3 JSON).`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if common.DebugFlag() {
+			defer common.Stopwatch("Holotree sbom command lasted").Report()
+		}
+		root, err := htfs.ResolveSbomSource(args[0])
+		pretty.Guard(err == nil, 1, "Could not find %q, reason: %v", args[0], err)
+		sbom := htfs.NewSbom(root, htfs.LibraryBlob, !sbomPackagesOnly)
+		var document any
+		switch sbomFormat {
+		case htfs.SbomCycloneDX:
+			document = sbom.CycloneDX()
+		case htfs.SbomSpdx:
+			document = sbom.Spdx()
+		default:
+			pretty.Exit(2, "Unknown SBOM format %q. Use %q or %q.", sbomFormat, htfs.SbomCycloneDX, htfs.SbomSpdx)
+		}
+		body, err := json.MarshalIndent(document, "", "  ")
+		pretty.Guard(err == nil, 3, "Could not create json, reason: %v", err)
+		if len(sbomOutput) == 0 {
+			common.Stdout("%s\n", body)
+			return
+		}
+		err = pathlib.WriteFile(sbomOutput, append(body, '\n'), 0o644)
+		pretty.Guard(err == nil, 4, "Could not write %q, reason: %v", sbomOutput, err)
+		common.Log("Wrote %s SBOM with %d packages and %d files to %q.", sbomFormat, len(sbom.Packages), len(sbom.Files), sbomOutput)
+		pretty.Ok()
+	},
+}
+
+func init() {
+	holotreeCmd.AddCommand(holotreeSbomCmd)
+	holotreeSbomCmd.Flags().StringVarP(&sbomFormat, "format", "f", htfs.SbomCycloneDX, "Output format: cyclonedx or spdx.")
+	holotreeSbomCmd.Flags().StringVarP(&sbomOutput, "output", "o", "", "Write SBOM to this file instead of stdout.")
+	holotreeSbomCmd.Flags().BoolVarP(&sbomPackagesOnly, "packages-only", "", false, "Only list packages, leave individual files out.")
+}
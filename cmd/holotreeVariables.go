@@ -147,10 +147,10 @@ func removeUnwanted(variables []string) []string {
 }
 
 var holotreeVariablesCmd = &cobra.Command{
-	Use:     "variables conda.yaml+",
+	Use:     "variables <conda.yaml|pyproject.toml|requirements.txt>+",
 	Aliases: []string{"vars"},
 	Short:   "Do holotree operations.",
-	Long:    "Do holotree operations.",
+	Long:    "Do holotree operations.\n\nEnvironment files can be conda.yaml, package.yaml, pyproject.toml, or\nrequirements.txt (any \"*requirements*.txt\") files.",
 	Run: func(cmd *cobra.Command, args []string) {
 		defer journal.BuildEventStats("variables")
 		if common.DebugFlag() {
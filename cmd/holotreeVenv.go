@@ -24,15 +24,15 @@ func deleteByExactIdentity(exact string) {
 	_, roots := htfs.LoadCatalogs()
 	for _, label := range roots.FindEnvironments([]string{exact}) {
 		common.Log("Removing %v", label)
-		err := roots.RemoveHolotreeSpace(label)
+		err := roots.RemoveHolotreeSpace(label, false)
 		pretty.Guard(err == nil, 4, "Error: %v", err)
 	}
 }
 
 var holotreeVenvCmd = &cobra.Command{
-	Use:   "venv conda.yaml+",
+	Use:   "venv <conda.yaml|pyproject.toml|requirements.txt>+",
 	Short: "Create user managed virtual python environment inside automation folder.",
-	Long:  "Create user managed virtual python environment inside automation folder.",
+	Long:  "Create user managed virtual python environment inside automation folder.\n\nEnvironment files can be conda.yaml, package.yaml, pyproject.toml, or\nrequirements.txt (any \"*requirements*.txt\") files.",
 	Args:  cobra.MinimumNArgs(1),
 
 	Run: func(cmd *cobra.Command, args []string) {
@@ -18,9 +18,9 @@ func dumpYaml(title string, environment *conda.Environment) {
 }
 
 var mergeCmd = &cobra.Command{
-	Use:   "merge conda.yaml+",
+	Use:   "merge <conda.yaml|pyproject.toml|requirements.txt>+",
 	Short: "Tool for testing conda.yaml merging.",
-	Long:  "Tool for testing conda.yaml merging.",
+	Long:  "Tool for testing conda.yaml merging.\n\nEnvironment files can be conda.yaml, package.yaml, pyproject.toml, or\nrequirements.txt (any \"*requirements*.txt\") files.",
 	Run: func(cmd *cobra.Command, args []string) {
 		var left, right *conda.Environment
 		var err error
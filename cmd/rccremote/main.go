@@ -19,6 +19,13 @@ var (
 	holdingArea string
 	debugFlag   bool
 	traceFlag   bool
+	tokensFile  string
+	auditFile   string
+	certFile    string
+	keyFile     string
+	clientCA    string
+	deltaCache  int64
+	streamDelta bool
 )
 
 func defaultHoldLocation() string {
@@ -38,6 +45,13 @@ func init() {
 	flag.IntVar(&serverPort, "port", 4653, "Port to bind server in given hostname.")
 	flag.StringVar(&holdingArea, "hold", defaultHoldLocation(), "Directory where to put HOLD files once known.")
 	flag.StringVar(&domainId, "domain", "personal", "Symbolic domain that this peer serves.")
+	flag.StringVar(&tokensFile, "tokens", "", "YAML file of authorization tokens and their scopes. Without it, all requests are allowed.")
+	flag.StringVar(&auditFile, "audit", "", "File where to append authorization decisions as JSON lines (in addition to log).")
+	flag.StringVar(&certFile, "cert", "", "PEM certificate file for serving HTTPS. Requires also -key.")
+	flag.StringVar(&keyFile, "key", "", "PEM private key file matching -cert certificate.")
+	flag.StringVar(&clientCA, "client-ca", "", "PEM CA bundle to verify client certificates against (mutual TLS). Requires -cert and -key.")
+	flag.Int64Var(&deltaCache, "delta-cache", 2048, "Size budget in megabytes for cached delta zips. Least recently used are evicted first.")
+	flag.BoolVar(&streamDelta, "stream", false, "Stream delta zips to clients while they are being built into cache.")
 }
 
 func ExitProtection() {
@@ -66,7 +80,20 @@ func process() {
 	}
 	pretty.Guard(common.SharedHolotree, 1, "Shared holotree must be enabled and in use for rccremote to work.")
 	common.Log("Remote for rcc starting (%s) ...", common.Version)
-	remotree.Serve(serverName, serverPort, domainId, holdingArea)
+	err := remotree.Serve(&remotree.Options{
+		Hostname:    serverName,
+		Port:        serverPort,
+		Domain:      domainId,
+		Holding:     holdingArea,
+		Tokens:      tokensFile,
+		Auditlog:    auditFile,
+		Certificate: certFile,
+		Key:         keyFile,
+		ClientCA:    clientCA,
+		DeltaCache:  deltaCache * 1024 * 1024,
+		Stream:      streamDelta,
+	})
+	pretty.Guard(err == nil, 2, "Remote for rcc failed, reason: %v", err)
 }
 
 func main() {
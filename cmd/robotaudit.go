@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/conda"
+	"github.com/robocorp/rcc/operations"
+	"github.com/robocorp/rcc/pretty"
+
+	"github.com/spf13/cobra"
+)
+
+var robotAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Audit dependencies of robot execution environment against offline OSV database.",
+	Long: `Audit dependencies of robot execution environment against offline OSV database.
+
+See "rcc holotree audit -h" for details about OSV database.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if common.DebugFlag() {
+			defer common.Stopwatch("Robot audit run lasted").Report()
+		}
+		database := loadAuditDatabase()
+		simple, _, _, label := operations.LoadAnyTaskEnvironment(robotFile, forceFlag)
+		pretty.Guard(!simple, 1, "Cannot audit dependencies of simple robots.")
+		reportAudit(conda.LoadWantedDependencies(conda.GoldenMasterFilename(label)).Audit(database))
+		pretty.Ok()
+	},
+}
+
+func init() {
+	robotCmd.AddCommand(robotAuditCmd)
+	robotAuditCmd.Flags().StringVarP(&auditDatabase, "database", "", "", "Location of OSV database (folder, zip, or json file). Default is 'osv' under ROBOCORP_HOME.")
+	robotAuditCmd.Flags().StringVarP(&auditFailOn, "fail-on", "", "", "Fail when vulnerabilities with this or higher severity are found (low, medium, high, critical).")
+	robotAuditCmd.Flags().BoolVarP(&jsonFlag, "json", "j", false, "Output audit report in JSON format.")
+	robotAuditCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Forced environment update.")
+	robotAuditCmd.Flags().StringVarP(&robotFile, "robot", "r", "robot.yaml", "Full path to the 'robot.yaml' configuration file.")
+	robotAuditCmd.Flags().StringVarP(&common.HolotreeSpace, "space", "s", "user", "Space to use for execution environment dependencies.")
+}
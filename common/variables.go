@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/robocorp/rcc/set"
 )
@@ -29,6 +30,9 @@ const (
 	RCC_REMOTE_AUTHORIZATION              = `RCC_REMOTE_AUTHORIZATION`
 	RCC_NO_TEMP_MANAGEMENT                = `RCC_NO_TEMP_MANAGEMENT`
 	RCC_NO_PYC_MANAGEMENT                 = `RCC_NO_PYC_MANAGEMENT`
+	RCC_ZIP_MAX_ENTRIES                   = `RCC_ZIP_MAX_ENTRIES`
+	RCC_ZIP_MAX_SIZE                      = `RCC_ZIP_MAX_SIZE`
+	RCC_ZIP_MAX_RATIO                     = `RCC_ZIP_MAX_RATIO`
 	VERBOSE_ENVIRONMENT_BUILDING          = `RCC_VERBOSE_ENVIRONMENT_BUILDING`
 	ROBOCORP_OVERRIDE_SYSTEM_REQUIREMENTS = `ROBOCORP_OVERRIDE_SYSTEM_REQUIREMENTS`
 	RCC_VERBOSITY                         = `RCC_VERBOSITY`
@@ -136,6 +140,21 @@ func RccRemoteOrigin() string {
 	return os.Getenv(RCC_REMOTE_ORIGIN)
 }
 
+func RccRemoteOrigins() []string {
+	return SplitOrigins(RccRemoteOrigin())
+}
+
+func SplitOrigins(text string) []string {
+	separator := func(char rune) bool {
+		return char == ',' || char == ';' || unicode.IsSpace(char)
+	}
+	result := []string{}
+	for _, origin := range strings.FieldsFunc(text, separator) {
+		result = append(result, strings.TrimRight(origin, "/"))
+	}
+	return result
+}
+
 func RccRemoteAuthorization() (string, bool) {
 	result := os.Getenv(RCC_REMOTE_AUTHORIZATION)
 	return result, len(result) > 0
@@ -189,6 +208,10 @@ func JournalLocation() string {
 	return filepath.Join(Product.Home(), "journals")
 }
 
+func OsvLocation() string {
+	return filepath.Join(Product.Home(), "osv")
+}
+
 func TemplateLocation() string {
 	return filepath.Join(Product.Home(), "templates")
 }
@@ -276,6 +299,10 @@ func HolotreeLock() string {
 	return filepath.Join(HolotreeLocation(), "global.lck")
 }
 
+func BlueprintLock(hash string) string {
+	return filepath.Join(HolotreeLocation(), fmt.Sprintf("build_%s.lck", hash))
+}
+
 func BadHololibSitePackagesLocation() string {
 	return filepath.Join(HololibLocation(), "site-packages")
 }
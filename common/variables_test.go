@@ -0,0 +1,25 @@
+package common_test
+
+import (
+	"testing"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/hamlet"
+)
+
+func TestCanSplitRemoteOrigins(t *testing.T) {
+	must_be, _ := hamlet.Specifications(t)
+
+	must_be.Equal(0, len(common.SplitOrigins("")))
+	must_be.Equal(0, len(common.SplitOrigins(" , ; ")))
+
+	origins := common.SplitOrigins("https://one.example.com/")
+	must_be.Equal(1, len(origins))
+	must_be.Equal("https://one.example.com", origins[0])
+
+	origins = common.SplitOrigins("https://one.example.com, http://two.example.com:4653;https://three.example.com/\n")
+	must_be.Equal(3, len(origins))
+	must_be.Equal("https://one.example.com", origins[0])
+	must_be.Equal("http://two.example.com:4653", origins[1])
+	must_be.Equal("https://three.example.com", origins[2])
+}
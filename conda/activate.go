@@ -99,7 +99,7 @@ func diffStringMaps(before, after map[string]string) map[string]string {
 	return result
 }
 
-func Activate(sink io.Writer, targetFolder string) error {
+func Activate(sink io.Writer, targetFolder string, variables map[string]string) error {
 	envCommand := []string{common.BinRcc(), "internal", "env", "--label", "before"}
 	out, _, err := LiveCapture(targetFolder, envCommand...)
 	if err != nil {
@@ -134,6 +134,9 @@ func Activate(sink io.Writer, targetFolder string) error {
 		return err
 	}
 	difference := diffStringMaps(before, after)
+	for name, value := range expandVariables(variables, targetFolder) {
+		difference[name] = value
+	}
 	body, err := json.MarshalIndent(difference, "", "  ")
 	if err != nil {
 		return err
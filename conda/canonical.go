@@ -0,0 +1,185 @@
+package conda
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	pep503Separators = regexp.MustCompile(`[-_.]+`)
+	extrasPattern    = regexp.MustCompile(`^([^\[]+)\[([^\]]*)\]$`)
+	prereleaseNames  = map[int]string{-3: "a", -2: "b", -1: "rc"}
+	condaJoiners     = regexp.MustCompile(`\s*([,|])\s*`)
+	condaOperators   = regexp.MustCompile(`([<=~!>]+)\s+`)
+)
+
+func Pep503Name(name string) string {
+	return strings.ToLower(pep503Separators.ReplaceAllString(strings.TrimSpace(name), "-"))
+}
+
+func canonicalPipName(name string) string {
+	match := extrasPattern.FindStringSubmatch(strings.TrimSpace(name))
+	if match == nil {
+		return Pep503Name(name)
+	}
+	extras := []string{}
+	for _, extra := range strings.Split(match[2], ",") {
+		if len(strings.TrimSpace(extra)) > 0 {
+			extras = append(extras, Pep503Name(extra))
+		}
+	}
+	sort.Strings(extras)
+	return fmt.Sprintf("%s[%s]", Pep503Name(match[1]), strings.Join(extras, ","))
+}
+
+func (it *Version) Canonical(trimZeros bool) string {
+	if !it.Pep440 {
+		return strings.ToLower(strings.TrimSpace(it.Original))
+	}
+	builder := strings.Builder{}
+	if it.Epoch != 0 {
+		fmt.Fprintf(&builder, "%d!", it.Epoch)
+	}
+	release := it.Release
+	if trimZeros {
+		release = append([]int{}, it.Release...)
+		for len(release) > 1 && release[len(release)-1] == 0 {
+			release = release[:len(release)-1]
+		}
+	} else {
+		release = releaseAsWritten(it.Original)
+	}
+	for at, number := range release {
+		if at > 0 {
+			builder.WriteString(".")
+		}
+		fmt.Fprintf(&builder, "%d", number)
+	}
+	if name, ok := prereleaseNames[it.PreKind]; ok {
+		fmt.Fprintf(&builder, "%s%d", name, it.PreNum)
+	}
+	if it.Post >= 0 {
+		fmt.Fprintf(&builder, ".post%d", it.Post)
+	}
+	if it.Dev >= 0 {
+		fmt.Fprintf(&builder, ".dev%d", it.Dev)
+	}
+	if len(it.Local) > 0 {
+		fmt.Fprintf(&builder, "+%s", pep503Separators.ReplaceAllString(it.Local, "."))
+	}
+	return builder.String()
+}
+
+func releaseAsWritten(text string) []int {
+	match := pep440Pattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(text)))
+	result := []int{}
+	if match == nil {
+		return result
+	}
+	for _, part := range strings.Split(match[2], ".") {
+		result = append(result, atoi(part, 0))
+	}
+	return result
+}
+
+func (it *VersionClause) canonical() string {
+	switch {
+	case it.Operator == "===":
+		return it.Operator + it.Version
+	case strings.HasSuffix(it.Version, "*"):
+		bare := strings.TrimRight(strings.TrimSuffix(it.Version, "*"), ".")
+		return it.Operator + ParseVersion(bare).Canonical(false) + ".*"
+	case it.Operator == "~=":
+		return it.Operator + ParseVersion(it.Version).Canonical(false)
+	default:
+		return it.Operator + ParseVersion(it.Version).Canonical(true)
+	}
+}
+
+func canonicalPip(dependency *Dependency) string {
+	if strings.HasPrefix(dependency.Name, "-") || strings.ContainsAny(dependency.Versions, ";@") || strings.Contains(dependency.Name, "://") {
+		return strings.Join(strings.Fields(dependency.Original), " ")
+	}
+	name := canonicalPipName(dependency.Name)
+	spec := strings.TrimSpace(dependency.Qualifier + dependency.Versions)
+	if len(spec) == 0 {
+		return name
+	}
+	clauses, err := ParseSpecifiers(spec)
+	if err != nil {
+		return strings.Join(strings.Fields(dependency.Original), " ")
+	}
+	parts := make([]string, 0, len(clauses))
+	for _, clause := range clauses {
+		parts = append(parts, clause.canonical())
+	}
+	sort.Strings(parts)
+	result := []string{name + strings.Join(parts, ",")}
+	hashes := append([]string{}, dependency.Hashes...)
+	sort.Strings(hashes)
+	for _, hash := range hashes {
+		result = append(result, "--hash="+hash)
+	}
+	return strings.Join(result, " ")
+}
+
+func canonicalConda(dependency *Dependency) string {
+	if dependency.IsExplicit() {
+		return strings.TrimSpace(dependency.Original)
+	}
+	name := strings.ToLower(dependency.Name)
+	spec := strings.ToLower(dependency.Qualifier + dependency.Versions)
+	spec = condaJoiners.ReplaceAllString(spec, "$1")
+	spec = condaOperators.ReplaceAllString(spec, "$1")
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return name
+	}
+	separator := ""
+	if !strings.ContainsAny(fields[0][:1], "<=>!~") {
+		separator = " "
+	}
+	return name + separator + strings.Join(fields, " ")
+}
+
+func canonicalList(source []*Dependency, canonical func(*Dependency) string) []interface{} {
+	options := []string{}
+	packages := []string{}
+	for _, dependency := range source {
+		if strings.HasPrefix(dependency.Name, "-") {
+			options = append(options, canonical(dependency))
+			continue
+		}
+		packages = append(packages, canonical(dependency))
+	}
+	sort.Strings(packages)
+	result := make([]interface{}, 0, len(options)+len(packages)+1)
+	for _, entry := range append(options, packages...) {
+		result = append(result, entry)
+	}
+	return result
+}
+
+func (it *Environment) AsCanonicalYaml() (string, error) {
+	result := new(internalEnvironment)
+	result.Prefix = it.Prefix
+	result.Channels = append([]string{}, it.Channels...)
+	result.Dependencies = canonicalList(it.Conda, canonicalConda)
+	seenScripts := make(map[string]bool)
+	result.PostInstall = addItem(seenScripts, it.PostInstall, result.PostInstall)
+	result.Variables = it.Variables
+	if len(it.Pip) > 0 {
+		pip := make(map[interface{}]interface{})
+		pip["pip"] = canonicalList(it.Pip, canonicalPip)
+		result.Dependencies = append(result.Dependencies, pip)
+	}
+	content, err := yaml.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
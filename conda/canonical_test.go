@@ -0,0 +1,121 @@
+package conda_test
+
+import (
+	"testing"
+
+	"github.com/robocorp/rcc/conda"
+	"github.com/robocorp/rcc/hamlet"
+)
+
+const (
+	cosmeticFirst = `
+name: first
+channels:
+- conda-forge
+- nodefaults
+dependencies:
+- python=3.10.12
+- pip = 23.2.1
+- numpy >= 1.24 , < 2  py310_0
+- pip:
+  - --use-feature=truststore
+  - Requests==2.31
+  - robocorp_tasks[Extra_one,cli] >= 2.0, <3
+  - urllib3~=2.0.0
+`
+	cosmeticSecond = `
+name: second
+channels:
+- conda-forge
+- nodefaults
+dependencies:
+- numpy>=1.24,<2 py310_0
+- pip=23.2.1
+- python=3.10.12
+- pip:
+  - urllib3 ~= 2.0.0
+  - robocorp-tasks[cli,extra-one]<3,>=2.0.0
+  - --use-feature=truststore
+  - requests ==2.31.0
+`
+)
+
+func TestCanNormalizeNamesAndVersions(t *testing.T) {
+	must_be, _ := hamlet.Specifications(t)
+
+	must_be.Equal("friendly-bard", conda.Pep503Name("Friendly-Bard"))
+	must_be.Equal("friendly-bard", conda.Pep503Name("FRIENDLY_._BARD"))
+	must_be.Equal("1.2", conda.ParseVersion("1.2.0").Canonical(true))
+	must_be.Equal("1.2.0", conda.ParseVersion("1.2.0").Canonical(false))
+	must_be.Equal("1a1", conda.ParseVersion("1.0-alpha.1").Canonical(true))
+	must_be.Equal("1.0a1", conda.ParseVersion("1.0-alpha.1").Canonical(false))
+	must_be.Equal("1!2rc0.post3.dev4+ubuntu.1", conda.ParseVersion("1!2.0-c-r3-dev4+Ubuntu-1").Canonical(true))
+	must_be.Equal("1.1.1w", conda.ParseVersion("1.1.1W").Canonical(true))
+}
+
+func TestEquivalentEnvironmentsHaveSameCanonicalForm(t *testing.T) {
+	must_be, wont_be := hamlet.Specifications(t)
+
+	first, err := conda.CondaYamlFrom([]byte(cosmeticFirst))
+	must_be.Nil(err)
+	second, err := conda.CondaYamlFrom([]byte(cosmeticSecond))
+	must_be.Nil(err)
+
+	legacyFirst, err := first.AsYaml()
+	must_be.Nil(err)
+	legacySecond, err := second.AsYaml()
+	must_be.Nil(err)
+	wont_be.Equal(legacyFirst, legacySecond)
+
+	canonicalFirst, err := first.AsCanonicalYaml()
+	must_be.Nil(err)
+	canonicalSecond, err := second.AsCanonicalYaml()
+	must_be.Nil(err)
+	must_be.Equal(canonicalFirst, canonicalSecond)
+
+	expected := `channels:
+- conda-forge
+- nodefaults
+dependencies:
+- numpy>=1.24,<2 py310_0
+- pip=23.2.1
+- python=3.10.12
+- pip:
+  - --use-feature=truststore
+  - requests==2.31
+  - robocorp-tasks[cli,extra-one]<3,>=2
+  - urllib3~=2.0.0
+`
+	must_be.Equal(expected, canonicalFirst)
+
+	again, err := conda.CondaYamlFrom([]byte(canonicalFirst))
+	must_be.Nil(err)
+	stable, err := again.AsCanonicalYaml()
+	must_be.Nil(err)
+	must_be.Equal(canonicalFirst, stable)
+}
+
+func TestCanonicalFormKeepsMeaningfulDifferences(t *testing.T) {
+	must_be, _ := hamlet.Specifications(t)
+
+	prefix, _ := conda.CondaYamlFrom([]byte("dependencies:\n- pip:\n  - urllib3~=2.0\n"))
+	longer, _ := conda.CondaYamlFrom([]byte("dependencies:\n- pip:\n  - urllib3~=2.0.0\n"))
+	left, _ := prefix.AsCanonicalYaml()
+	right, _ := longer.AsCanonicalYaml()
+	must_be.Equal(false, left == right)
+
+	wild, _ := conda.CondaYamlFrom([]byte("dependencies:\n- python=3.10\n"))
+	exact, _ := conda.CondaYamlFrom([]byte("dependencies:\n- python=3.10.0\n"))
+	left, _ = wild.AsCanonicalYaml()
+	right, _ = exact.AsCanonicalYaml()
+	must_be.Equal(false, left == right)
+
+	forge, _ := conda.CondaYamlFrom([]byte("channels:\n- conda-forge\n- defaults\ndependencies:\n- python=3.10.12\n"))
+	defaults, _ := conda.CondaYamlFrom([]byte("channels:\n- defaults\n- conda-forge\ndependencies:\n- python=3.10.12\n"))
+	left, _ = forge.AsCanonicalYaml()
+	right, _ = defaults.AsCanonicalYaml()
+	must_be.Equal(false, left == right)
+	must_be.Equal("channels:\n- defaults\n- conda-forge\ndependencies:\n- python=3.10.12\n", right)
+	again, _ := conda.CondaYamlFrom([]byte(right))
+	must_be.Equal([]string{"defaults", "conda-forge"}, again.Channels)
+}
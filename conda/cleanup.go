@@ -3,6 +3,7 @@ package conda
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/robocorp/rcc/common"
@@ -84,17 +85,55 @@ func downloadCleanup(dryrun bool) (err error) {
 	return nil
 }
 
-func quickCleanup(dryrun bool) error {
+func pinnedSpaces(basedir string) map[string]bool {
+	result := make(map[string]bool)
+	for _, pinfile := range pathlib.Glob(basedir, "*.pin") {
+		result[strings.TrimSuffix(filepath.Base(pinfile), ".pin")] = true
+	}
+	return result
+}
+
+func holotreeCleanup(dryrun, includePinned bool) (err error) {
+	defer fail.Around(&err)
+
+	location := common.HolotreeLocation()
+	pinned := pinnedSpaces(location)
+	if includePinned || len(pinned) == 0 {
+		if dryrun {
+			common.Log("- %v", location)
+			return nil
+		}
+		return safeRemove("cache", location)
+	}
+	entries, err := os.ReadDir(location)
+	fail.On(err != nil, "Could not read %q, reason: %v", location, err)
+	for _, entry := range entries {
+		name := entry.Name()
+		if pinned[name] || pinned[strings.TrimSuffix(name, filepath.Ext(name))] {
+			common.Debug("Keeping pinned %v.", name)
+			continue
+		}
+		fullpath := filepath.Join(location, name)
+		if dryrun {
+			common.Log("- %v", fullpath)
+			continue
+		}
+		fail.Fast(safeRemove("cache", fullpath))
+	}
+	pretty.Note("Kept %d pinned holotree spaces. Use --include-pinned to remove them too.", len(pinned))
+	return nil
+}
+
+func quickCleanup(dryrun, includePinned bool) error {
 	downloadCleanup(dryrun)
+	err := holotreeCleanup(dryrun, includePinned)
+	if err != nil {
+		return err
+	}
 	if dryrun {
-		common.Log("- %v", common.HolotreeLocation())
 		common.Log("- %v", common.ProductTempRoot())
 		return nil
 	}
-	err := safeRemove("cache", common.HolotreeLocation())
-	if err != nil {
-		return err
-	}
 	return safeRemove("temp", common.ProductTempRoot())
 }
 
@@ -108,10 +147,10 @@ func cleanupAllCaches(dryrun bool) error {
 	return nil
 }
 
-func spotlessCleanup(dryrun, noCompress bool) (err error) {
+func spotlessCleanup(dryrun, noCompress, includePinned bool) (err error) {
 	defer fail.Around(&err)
 
-	fail.Fast(quickCleanup(dryrun))
+	fail.Fast(quickCleanup(dryrun, includePinned))
 	rcccache := filepath.Join(common.Product.Home(), "rcccache.yaml")
 	if dryrun {
 		common.Log("- %v", common.BinLocation())
@@ -175,7 +214,7 @@ func BugsCleanup() {
 	bugsCleanup(false)
 }
 
-func Cleanup(daylimit int, dryrun, quick, all, micromamba, downloads, noCompress, caches bool) (err error) {
+func Cleanup(daylimit int, dryrun, quick, all, micromamba, downloads, noCompress, caches, includePinned bool) (err error) {
 	defer fail.Around(&err)
 
 	lockfile := common.ProductLock()
@@ -196,7 +235,7 @@ func Cleanup(daylimit int, dryrun, quick, all, micromamba, downloads, noCompress
 	}
 
 	if quick {
-		return quickCleanup(dryrun)
+		return quickCleanup(dryrun, includePinned)
 	}
 
 	if caches {
@@ -204,7 +243,7 @@ func Cleanup(daylimit int, dryrun, quick, all, micromamba, downloads, noCompress
 	}
 
 	if all {
-		return spotlessCleanup(dryrun, noCompress)
+		return spotlessCleanup(dryrun, noCompress, includePinned)
 	}
 
 	deadline := time.Now().Add(-24 * time.Duration(daylimit) * time.Hour)
@@ -28,11 +28,13 @@ var (
 )
 
 type internalEnvironment struct {
-	Name         string        `yaml:"name,omitempty"`
-	Channels     []string      `yaml:"channels"`
-	Dependencies []interface{} `yaml:"dependencies"`
-	Prefix       string        `yaml:"prefix,omitempty"`
-	PostInstall  []string      `yaml:"rccPostInstall,omitempty"`
+	Name         string            `yaml:"name,omitempty"`
+	Channels     []string          `yaml:"channels"`
+	Dependencies []interface{}     `yaml:"dependencies"`
+	Prefix       string            `yaml:"prefix,omitempty"`
+	PostInstall  []string          `yaml:"rccPostInstall,omitempty"`
+	Variables    map[string]string `yaml:"variables,omitempty"`
+	Platforms    []string          `yaml:"platforms,omitempty"`
 }
 
 type Environment struct {
@@ -42,6 +44,9 @@ type Environment struct {
 	Conda       []*Dependency
 	Pip         []*Dependency
 	PostInstall []string
+	Variables   map[string]string
+	Platforms   []string
+	sources     map[string]string
 }
 
 type Dependency struct {
@@ -49,6 +54,8 @@ type Dependency struct {
 	Name      string
 	Qualifier string
 	Versions  string
+	Hashes    []string
+	Source    string
 }
 
 func AsDependency(value string) *Dependency {
@@ -57,14 +64,32 @@ func AsDependency(value string) *Dependency {
 	if len(parts) != 4 {
 		return nil
 	}
+	versions, hashes := splitHashes(parts[3])
 	return &Dependency{
 		Original:  parts[0],
 		Name:      parts[1],
 		Qualifier: parts[2],
-		Versions:  parts[3],
+		Versions:  versions,
+		Hashes:    hashes,
 	}
 }
 
+func splitHashes(versions string) (string, []string) {
+	if !strings.Contains(versions, "--hash=") {
+		return versions, nil
+	}
+	rest := []string{}
+	hashes := []string{}
+	for _, field := range strings.Fields(versions) {
+		if strings.HasPrefix(field, "--hash=") {
+			hashes = append(hashes, strings.TrimPrefix(field, "--hash="))
+			continue
+		}
+		rest = append(rest, field)
+	}
+	return strings.Join(rest, " "), hashes
+}
+
 func IsSpecialCacheable(name, version string) bool {
 	flat := fmt.Sprintf("%s=%s", strings.TrimSpace(name), strings.TrimSpace(version))
 	return strings.EqualFold(flat, useFeatureTruststore)
@@ -81,7 +106,7 @@ func (it *Dependency) Representation() string {
 }
 
 func (it *Dependency) IsCacheable() bool {
-	if IsSpecialCacheable(it.Name, it.Versions) {
+	if IsSpecialCacheable(it.Name, it.Versions) || it.IsExplicit() {
 		return true
 	}
 	if !it.IsExact() {
@@ -100,10 +125,28 @@ func (it *Dependency) Match(name string) bool {
 	return strings.EqualFold(name, it.Name)
 }
 
-func (it *Dependency) IsExact() bool {
+func (it *Dependency) constrained() bool {
 	return len(it.Qualifier)+len(it.Versions) > 0
 }
 
+func (it *Dependency) IsExact() bool {
+	fields := strings.Fields(it.Qualifier + it.Versions)
+	if len(fields) == 0 || strings.ContainsAny(fields[0], "|*") {
+		return false
+	}
+	clauses, err := ParseSpecifiers(fields[0])
+	if err != nil || len(clauses) != 1 {
+		return false
+	}
+	switch clauses[0].Operator {
+	case "==", "===":
+		return true
+	case "=":
+		return len(strings.Split(clauses[0].Version, ".")) > 2
+	}
+	return false
+}
+
 func (it *Dependency) SameAs(right *Dependency) bool {
 	return !strings.HasPrefix(it.Name, "-") && it.Name == right.Name
 }
@@ -112,20 +155,42 @@ func (it *Dependency) ExactlySame(right *Dependency) bool {
 	return it.Name == right.Name && it.Qualifier == right.Qualifier && it.Versions == right.Versions
 }
 
+func (it *Dependency) origin() string {
+	if len(it.Source) == 0 {
+		return it.Original
+	}
+	return fmt.Sprintf("%s (from %s)", it.Original, it.Source)
+}
+
 func (it *Dependency) ChooseSpecific(right *Dependency) (*Dependency, error) {
+	return it.chooseSpecific(right, true)
+}
+
+func (it *Dependency) chooseSpecific(right *Dependency, conda bool) (*Dependency, error) {
 	if !it.SameAs(right) {
 		return nil, fmt.Errorf("Not same component: %v vs. %v", it.Name, right.Name)
 	}
-	if it.IsExact() && !right.IsExact() {
+	if !right.constrained() {
 		return it, nil
 	}
-	if !it.IsExact() && right.IsExact() {
+	if !it.constrained() {
 		return right, nil
 	}
 	if it.ExactlySame(right) {
 		return it, nil
 	}
-	return nil, fmt.Errorf("Wont choose between dependencies: %v vs. %v", it.Original, right.Original)
+	if it.IsExplicit() || right.IsExplicit() {
+		return nil, fmt.Errorf("Wont choose between dependencies: %v vs. %v", it.origin(), right.origin())
+	}
+	chosen, err := intersectDependencies(it, right, conda)
+	if err == errUnsatisfiable {
+		return nil, fmt.Errorf("Wont choose between dependencies: %v vs. %v, because %v.", it.origin(), right.origin(), err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Wont choose between dependencies: %v vs. %v, reason: %v", it.origin(), right.origin(), err)
+	}
+	common.Trace("Combined dependencies %v and %v into %v.", it.origin(), right.origin(), chosen.Original)
+	return chosen, nil
 }
 
 func (it *Dependency) Index(others []*Dependency) int {
@@ -142,9 +207,11 @@ func (it *internalEnvironment) AsEnvironment() *Environment {
 		Name:        it.Name,
 		Prefix:      it.Prefix,
 		PostInstall: []string{},
+		Platforms:   it.Platforms,
 	}
 	seenScripts := make(map[string]bool)
 	result.PostInstall = addItem(seenScripts, it.PostInstall, result.PostInstall)
+	result.pushVariables(it.Variables, nil)
 	channel, ok := LocalChannel()
 	if ok {
 		pushChannels(result, []string{channel})
@@ -206,6 +273,7 @@ func (it *Environment) FreezeDependencies(fixed dependencies) *Environment {
 		Conda:       []*Dependency{},
 		Pip:         []*Dependency{},
 		PostInstall: it.PostInstall,
+		Variables:   it.Variables,
 	}
 	used := make(map[string]bool)
 	for _, dependency := range fixed {
@@ -390,7 +458,15 @@ func (it *Environment) Merge(right *Environment) (*Environment, error) {
 	result.PostInstall = addItem(seenScripts, it.PostInstall, result.PostInstall)
 	result.PostInstall = addItem(seenScripts, right.PostInstall, result.PostInstall)
 
-	err := pushConda(result, it.Conda)
+	err := result.pushVariables(it.Variables, it.sources)
+	if err != nil {
+		return nil, err
+	}
+	err = result.pushVariables(right.Variables, right.sources)
+	if err != nil {
+		return nil, err
+	}
+	err = pushConda(result, it.Conda)
 	if err != nil {
 		return nil, err
 	}
@@ -413,10 +489,10 @@ func (it *Environment) Merge(right *Environment) (*Environment, error) {
 	return result, nil
 }
 
-func semiSmartPush(target []*Dependency, candidate *Dependency) ([]*Dependency, error) {
+func semiSmartPush(target []*Dependency, candidate *Dependency, conda bool) ([]*Dependency, error) {
 	for index, value := range target {
 		if value.SameAs(candidate) {
-			chosen, err := value.ChooseSpecific(candidate)
+			chosen, err := value.chooseSpecific(candidate, conda)
 			if err != nil {
 				return nil, err
 			}
@@ -438,7 +514,7 @@ func (it *Environment) PushChannel(channel string) {
 }
 
 func (it *Environment) PushConda(dependency *Dependency) error {
-	result, err := semiSmartPush(it.Conda, dependency)
+	result, err := semiSmartPush(it.Conda, dependency, true)
 	if err != nil {
 		return err
 	}
@@ -447,7 +523,7 @@ func (it *Environment) PushConda(dependency *Dependency) error {
 }
 
 func (it *Environment) PushPip(dependency *Dependency) error {
-	result, err := semiSmartPush(it.Pip, dependency)
+	result, err := semiSmartPush(it.Pip, dependency, false)
 	if err != nil {
 		return err
 	}
@@ -522,6 +598,7 @@ func (it *Environment) AsYaml() (string, error) {
 	result.Dependencies = it.CondaList()
 	seenScripts := make(map[string]bool)
 	result.PostInstall = addItem(seenScripts, it.PostInstall, result.PostInstall)
+	result.Variables = it.Variables
 	if len(it.Pip) > 0 {
 		result.Dependencies = append(result.Dependencies, it.PipMap())
 	}
@@ -598,6 +675,9 @@ func (it *Environment) Diagnostics(target *common.DiagnosticStatus, production b
 			notice(0, "", "Dependency %q seems to be duplicate of previous dependency.", dependency.Original)
 		}
 		packages[presentation] = true
+		if dependency.IsExplicit() {
+			continue
+		}
 		if !dependency.IsCacheable() {
 			diagnose.Warning(common.CategoryEnvironmentCache, "", "Conda dependency %q is not publicly cacheable.", dependency.Original)
 			ok = false
@@ -647,18 +727,34 @@ func (it *Environment) Diagnostics(target *common.DiagnosticStatus, production b
 	if floating {
 		diagnose.Warning(0, "", "Floating dependencies in %s Cloud containers will be slow, because floating environments cannot be cached.", common.Product.Name())
 	}
+	it.policyDiagnostics(target)
 }
 
 func CondaYamlFrom(content []byte) (*Environment, error) {
+	return condaYamlFor(content, common.Platform())
+}
+
+func condaYamlFor(content []byte, platform string) (*Environment, error) {
+	if IsLockfile(content) {
+		return environmentFromLockfile(content)
+	}
 	result := new(internalEnvironment)
-	err := yaml.Unmarshal(content, result)
+	err := yaml.Unmarshal(applySelectors(content, platform), result)
+	if err != nil {
+		return nil, err
+	}
+	err = result.selectFor(platform)
+	if err != nil {
+		return nil, err
+	}
+	err = validateVariables(result.Variables)
 	if err != nil {
 		return nil, err
 	}
 	return result.AsEnvironment(), nil
 }
 
-func readCondaYaml(filename string) (*Environment, error) {
+func readCondaYaml(filename, platform string) (*Environment, error) {
 	var content []byte
 	var err error
 
@@ -670,7 +766,25 @@ func readCondaYaml(filename string) (*Environment, error) {
 	if err != nil {
 		return nil, fmt.Errorf("%q: %w", filename, err)
 	}
-	return CondaYamlFrom(content)
+	environment, err := condaYamlFor(content, platform)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", filename, err)
+	}
+	return environment.withSource(filename), nil
+}
+
+func (it *Environment) withSource(filename string) *Environment {
+	for _, dependency := range append(append([]*Dependency{}, it.Conda...), it.Pip...) {
+		if len(dependency.Source) == 0 {
+			dependency.Source = filename
+		}
+	}
+	for name := range it.Variables {
+		if len(it.sources[name]) == 0 {
+			it.sources[name] = filename
+		}
+	}
+	return it
 }
 
 func pipContent(result []*Dependency, value interface{}) []*Dependency {
@@ -1,6 +1,7 @@
 package conda_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/robocorp/rcc/common"
@@ -34,6 +35,18 @@ func TestCanCompareDependencies(t *testing.T) {
 
 	wont_be.True(first.IsExact())
 	must_be.True(second.IsExact())
+	must_be.True(conda.AsDependency("pandas==2.0.3").IsExact())
+	must_be.True(conda.AsDependency("pandas===2.0.3").IsExact())
+	must_be.True(conda.AsDependency("numpy==1.26").IsExact())
+	must_be.True(conda.AsDependency("python=3.10.12 h4de0772_0").IsExact())
+	wont_be.True(conda.AsDependency("python=3.10").IsExact())
+	wont_be.True(conda.AsDependency("numpy==1.26.*").IsExact())
+	wont_be.True(conda.AsDependency("pandas>=1.5").IsExact())
+	wont_be.True(conda.AsDependency("pandas>=1.5,<2.1").IsExact())
+	wont_be.True(conda.AsDependency("pandas!=2.0.0").IsExact())
+	wont_be.True(conda.AsDependency("pandas~=2.0.3").IsExact())
+	wont_be.True(conda.AsDependency("numpy 1.26.0|1.26.1").IsExact())
+	wont_be.True(conda.AsDependency("pandas>=1.5").IsCacheable())
 
 	must_be.True(first.SameAs(second))
 	must_be.True(first.SameAs(third))
@@ -54,12 +67,69 @@ func TestCanCompareDependencies(t *testing.T) {
 	must_be.Equal("Not same component: python vs. robotframework", err.Error())
 	must_be.Nil(chosen)
 
+	chosen, err = conda.AsDependency("pandas==1.4.4").ChooseSpecific(conda.AsDependency("pandas>=1.5"))
+	wont_be.Nil(err)
+	must_be.Nil(chosen)
+
+	chosen, err = conda.AsDependency("pandas<2.1").ChooseSpecific(conda.AsDependency("pandas==2.0.3"))
+	must_be.Nil(err)
+	must_be.Equal("pandas==2.0.3", chosen.Original)
+
 	chosen, err = second.ChooseSpecific(third)
 	wont_be.Nil(err)
-	must_be.Equal("Wont choose between dependencies: python=3.7.7 vs. python=3.9.13", err.Error())
+	must_be.Equal("Wont choose between dependencies: python=3.7.7 vs. python=3.9.13, because no version can satisfy both.", err.Error())
 	must_be.Nil(chosen)
 }
 
+func TestCanIntersectDependencyRanges(t *testing.T) {
+	must_be, wont_be := hamlet.Specifications(t)
+
+	environment := &conda.Environment{}
+	must_be.Nil(environment.PushPip(conda.AsDependency("pandas>=1.5")))
+	must_be.Nil(environment.PushPip(conda.AsDependency("pandas<2.1")))
+	must_be.Equal(1, len(environment.Pip))
+	must_be.Equal("pandas>=1.5,<2.1", environment.Pip[0].Original)
+
+	must_be.Nil(environment.PushPip(conda.AsDependency("pandas==2.0.3")))
+	must_be.Equal("pandas==2.0.3", environment.Pip[0].Original)
+
+	must_be.Nil(environment.PushPip(conda.AsDependency("numpy~=1.24.0")))
+	must_be.Nil(environment.PushPip(conda.AsDependency("numpy>=1.20")))
+	must_be.Equal("numpy~=1.24.0", environment.Pip[1].Original)
+
+	wont_be.Nil(environment.PushPip(conda.AsDependency("pandas>=2.1")))
+	wont_be.Nil(environment.PushPip(conda.AsDependency("numpy<1.24")))
+
+	must_be.Nil(environment.PushConda(conda.AsDependency("python=3.10")))
+	must_be.Nil(environment.PushConda(conda.AsDependency("python>=3.9,<3.12")))
+	must_be.Equal("python=3.10", environment.Conda[0].Original)
+	must_be.Nil(environment.PushConda(conda.AsDependency("python!=3.10.0")))
+	must_be.Equal("python 3.10.*,!=3.10.0", environment.Conda[0].Original)
+
+	must_be.Nil(environment.PushConda(conda.AsDependency("openssl 1.1.*|3.*")))
+	must_be.Nil(environment.PushConda(conda.AsDependency("openssl>=3.0")))
+	must_be.Equal("openssl 3.*,>=3.0", environment.Conda[1].Original)
+
+	must_be.Nil(environment.PushConda(conda.AsDependency("numpy 1.24.* py310_0")))
+	wont_be.Nil(environment.PushConda(conda.AsDependency("numpy 1.24.* py311_0")))
+	must_be.Nil(environment.PushConda(conda.AsDependency("numpy>=1.20")))
+	must_be.Equal("numpy 1.24.* py310_0", environment.Conda[2].Original)
+}
+
+func TestMergeConflictsTellWhereConstraintsCameFrom(t *testing.T) {
+	must_be, wont_be := hamlet.Specifications(t)
+
+	left, err := conda.ReadPackageCondaYaml("testdata/conda.yaml")
+	must_be.Nil(err)
+	right, err := conda.CondaYamlFrom([]byte("dependencies:\n- python>=3.12\n"))
+	must_be.Nil(err)
+	right.Conda[0].Source = "extra.yaml"
+	_, err = left.Merge(right)
+	wont_be.Nil(err)
+	must_be.True(strings.Contains(err.Error(), "(from testdata/conda.yaml)"))
+	must_be.True(strings.Contains(err.Error(), "python>=3.12 (from extra.yaml)"))
+}
+
 func TestCanCreateCondaYamlFromEmptyByteSlice(t *testing.T) {
 	must_be, wont_be := hamlet.Specifications(t)
 
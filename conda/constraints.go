@@ -0,0 +1,321 @@
+package conda
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	errUnsatisfiable = errors.New("no version can satisfy both")
+)
+
+type (
+	bound struct {
+		version   string
+		inclusive bool
+		set       bool
+	}
+
+	versionRange struct {
+		lower    bound
+		upper    bound
+		pins     []string
+		excluded []*VersionClause
+		clauses  []*VersionClause
+	}
+)
+
+func prefixUpper(prefix string) (string, bool) {
+	parts := strings.Split(prefix, ".")
+	last, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return "", false
+	}
+	parts[len(parts)-1] = strconv.Itoa(last + 1)
+	return strings.Join(parts, "."), true
+}
+
+func (it *versionRange) raiseLower(version string, inclusive bool) {
+	if !it.lower.set {
+		it.lower = bound{version, inclusive, true}
+		return
+	}
+	order := CompareVersions(version, it.lower.version)
+	if order > 0 || (order == 0 && !inclusive) {
+		it.lower = bound{version, inclusive, true}
+	}
+}
+
+func (it *versionRange) capUpper(version string, inclusive bool) {
+	if !it.upper.set {
+		it.upper = bound{version, inclusive, true}
+		return
+	}
+	order := CompareVersions(version, it.upper.version)
+	if order < 0 || (order == 0 && !inclusive) {
+		it.upper = bound{version, inclusive, true}
+	}
+}
+
+func (it *versionRange) prefixed(prefix string) {
+	it.raiseLower(prefix, true)
+	upper, ok := prefixUpper(prefix)
+	if ok {
+		it.capUpper(upper, false)
+	}
+}
+
+func newVersionRange(clauses []*VersionClause) *versionRange {
+	result := &versionRange{clauses: clauses}
+	for _, clause := range clauses {
+		wildcard := strings.HasSuffix(clause.Version, "*")
+		bare := strings.TrimRight(strings.TrimSuffix(clause.Version, "*"), ".")
+		switch clause.Operator {
+		case "===":
+			result.pins = append(result.pins, clause.Version)
+		case "==":
+			if wildcard {
+				result.prefixed(bare)
+			} else {
+				result.pins = append(result.pins, clause.Version)
+			}
+		case "=":
+			result.prefixed(bare)
+		case "~=":
+			result.raiseLower(bare, true)
+			release := strings.Split(bare, ".")
+			if len(release) > 1 {
+				upper, ok := prefixUpper(strings.Join(release[:len(release)-1], "."))
+				if ok {
+					result.capUpper(upper, false)
+				}
+			}
+		case ">=":
+			result.raiseLower(clause.Version, true)
+		case ">":
+			result.raiseLower(clause.Version, false)
+		case "<=":
+			result.capUpper(clause.Version, true)
+		case "<":
+			result.capUpper(clause.Version, false)
+		case "!=":
+			result.excluded = append(result.excluded, clause)
+		}
+	}
+	return result
+}
+
+func (it *versionRange) matchesAll(version string) bool {
+	for _, clause := range it.clauses {
+		if !clause.Matches(version) {
+			return false
+		}
+	}
+	return true
+}
+
+func (it *versionRange) Satisfiable() bool {
+	if len(it.pins) > 0 {
+		return it.matchesAll(it.pins[0])
+	}
+	if it.lower.set && it.upper.set {
+		order := CompareVersions(it.lower.version, it.upper.version)
+		if order > 0 {
+			return false
+		}
+		if order == 0 {
+			return it.lower.inclusive && it.upper.inclusive && it.matchesAll(it.lower.version)
+		}
+		for _, clause := range it.excluded {
+			if !strings.HasSuffix(clause.Version, "*") {
+				continue
+			}
+			bare := strings.TrimRight(strings.TrimSuffix(clause.Version, "*"), ".")
+			upper, ok := prefixUpper(bare)
+			if ok && hasVersionPrefix(it.lower.version, bare) && CompareVersions(it.upper.version, upper) <= 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (it *versionRange) within(other *versionRange) bool {
+	if len(it.pins) > 0 {
+		return other.matchesAll(it.pins[0])
+	}
+	if len(other.pins) > 0 || len(other.excluded) > 0 {
+		return false
+	}
+	if other.lower.set {
+		if !it.lower.set {
+			return false
+		}
+		order := CompareVersions(it.lower.version, other.lower.version)
+		if order < 0 || (order == 0 && it.lower.inclusive && !other.lower.inclusive) {
+			return false
+		}
+	}
+	if other.upper.set {
+		if !it.upper.set {
+			return false
+		}
+		order := CompareVersions(it.upper.version, other.upper.version)
+		if order > 0 || (order == 0 && it.upper.inclusive && !other.upper.inclusive) {
+			return false
+		}
+	}
+	return true
+}
+
+func splitBuild(dependency *Dependency, conda bool) (string, string) {
+	spec := strings.TrimSpace(dependency.Qualifier + dependency.Versions)
+	if !conda {
+		return spec, ""
+	}
+	fields := strings.Fields(spec)
+	if len(fields) < 2 {
+		return spec, ""
+	}
+	return fields[0], strings.Join(fields[1:], " ")
+}
+
+func alternatives(spec string, conda bool) ([][]*VersionClause, error) {
+	options := []string{spec}
+	if conda {
+		options = strings.Split(spec, "|")
+	}
+	result := make([][]*VersionClause, 0, len(options))
+	for _, option := range options {
+		clauses, err := ParseSpecifiers(option)
+		if err != nil {
+			return nil, err
+		}
+		if len(clauses) == 0 {
+			return nil, fmt.Errorf("Empty version specifier in %q.", spec)
+		}
+		result = append(result, clauses)
+	}
+	return result, nil
+}
+
+func combineClauses(left, right []*VersionClause) []*VersionClause {
+	result := make([]*VersionClause, 0, len(left)+len(right))
+	seen := make(map[string]bool)
+	for _, clause := range append(append([]*VersionClause{}, left...), right...) {
+		key := clause.Operator + clause.Version
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, clause)
+	}
+	return result
+}
+
+func impliedBy(narrow, wide [][]*VersionClause) bool {
+	for _, option := range narrow {
+		inner := newVersionRange(option)
+		covered := false
+		for _, candidate := range wide {
+			if inner.within(newVersionRange(candidate)) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+func (it *VersionClause) render(conda bool) string {
+	wildcard := it.Operator == "==" && strings.HasSuffix(it.Version, "*")
+	if it.Operator != "=" && !(conda && wildcard) {
+		return it.Operator + it.Version
+	}
+	bare := strings.TrimRight(strings.TrimSuffix(it.Version, "*"), ".")
+	if conda {
+		return bare + ".*"
+	}
+	return "==" + bare + ".*"
+}
+
+func renderDependency(name, build string, options [][]*VersionClause, conda bool) string {
+	parts := make([]string, 0, len(options))
+	for _, option := range options {
+		rendered := make([]string, 0, len(option))
+		for _, clause := range option {
+			rendered = append(rendered, clause.render(conda))
+		}
+		parts = append(parts, strings.Join(rendered, ","))
+	}
+	spec := strings.Join(parts, "|")
+	separator := ""
+	if len(spec) > 0 && !strings.ContainsAny(spec[:1], "<=>!~") {
+		separator = " "
+	}
+	result := name + separator + spec
+	if len(build) > 0 {
+		result = result + " " + build
+	}
+	return result
+}
+
+func joinSources(left, right string) string {
+	switch {
+	case len(left) == 0 || left == right:
+		return right
+	case len(right) == 0:
+		return left
+	default:
+		return left + ", " + right
+	}
+}
+
+func intersectDependencies(left, right *Dependency, conda bool) (*Dependency, error) {
+	leftSpec, leftBuild := splitBuild(left, conda)
+	rightSpec, rightBuild := splitBuild(right, conda)
+	if len(leftBuild) > 0 && len(rightBuild) > 0 && leftBuild != rightBuild {
+		return nil, fmt.Errorf("build strings %q and %q differ", leftBuild, rightBuild)
+	}
+	build := leftBuild
+	if len(build) == 0 {
+		build = rightBuild
+	}
+	lefties, err := alternatives(leftSpec, conda)
+	if err != nil {
+		return nil, err
+	}
+	righties, err := alternatives(rightSpec, conda)
+	if err != nil {
+		return nil, err
+	}
+	if leftBuild == build && impliedBy(lefties, righties) {
+		return left, nil
+	}
+	if rightBuild == build && impliedBy(righties, lefties) {
+		return right, nil
+	}
+	kept := [][]*VersionClause{}
+	for _, lefty := range lefties {
+		for _, righty := range righties {
+			combined := combineClauses(lefty, righty)
+			if newVersionRange(combined).Satisfiable() {
+				kept = append(kept, combined)
+			}
+		}
+	}
+	if len(kept) == 0 {
+		return nil, errUnsatisfiable
+	}
+	result := AsDependency(renderDependency(left.Name, build, kept, conda))
+	if result == nil {
+		return nil, fmt.Errorf("could not express combined constraints")
+	}
+	result.Source = joinSources(left.Source, right.Source)
+	return result, nil
+}
@@ -134,8 +134,12 @@ func LoadWantedDependencies(filename string) dependencies {
 	if err != nil {
 		return dependencies{}
 	}
+	return ParseWantedDependencies(body)
+}
+
+func ParseWantedDependencies(body []byte) dependencies {
 	result := make(dependencies, 0, 100)
-	err = yaml.Unmarshal(body, &result)
+	err := yaml.Unmarshal(body, &result)
 	if err != nil {
 		return dependencies{}
 	}
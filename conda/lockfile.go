@@ -0,0 +1,263 @@
+package conda
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/pathlib"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	LockfileVersion = 1
+	explicitMarker  = "@EXPLICIT"
+)
+
+var (
+	lockfilePattern = regexp.MustCompile(`(?m)^rccLock\s*:`)
+	condaArchive    = regexp.MustCompile(`^(.+)-([^-]+)-([^-]+)\.(?:conda|tar\.bz2)$`)
+)
+
+type (
+	LockPlatform struct {
+		Subdir    string
+		PipTags   []string
+		Overrides []string
+	}
+
+	LockedConda struct {
+		Name    string `yaml:"name"`
+		Version string `yaml:"version"`
+		Build   string `yaml:"build,omitempty"`
+		Channel string `yaml:"channel,omitempty"`
+		Url     string `yaml:"url"`
+		Md5     string `yaml:"md5,omitempty"`
+		Sha256  string `yaml:"sha256,omitempty"`
+	}
+
+	LockedPip struct {
+		Name    string   `yaml:"name"`
+		Version string   `yaml:"version"`
+		Url     string   `yaml:"url,omitempty"`
+		Hashes  []string `yaml:"hashes"`
+	}
+
+	LockedPlatform struct {
+		Subdir string         `yaml:"subdir"`
+		Conda  []*LockedConda `yaml:"conda"`
+		Pip    []*LockedPip   `yaml:"pip,omitempty"`
+	}
+
+	Lockfile struct {
+		Version     int                        `yaml:"rccLock"`
+		Source      string                     `yaml:"source,omitempty"`
+		Blueprint   string                     `yaml:"blueprint,omitempty"`
+		Generator   string                     `yaml:"generator,omitempty"`
+		Name        string                     `yaml:"name,omitempty"`
+		Channels    []string                   `yaml:"channels"`
+		PipOptions  []string                   `yaml:"pipOptions,omitempty"`
+		PostInstall []string                   `yaml:"rccPostInstall,omitempty"`
+		Variables   map[string]string          `yaml:"variables,omitempty"`
+		Platforms   map[string]*LockedPlatform `yaml:"platforms"`
+	}
+)
+
+var lockPlatforms = map[string]*LockPlatform{
+	"linux_amd64": {
+		Subdir:    "linux-64",
+		PipTags:   []string{"manylinux_2_28_x86_64", "manylinux_2_17_x86_64", "manylinux2014_x86_64", "linux_x86_64"},
+		Overrides: []string{"CONDA_OVERRIDE_GLIBC=2.28"},
+	},
+	"linux_arm64": {
+		Subdir:    "linux-aarch64",
+		PipTags:   []string{"manylinux_2_28_aarch64", "manylinux_2_17_aarch64", "manylinux2014_aarch64", "linux_aarch64"},
+		Overrides: []string{"CONDA_OVERRIDE_GLIBC=2.28"},
+	},
+	"darwin_amd64": {
+		Subdir:    "osx-64",
+		PipTags:   []string{"macosx_11_0_x86_64", "macosx_10_9_x86_64", "macosx_10_9_universal2"},
+		Overrides: []string{"CONDA_OVERRIDE_OSX=11.0"},
+	},
+	"darwin_arm64": {
+		Subdir:    "osx-arm64",
+		PipTags:   []string{"macosx_11_0_arm64", "macosx_10_9_universal2"},
+		Overrides: []string{"CONDA_OVERRIDE_OSX=11.0"},
+	},
+	"windows_amd64": {
+		Subdir:    "win-64",
+		PipTags:   []string{"win_amd64"},
+		Overrides: []string{},
+	},
+}
+
+func LockablePlatform(platform string) (*LockPlatform, bool) {
+	found, ok := lockPlatforms[strings.ToLower(strings.TrimSpace(platform))]
+	return found, ok
+}
+
+func LockablePlatforms() []string {
+	result := make([]string, 0, len(lockPlatforms))
+	for platform := range lockPlatforms {
+		result = append(result, platform)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func IsLockfile(content []byte) bool {
+	return lockfilePattern.Match(content)
+}
+
+func LockfileFrom(content []byte) (*Lockfile, error) {
+	result := new(Lockfile)
+	err := yaml.Unmarshal(content, result)
+	if err != nil {
+		return nil, err
+	}
+	if result.Version != LockfileVersion {
+		return nil, fmt.Errorf("Unsupported lock file version %d, expected version %d.", result.Version, LockfileVersion)
+	}
+	return result, nil
+}
+
+func LockfileCovers(filename, platform string) bool {
+	content, err := os.ReadFile(filename)
+	if err != nil || !IsLockfile(content) {
+		return true
+	}
+	lock, err := LockfileFrom(content)
+	if err != nil {
+		return true
+	}
+	_, ok := lock.Platforms[platform]
+	return ok
+}
+
+func (it *Lockfile) PlatformNames() []string {
+	result := make([]string, 0, len(it.Platforms))
+	for platform := range it.Platforms {
+		result = append(result, platform)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (it *Lockfile) AsYaml() ([]byte, error) {
+	return yaml.Marshal(it)
+}
+
+func (it *Lockfile) SaveAs(filename string) error {
+	content, err := it.AsYaml()
+	if err != nil {
+		return err
+	}
+	return pathlib.WriteFile(filename, content, 0o644)
+}
+
+func (it *LockedConda) Spec() string {
+	if len(it.Md5) > 0 {
+		return fmt.Sprintf("%s#%s", it.Url, it.Md5)
+	}
+	return it.Url
+}
+
+func (it *LockedPip) Spec() string {
+	parts := []string{fmt.Sprintf("%s==%s", it.Name, it.Version)}
+	for _, hash := range it.Hashes {
+		parts = append(parts, fmt.Sprintf("--hash=%s", hash))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (it *Lockfile) ForPlatform(platform string) (*Environment, error) {
+	locked, ok := it.Platforms[platform]
+	if !ok {
+		return nil, fmt.Errorf("Lock file does not cover platform %q, it only has %q. Regenerate it with 'rcc holotree lock'.", platform, it.PlatformNames())
+	}
+	result := &Environment{
+		Name:        it.Name,
+		Channels:    []string{},
+		Conda:       make([]*Dependency, 0, len(locked.Conda)),
+		Pip:         make([]*Dependency, 0, len(it.PipOptions)+len(locked.Pip)),
+		PostInstall: []string{},
+	}
+	seenScripts := make(map[string]bool)
+	result.PostInstall = addItem(seenScripts, it.PostInstall, result.PostInstall)
+	result.pushVariables(it.Variables, nil)
+	pushChannels(result, it.Channels)
+	for _, entry := range locked.Conda {
+		spec := entry.Spec()
+		result.Conda = append(result.Conda, &Dependency{
+			Original: spec,
+			Name:     spec,
+		})
+	}
+	for _, option := range it.PipOptions {
+		dependency := AsDependency(option)
+		if dependency != nil {
+			result.Pip = append(result.Pip, dependency)
+		}
+	}
+	for _, entry := range locked.Pip {
+		dependency := AsDependency(entry.Spec())
+		if dependency != nil {
+			result.Pip = append(result.Pip, dependency)
+		}
+	}
+	return result, nil
+}
+
+func environmentFromLockfile(content []byte) (*Environment, error) {
+	lock, err := LockfileFrom(content)
+	if err != nil {
+		return nil, err
+	}
+	common.Debug("Using lock file generated by %q from %q for platform %q.", lock.Generator, lock.Source, common.Platform())
+	return lock.ForPlatform(common.Platform())
+}
+
+func (it *Dependency) IsExplicit() bool {
+	return strings.Contains(it.Name, "://")
+}
+
+func explicitPackage(spec string) (channel, name, version string, ok bool) {
+	location := strings.SplitN(spec, "#", 2)[0]
+	match := condaArchive.FindStringSubmatch(path.Base(location))
+	if match == nil {
+		return "", "", "", false
+	}
+	return path.Dir(path.Dir(location)), match[1], match[2], true
+}
+
+func (it *Environment) IsExplicit() bool {
+	if len(it.Conda) == 0 {
+		return false
+	}
+	for _, dependency := range it.Conda {
+		if !dependency.IsExplicit() {
+			return false
+		}
+	}
+	return true
+}
+
+func (it *Environment) AsExplicitSpec() string {
+	lines := make([]string, 0, len(it.Conda)+2)
+	lines = append(lines, "# This file was generated from rcc lock file.", explicitMarker)
+	for _, dependency := range it.Conda {
+		lines = append(lines, dependency.Original)
+	}
+	return strings.Join(lines, Newline) + Newline
+}
+
+func (it *Environment) SaveAsExplicit(filename string) error {
+	content := it.AsExplicitSpec()
+	common.Trace("FINAL explicit conda specification as %v:\n---\n%v---", filename, content)
+	return pathlib.WriteFile(filename, []byte(content), 0o640)
+}
@@ -0,0 +1,101 @@
+package conda_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/robocorp/rcc/conda"
+	"github.com/robocorp/rcc/hamlet"
+)
+
+func TestCanParseHashesFromPipDependencies(t *testing.T) {
+	must_be, wont_be := hamlet.Specifications(t)
+
+	plain := conda.AsDependency("requests==2.31.0")
+	must_be.Equal("2.31.0", plain.Versions)
+	must_be.Nil(plain.Hashes)
+
+	hashed := conda.AsDependency("requests==2.31.0 --hash=sha256:aaaa --hash=sha256:bbbb")
+	wont_be.Nil(hashed)
+	must_be.Equal("requests", hashed.Name)
+	must_be.Equal("==", hashed.Qualifier)
+	must_be.Equal("2.31.0", hashed.Versions)
+	must_be.Equal([]string{"sha256:aaaa", "sha256:bbbb"}, hashed.Hashes)
+	must_be.Equal("requests==2.31.0 --hash=sha256:aaaa --hash=sha256:bbbb", hashed.Original)
+	must_be.True(hashed.IsCacheable())
+	must_be.True(hashed.IsPinned())
+}
+
+func TestCanRecognizeLockfiles(t *testing.T) {
+	must_be, wont_be := hamlet.Specifications(t)
+
+	content, err := os.ReadFile("testdata/lock.yaml")
+	must_be.Nil(err)
+	must_be.True(conda.IsLockfile(content))
+
+	plain, err := os.ReadFile("testdata/conda.yaml")
+	must_be.Nil(err)
+	wont_be.True(conda.IsLockfile(plain))
+
+	lock, err := conda.LockfileFrom(content)
+	must_be.Nil(err)
+	must_be.Equal([]string{"linux_amd64", "windows_amd64"}, lock.PlatformNames())
+	must_be.True(conda.LockfileCovers("testdata/lock.yaml", "windows_amd64"))
+	wont_be.True(conda.LockfileCovers("testdata/lock.yaml", "darwin_arm64"))
+	must_be.True(conda.LockfileCovers("testdata/conda.yaml", "darwin_arm64"))
+
+	_, err = conda.LockfileFrom([]byte("rccLock: 99\n"))
+	wont_be.Nil(err)
+}
+
+func TestCanCreateEnvironmentFromLockfile(t *testing.T) {
+	must_be, wont_be := hamlet.Specifications(t)
+
+	content, err := os.ReadFile("testdata/lock.yaml")
+	must_be.Nil(err)
+	lock, err := conda.LockfileFrom(content)
+	must_be.Nil(err)
+
+	_, err = lock.ForPlatform("darwin_arm64")
+	wont_be.Nil(err)
+
+	environment, err := lock.ForPlatform("linux_amd64")
+	must_be.Nil(err)
+	must_be.True(environment.IsExplicit())
+	must_be.True(environment.IsCacheable())
+	must_be.Equal(2, len(environment.Conda))
+	must_be.Equal("https://conda.anaconda.org/conda-forge/linux-64/python-3.10.12-hd12c33a_0_cpython.conda#eb6f1df105f37daedd6dca78523baa75", environment.Conda[0].Original)
+	must_be.Equal(2, len(environment.Pip))
+	must_be.Equal("--use-feature=truststore", environment.Pip[0].Original)
+	must_be.Equal("robocorp-tasks==2.1.1 --hash=sha256:aaaa --hash=sha256:bbbb", environment.Pip[1].Original)
+	must_be.Equal([]string{"python -m robocorp.tasks --version"}, environment.PostInstall)
+
+	lines := strings.Split(strings.TrimSpace(environment.AsExplicitSpec()), conda.Newline)
+	must_be.Equal(4, len(lines))
+	must_be.Equal("@EXPLICIT", lines[1])
+	must_be.Equal(environment.Conda[1].Original, lines[3])
+
+	blueprint, err := environment.AsYaml()
+	must_be.Nil(err)
+	again, err := conda.CondaYamlFrom([]byte(blueprint))
+	must_be.Nil(err)
+	must_be.True(again.IsExplicit())
+}
+
+func TestLockPlatformsHaveDefaults(t *testing.T) {
+	must_be, _ := hamlet.Specifications(t)
+
+	environment, err := conda.CondaYamlFrom([]byte("channels: [conda-forge]\nplatforms: [linux_amd64, win_bad]\ndependencies: [python=3.10.12]\n"))
+	must_be.Nil(err)
+	must_be.Equal([]string{"linux_amd64", "win_bad"}, environment.LockPlatforms(nil))
+	must_be.Equal([]string{"darwin_arm64"}, environment.LockPlatforms([]string{"darwin_arm64"}))
+	blueprint, err := environment.AsYaml()
+	must_be.Nil(err)
+	must_be.Equal(false, strings.Contains(blueprint, "platforms"))
+
+	_, ok := conda.LockablePlatform("windows_amd64")
+	must_be.True(ok)
+	_, ok = conda.LockablePlatform("win_bad")
+	must_be.Equal(false, ok)
+}
@@ -0,0 +1,248 @@
+package conda
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/fail"
+	"github.com/robocorp/rcc/settings"
+	"github.com/robocorp/rcc/shell"
+)
+
+type (
+	mambaAction struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+		Build   string `json:"build_string"`
+		Channel string `json:"channel"`
+		Url     string `json:"url"`
+		Md5     string `json:"md5"`
+		Sha256  string `json:"sha256"`
+	}
+
+	mambaDryRun struct {
+		Success bool `json:"success"`
+		Actions struct {
+			Link []*mambaAction `json:"LINK"`
+		} `json:"actions"`
+	}
+
+	pipReportEntry struct {
+		DownloadInfo struct {
+			Url         string `json:"url"`
+			ArchiveInfo *struct {
+				Hash   string            `json:"hash"`
+				Hashes map[string]string `json:"hashes"`
+			} `json:"archive_info"`
+		} `json:"download_info"`
+		Metadata struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"metadata"`
+	}
+
+	pipReport struct {
+		Install []*pipReportEntry `json:"install"`
+	}
+)
+
+func parseMambaDryRun(content []byte) (result []*LockedConda, err error) {
+	defer fail.Around(&err)
+
+	plan := new(mambaDryRun)
+	err = json.Unmarshal(content, plan)
+	fail.On(err != nil, "Could not parse micromamba dry-run output, reason: %v", err)
+	fail.On(!plan.Success, "Micromamba dry-run did not succeed.")
+	result = make([]*LockedConda, 0, len(plan.Actions.Link))
+	for _, action := range plan.Actions.Link {
+		fail.On(len(action.Url) == 0, "Micromamba did not report url for package %q.", action.Name)
+		result = append(result, &LockedConda{
+			Name:    action.Name,
+			Version: action.Version,
+			Build:   action.Build,
+			Channel: action.Channel,
+			Url:     action.Url,
+			Md5:     action.Md5,
+			Sha256:  action.Sha256,
+		})
+	}
+	sort.SliceStable(result, func(left, right int) bool {
+		return result[left].Name < result[right].Name
+	})
+	return result, nil
+}
+
+func parsePipReport(content []byte, provided map[string]bool) (result []*LockedPip, err error) {
+	defer fail.Around(&err)
+
+	report := new(pipReport)
+	err = json.Unmarshal(content, report)
+	fail.On(err != nil, "Could not parse pip installation report, reason: %v", err)
+	result = make([]*LockedPip, 0, len(report.Install))
+	for _, entry := range report.Install {
+		name := entry.Metadata.Name
+		if provided[canonicalName(name)] {
+			common.Debug("Pip dependency %q is already provided by conda, not locked.", name)
+			continue
+		}
+		archive := entry.DownloadInfo.ArchiveInfo
+		fail.On(archive == nil, "Cannot lock pip dependency %q from %q, only archives with hashes can be locked.", name, entry.DownloadInfo.Url)
+		hashes := []string{}
+		for algorithm, digest := range archive.Hashes {
+			hashes = append(hashes, fmt.Sprintf("%s:%s", algorithm, digest))
+		}
+		if len(hashes) == 0 && len(archive.Hash) > 0 {
+			hashes = append(hashes, strings.Replace(archive.Hash, "=", ":", 1))
+		}
+		fail.On(len(hashes) == 0, "Cannot lock pip dependency %q, pip did not report any hashes for it.", name)
+		sort.Strings(hashes)
+		result = append(result, &LockedPip{
+			Name:    name,
+			Version: entry.Metadata.Version,
+			Url:     entry.DownloadInfo.Url,
+			Hashes:  hashes,
+		})
+	}
+	sort.SliceStable(result, func(left, right int) bool {
+		return canonicalName(result[left].Name) < canonicalName(result[right].Name)
+	})
+	return result, nil
+}
+
+func pythonMinor(packages []*LockedConda) (string, bool) {
+	for _, entry := range packages {
+		if entry.Name != "python" {
+			continue
+		}
+		parts := strings.Split(entry.Version, ".")
+		if len(parts) < 2 {
+			return "", false
+		}
+		return strings.Join(parts[:2], "."), true
+	}
+	return "", false
+}
+
+func solveCondaPlatform(condaYaml, platform string, target *LockPlatform) ([]*LockedConda, error) {
+	common.Timeline("lock: micromamba solve for %s", platform)
+	prefix := filepath.Join(os.TempDir(), fmt.Sprintf("rcc_lock_%x_%s", common.When, platform))
+	mambaCommand := common.NewCommander(BinMicromamba(), "create", "--dry-run", "--json", "--strict-channel-priority", "--repodata-ttl", "57600", "-y", "--platform", target.Subdir, "-f", condaYaml, "-p", prefix)
+	mambaCommand.Option("--channel-alias", settings.Global.CondaURL())
+	mambaCommand.ConditionalFlag(!settings.Global.HasMicroMambaRc(), "--no-rc")
+	mambaCommand.ConditionalFlag(settings.Global.HasMicroMambaRc(), "--rc-file", common.MicroMambaRcFile())
+	environment := append(CondaEnvironment(), target.Overrides...)
+	output, code, err := shell.New(environment, ".", mambaCommand.CLI()...).CaptureOutput()
+	if err != nil || code != 0 {
+		return nil, fmt.Errorf("Micromamba could not solve environment for platform %q [%s], code: %d, reason: %v", platform, target.Subdir, code, err)
+	}
+	return parseMambaDryRun([]byte(output))
+}
+
+func solvePipPlatform(python, requirementsText, platform, version string, target *LockPlatform, provided map[string]bool) ([]*LockedPip, error) {
+	common.Timeline("lock: pip resolve for %s", platform)
+	workspace, err := os.MkdirTemp("", "rcc_lock_pip")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workspace)
+	report := filepath.Join(workspace, "report.json")
+	command := []string{python, "-m", "pip", "install", "--isolated", "--no-color", "--disable-pip-version-check", "--quiet", "--dry-run", "--ignore-installed", "--only-binary=:all:", "--implementation", "cp", "--python-version", version, "--target", filepath.Join(workspace, "target"), "--report", report}
+	for _, tag := range target.PipTags {
+		command = append(command, "--platform", tag)
+	}
+	command = append(command, "--requirement", requirementsText)
+	_, code, err := shell.New(CondaEnvironment(), ".", command...).CaptureOutput()
+	if err != nil || code != 0 {
+		return nil, fmt.Errorf("Pip could not resolve requirements for platform %q, code: %d, reason: %v", platform, code, err)
+	}
+	content, err := os.ReadFile(report)
+	if err != nil {
+		return nil, err
+	}
+	return parsePipReport(content, provided)
+}
+
+func SolveLockfile(environment *Environment, source, python string, platforms []string) (lock *Lockfile, err error) {
+	defer fail.Around(&err)
+
+	fail.On(!MustMicromamba(), "Could not get micromamba installed.")
+	blueprint, err := environment.AsYaml()
+	fail.On(err != nil, "Could not create blueprint, reason: %v", err)
+	lock = &Lockfile{
+		Version:     LockfileVersion,
+		Source:      filepath.Base(source),
+		Blueprint:   common.BlueprintHash([]byte(blueprint)),
+		Generator:   fmt.Sprintf("rcc %s", common.Version),
+		Name:        environment.Name,
+		Channels:    environment.Channels,
+		PipOptions:  []string{},
+		PostInstall: environment.PostInstall,
+		Variables:   environment.Variables,
+		Platforms:   make(map[string]*LockedPlatform),
+	}
+	for _, dependency := range environment.Pip {
+		if strings.HasPrefix(dependency.Name, "-") {
+			lock.PipOptions = append(lock.PipOptions, dependency.Original)
+		}
+	}
+	workspace, err := os.MkdirTemp("", "rcc_lock")
+	fail.On(err != nil, "Could not create temporary directory, reason: %v", err)
+	defer os.RemoveAll(workspace)
+
+	for _, platform := range platforms {
+		target, ok := LockablePlatform(platform)
+		fail.On(!ok, "Platform %q cannot be locked, supported platforms are %q.", platform, LockablePlatforms())
+		common.Log("Locking environment for platform %s [%s].", platform, target.Subdir)
+		selected, err := ReadPackageCondaYamlFor(source, platform)
+		fail.Fast(err)
+		pipNeeded := selected.HasPipRequirements()
+		fail.On(pipNeeded && len(python) == 0, "Pip dependencies need python to be resolved, but there is none available.")
+		condaYaml := filepath.Join(workspace, fmt.Sprintf("%s_conda.yaml", platform))
+		requirementsText := filepath.Join(workspace, fmt.Sprintf("%s_requirements.txt", platform))
+		fail.Fast(selected.AsPureConda().SaveAs(condaYaml))
+		fail.Fast(selected.SaveAsRequirements(requirementsText))
+		packages, err := solveCondaPlatform(condaYaml, platform, target)
+		fail.Fast(err)
+		locked := &LockedPlatform{
+			Subdir: target.Subdir,
+			Conda:  packages,
+			Pip:    []*LockedPip{},
+		}
+		if pipNeeded {
+			version, ok := pythonMinor(packages)
+			fail.On(!ok, "There is no python in conda solution for platform %q, cannot resolve pip dependencies.", platform)
+			provided := make(map[string]bool)
+			for _, entry := range packages {
+				provided[canonicalName(entry.Name)] = true
+			}
+			locked.Pip, err = solvePipPlatform(python, requirementsText, platform, version, target, provided)
+			fail.Fast(err)
+		}
+		lock.Platforms[strings.ToLower(platform)] = locked
+	}
+	return lock, nil
+}
+
+func (it *Environment) LockPlatforms(wanted []string) []string {
+	if len(wanted) > 0 {
+		return wanted
+	}
+	if len(it.Platforms) > 0 {
+		return it.Platforms
+	}
+	return []string{common.Platform()}
+}
+
+func (it *Environment) HasPipRequirements() bool {
+	for _, dependency := range it.Pip {
+		if !strings.HasPrefix(dependency.Name, "-") {
+			return true
+		}
+	}
+	return false
+}
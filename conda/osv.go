@@ -0,0 +1,548 @@
+package conda
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/fail"
+	"github.com/robocorp/rcc/pathlib"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	SeverityUnknown  = "unknown"
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+
+	osvPypi         = "pypi"
+	osvConda        = "conda"
+	osvCondaMapping = "conda-mapping.yaml"
+)
+
+var (
+	severityRanks = map[string]int{
+		SeverityUnknown:  0,
+		SeverityLow:      1,
+		SeverityMedium:   2,
+		"moderate":       2,
+		SeverityHigh:     3,
+		SeverityCritical: 4,
+	}
+)
+
+type (
+	osvEvent struct {
+		Introduced   string `json:"introduced"`
+		Fixed        string `json:"fixed"`
+		LastAffected string `json:"last_affected"`
+		Limit        string `json:"limit"`
+	}
+
+	osvRange struct {
+		Type   string      `json:"type"`
+		Events []*osvEvent `json:"events"`
+	}
+
+	osvPackage struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	}
+
+	osvAffected struct {
+		Package  osvPackage  `json:"package"`
+		Ranges   []*osvRange `json:"ranges"`
+		Versions []string    `json:"versions"`
+	}
+
+	osvSeverity struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	}
+
+	osvReference struct {
+		Type string `json:"type"`
+		Url  string `json:"url"`
+	}
+
+	Advisory struct {
+		Id               string          `json:"id"`
+		Aliases          []string        `json:"aliases"`
+		Summary          string          `json:"summary"`
+		Withdrawn        string          `json:"withdrawn"`
+		Severity         []*osvSeverity  `json:"severity"`
+		Affected         []*osvAffected  `json:"affected"`
+		References       []*osvReference `json:"references"`
+		DatabaseSpecific map[string]any  `json:"database_specific"`
+	}
+
+	VulnerabilityDatabase struct {
+		Location   string
+		Advisories int
+		index      map[string][]*Advisory
+		mapping    map[string]string
+	}
+
+	Finding struct {
+		Package  string   `json:"package"`
+		Version  string   `json:"version"`
+		Origin   string   `json:"origin"`
+		Advisory string   `json:"advisory"`
+		Aliases  []string `json:"aliases,omitempty"`
+		Summary  string   `json:"summary,omitempty"`
+		Severity string   `json:"severity"`
+		Score    float64  `json:"score,omitempty"`
+		Fixed    []string `json:"fixed"`
+		Link     string   `json:"link,omitempty"`
+	}
+
+	AuditReport struct {
+		Database   string     `json:"database"`
+		Advisories int        `json:"advisories"`
+		Packages   int        `json:"packages"`
+		Findings   []*Finding `json:"findings"`
+	}
+)
+
+func SeverityRank(severity string) (int, bool) {
+	rank, ok := severityRanks[strings.ToLower(strings.TrimSpace(severity))]
+	return rank, ok
+}
+
+func canonicalName(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "-", ".", "-").Replace(strings.TrimSpace(name)))
+}
+
+func osvKey(ecosystem, name string) string {
+	return strings.ToLower(ecosystem) + ":" + canonicalName(name)
+}
+
+func LoadVulnerabilityDatabase(location string) (result *VulnerabilityDatabase, err error) {
+	defer fail.Around(&err)
+
+	common.TimelineBegin("OSV database load start [%s]", location)
+	defer common.TimelineEnd()
+
+	fail.On(!pathlib.Exists(location), "OSV database %q does not exist. Download OSV dump (for example PyPI all.zip) there first.", location)
+	result = &VulnerabilityDatabase{
+		Location: location,
+		index:    make(map[string][]*Advisory),
+		mapping:  make(map[string]string),
+	}
+	if pathlib.IsFile(location) {
+		fail.Fast(result.loadFile(location))
+		return result, nil
+	}
+	err = filepath.WalkDir(location, func(fullpath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		if entry.Name() == osvCondaMapping {
+			return result.loadMapping(fullpath)
+		}
+		return result.loadFile(fullpath)
+	})
+	fail.On(err != nil, "Failed to load OSV database %q, reason: %v", location, err)
+	common.Debug("Loaded %d OSV advisories from %q.", result.Advisories, location)
+	return result, nil
+}
+
+func (it *VulnerabilityDatabase) loadMapping(filename string) error {
+	body, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	mapping := make(map[string]string)
+	err = yaml.Unmarshal(body, &mapping)
+	if err != nil {
+		return fmt.Errorf("%q: %w", filename, err)
+	}
+	for conda, pypi := range mapping {
+		it.mapping[canonicalName(conda)] = pypi
+	}
+	return nil
+}
+
+func (it *VulnerabilityDatabase) loadFile(filename string) error {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".zip":
+		return it.loadZip(filename)
+	case ".json":
+		body, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		return it.loadJson(filename, body)
+	}
+	return nil
+}
+
+func (it *VulnerabilityDatabase) loadZip(filename string) error {
+	archive, err := zip.OpenReader(filename)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	for _, entry := range archive.File {
+		if strings.ToLower(filepath.Ext(entry.Name)) != ".json" {
+			continue
+		}
+		reader, err := entry.Open()
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+		err = it.loadJson(filename+":"+entry.Name, body)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (it *VulnerabilityDatabase) loadJson(filename string, body []byte) error {
+	advisories := []*Advisory{}
+	trimmed := strings.TrimSpace(string(body))
+	if strings.HasPrefix(trimmed, "[") {
+		err := json.Unmarshal(body, &advisories)
+		if err != nil {
+			return fmt.Errorf("%q: %w", filename, err)
+		}
+	} else {
+		advisory := &Advisory{}
+		err := json.Unmarshal(body, advisory)
+		if err != nil {
+			return fmt.Errorf("%q: %w", filename, err)
+		}
+		advisories = append(advisories, advisory)
+	}
+	for _, advisory := range advisories {
+		it.Add(advisory)
+	}
+	return nil
+}
+
+func (it *VulnerabilityDatabase) Add(advisory *Advisory) {
+	if len(advisory.Id) == 0 || len(advisory.Withdrawn) > 0 {
+		return
+	}
+	seen := make(map[string]bool)
+	for _, affected := range advisory.Affected {
+		key := osvKey(affected.Package.Ecosystem, affected.Package.Name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		it.index[key] = append(it.index[key], advisory)
+	}
+	it.Advisories += 1
+}
+
+func (it *VulnerabilityDatabase) candidates(entry *dependency) []string {
+	if entry.Origin == osvPypi {
+		return []string{osvKey(osvPypi, entry.Name)}
+	}
+	result := []string{osvKey(osvConda, entry.Name)}
+	pypi, ok := it.mapping[canonicalName(entry.Name)]
+	if !ok {
+		pypi = entry.Name
+	}
+	if len(pypi) > 0 {
+		result = append(result, osvKey(osvPypi, pypi))
+	}
+	return result
+}
+
+func (it osvEvent) version() string {
+	for _, version := range []string{it.Introduced, it.Fixed, it.LastAffected, it.Limit} {
+		if len(version) > 0 {
+			return version
+		}
+	}
+	return ""
+}
+
+func (it *osvRange) affects(version *Version) bool {
+	if it.Type != "ECOSYSTEM" && it.Type != "SEMVER" {
+		return false
+	}
+	events := make([]*osvEvent, len(it.Events))
+	copy(events, it.Events)
+	sort.SliceStable(events, func(left, right int) bool {
+		if events[left].Introduced == "0" {
+			return events[right].Introduced != "0"
+		}
+		if events[right].Introduced == "0" {
+			return false
+		}
+		return CompareVersions(events[left].version(), events[right].version()) < 0
+	})
+	affected := false
+	for _, event := range events {
+		switch {
+		case len(event.Introduced) > 0:
+			if event.Introduced == "0" || version.Compare(ParseVersion(event.Introduced)) >= 0 {
+				affected = true
+			}
+		case len(event.Fixed) > 0:
+			if version.Compare(ParseVersion(event.Fixed)) >= 0 {
+				affected = false
+			}
+		case len(event.LastAffected) > 0:
+			if version.Compare(ParseVersion(event.LastAffected)) > 0 {
+				affected = false
+			}
+		case len(event.Limit) > 0:
+			if version.Compare(ParseVersion(event.Limit)) >= 0 {
+				affected = false
+			}
+		}
+	}
+	return affected
+}
+
+func (it *osvAffected) affects(version *Version) bool {
+	for _, listed := range it.Versions {
+		if version.Compare(ParseVersion(listed)) == 0 {
+			return true
+		}
+	}
+	for _, span := range it.Ranges {
+		if span.affects(version) {
+			return true
+		}
+	}
+	return false
+}
+
+func (it *osvAffected) fixed() []string {
+	result := []string{}
+	for _, span := range it.Ranges {
+		for _, event := range span.Events {
+			if len(event.Fixed) > 0 {
+				result = append(result, event.Fixed)
+			}
+		}
+	}
+	return result
+}
+
+func (it *Advisory) Rating() (string, float64) {
+	for _, severity := range it.Severity {
+		if strings.HasPrefix(severity.Type, "CVSS_V3") {
+			score, ok := CvssV3Score(severity.Score)
+			if ok {
+				return CvssSeverity(score), score
+			}
+		}
+	}
+	declared, ok := it.DatabaseSpecific["severity"].(string)
+	if ok {
+		rank, ok := SeverityRank(declared)
+		if ok && rank > 0 {
+			if rank == severityRanks[SeverityMedium] {
+				return SeverityMedium, 0
+			}
+			return strings.ToLower(declared), 0
+		}
+	}
+	return SeverityUnknown, 0
+}
+
+func (it *Advisory) Link() string {
+	for _, reference := range it.References {
+		if reference.Type == "ADVISORY" {
+			return reference.Url
+		}
+	}
+	if len(it.References) > 0 {
+		return it.References[0].Url
+	}
+	return ""
+}
+
+func (it dependencies) Audit(database *VulnerabilityDatabase) *AuditReport {
+	report := &AuditReport{
+		Database:   database.Location,
+		Advisories: database.Advisories,
+		Packages:   len(it),
+		Findings:   []*Finding{},
+	}
+	for _, entry := range it {
+		version := ParseVersion(entry.Version)
+		seen := make(map[string]bool)
+		for _, key := range database.candidates(entry) {
+			for _, advisory := range database.index[key] {
+				if seen[advisory.Id] {
+					continue
+				}
+				fixed := []string{}
+				affected := false
+				for _, candidate := range advisory.Affected {
+					if osvKey(candidate.Package.Ecosystem, candidate.Package.Name) != key || !candidate.affects(version) {
+						continue
+					}
+					affected = true
+					fixed = append(fixed, candidate.fixed()...)
+				}
+				if !affected {
+					continue
+				}
+				seen[advisory.Id] = true
+				sort.SliceStable(fixed, func(left, right int) bool {
+					return CompareVersions(fixed[left], fixed[right]) < 0
+				})
+				severity, score := advisory.Rating()
+				report.Findings = append(report.Findings, &Finding{
+					Package:  entry.Name,
+					Version:  entry.Version,
+					Origin:   entry.Origin,
+					Advisory: advisory.Id,
+					Aliases:  advisory.Aliases,
+					Summary:  advisory.Summary,
+					Severity: severity,
+					Score:    score,
+					Fixed:    uniqueStrings(fixed),
+					Link:     advisory.Link(),
+				})
+			}
+		}
+	}
+	sort.SliceStable(report.Findings, func(left, right int) bool {
+		lefty, _ := SeverityRank(report.Findings[left].Severity)
+		righty, _ := SeverityRank(report.Findings[right].Severity)
+		if lefty != righty {
+			return lefty > righty
+		}
+		if report.Findings[left].Package != report.Findings[right].Package {
+			return report.Findings[left].Package < report.Findings[right].Package
+		}
+		return report.Findings[left].Advisory < report.Findings[right].Advisory
+	})
+	return report
+}
+
+func (it *AuditReport) Worst() string {
+	result, worst := SeverityUnknown, -1
+	for _, finding := range it.Findings {
+		rank, _ := SeverityRank(finding.Severity)
+		if rank > worst {
+			result, worst = finding.Severity, rank
+		}
+	}
+	return result
+}
+
+func (it *AuditReport) AtLeast(severity string) []*Finding {
+	limit, _ := SeverityRank(severity)
+	result := []*Finding{}
+	for _, finding := range it.Findings {
+		rank, _ := SeverityRank(finding.Severity)
+		if rank >= limit {
+			result = append(result, finding)
+		}
+	}
+	return result
+}
+
+func uniqueStrings(values []string) []string {
+	result := []string{}
+	seen := make(map[string]bool)
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func cvssRoundup(value float64) float64 {
+	scaled := int64(math.Round(value * 100000))
+	if scaled%10000 == 0 {
+		return float64(scaled) / 100000.0
+	}
+	return float64(scaled/10000+1) / 10.0
+}
+
+func CvssV3Score(vector string) (float64, bool) {
+	weights := map[string]map[string]float64{
+		"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+		"AC": {"L": 0.77, "H": 0.44},
+		"UI": {"N": 0.85, "R": 0.62},
+		"C":  {"H": 0.56, "L": 0.22, "N": 0},
+		"I":  {"H": 0.56, "L": 0.22, "N": 0},
+		"A":  {"H": 0.56, "L": 0.22, "N": 0},
+	}
+	metrics := make(map[string]string)
+	for _, part := range strings.Split(vector, "/") {
+		pair := strings.SplitN(part, ":", 2)
+		if len(pair) == 2 {
+			metrics[pair[0]] = pair[1]
+		}
+	}
+	changed := metrics["S"] == "C"
+	if !changed && metrics["S"] != "U" {
+		return 0, false
+	}
+	values := make(map[string]float64)
+	for metric, options := range weights {
+		value, ok := options[metrics[metric]]
+		if !ok {
+			return 0, false
+		}
+		values[metric] = value
+	}
+	switch metrics["PR"] {
+	case "N":
+		values["PR"] = 0.85
+	case "L":
+		values["PR"] = map[bool]float64{false: 0.62, true: 0.68}[changed]
+	case "H":
+		values["PR"] = map[bool]float64{false: 0.27, true: 0.5}[changed]
+	default:
+		return 0, false
+	}
+	iss := 1 - (1-values["C"])*(1-values["I"])*(1-values["A"])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	exploitability := 8.22 * values["AV"] * values["AC"] * values["PR"] * values["UI"]
+	if impact <= 0 {
+		return 0, true
+	}
+	if changed {
+		return cvssRoundup(math.Min(1.08*(impact+exploitability), 10)), true
+	}
+	return cvssRoundup(math.Min(impact+exploitability, 10)), true
+}
+
+func CvssSeverity(score float64) string {
+	switch {
+	case score >= 9.0:
+		return SeverityCritical
+	case score >= 7.0:
+		return SeverityHigh
+	case score >= 4.0:
+		return SeverityMedium
+	case score > 0:
+		return SeverityLow
+	default:
+		return SeverityUnknown
+	}
+}
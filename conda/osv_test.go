@@ -0,0 +1,83 @@
+package conda_test
+
+import (
+	"testing"
+
+	"github.com/robocorp/rcc/conda"
+	"github.com/robocorp/rcc/hamlet"
+)
+
+const auditedDependencies = `
+- name: openssl
+  version: 3.0.5
+  origin: conda-forge
+- name: python
+  version: 3.10.12
+  origin: conda-forge
+- name: pyyaml
+  version: 6.0
+  origin: conda-forge
+- name: requests
+  version: 2.28.0
+  origin: pypi
+- name: urllib3
+  version: 2.0.0
+  origin: pypi
+`
+
+func TestCanScoreCvssVectors(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	score, ok := conda.CvssV3Score("CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H")
+	must.True(ok)
+	must.Equal(9.8, score)
+	score, ok = conda.CvssV3Score("CVSS:3.1/AV:N/AC:H/PR:N/UI:R/S:C/C:H/I:N/A:N")
+	must.True(ok)
+	must.Equal(6.1, score)
+	score, ok = conda.CvssV3Score("CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:N/I:N/A:N")
+	must.True(ok)
+	must.Equal(0.0, score)
+	_, ok = conda.CvssV3Score("CVSS:3.1/AV:X")
+	wont.True(ok)
+
+	must.Equal(conda.SeverityCritical, conda.CvssSeverity(9.8))
+	must.Equal(conda.SeverityMedium, conda.CvssSeverity(6.1))
+	must.Equal(conda.SeverityLow, conda.CvssSeverity(0.1))
+}
+
+func TestCanAuditDependenciesAgainstOsvDatabase(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	_, err := conda.LoadVulnerabilityDatabase("testdata/missing")
+	wont.Nil(err)
+
+	database, err := conda.LoadVulnerabilityDatabase("testdata/osv")
+	must.Nil(err)
+	must.Equal(3, database.Advisories)
+
+	report := conda.ParseWantedDependencies([]byte(auditedDependencies)).Audit(database)
+	must.Equal(5, report.Packages)
+	must.Equal(3, len(report.Findings))
+
+	must.Equal("PYSEC-0000-1", report.Findings[0].Advisory)
+	must.Equal("pyyaml", report.Findings[0].Package)
+	must.Equal(conda.SeverityCritical, report.Findings[0].Severity)
+	must.Equal([]string{"5.4"}, report.Findings[0].Fixed)
+
+	must.Equal("OSV-CONDA-1", report.Findings[1].Advisory)
+	must.Equal(conda.SeverityHigh, report.Findings[1].Severity)
+	must.Equal([]string{}, report.Findings[1].Fixed)
+
+	must.Equal("GHSA-j8r2-6x86-q33q", report.Findings[2].Advisory)
+	must.Equal([]string{"CVE-2023-32681"}, report.Findings[2].Aliases)
+	must.Equal(conda.SeverityMedium, report.Findings[2].Severity)
+	must.Equal([]string{"2.31.0"}, report.Findings[2].Fixed)
+	must.Equal("https://nvd.nist.gov/vuln/detail/CVE-2023-32681", report.Findings[2].Link)
+
+	must.Equal(conda.SeverityCritical, report.Worst())
+	must.Equal(2, len(report.AtLeast(conda.SeverityHigh)))
+	must.Equal(3, len(report.AtLeast(conda.SeverityLow)))
+
+	fixed := conda.ParseWantedDependencies([]byte("- name: requests\n  version: 2.31.0\n  origin: pypi\n- name: pyyaml\n  version: 5.4.1\n  origin: conda-forge\n")).Audit(database)
+	must.Equal(0, len(fixed.Findings))
+}
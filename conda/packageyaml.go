@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/robocorp/rcc/cloud"
+	"github.com/robocorp/rcc/common"
 	"github.com/robocorp/rcc/pathlib"
 	"gopkg.in/yaml.v2"
 )
@@ -77,6 +78,10 @@ func packageYamlFrom(content []byte) (*Environment, error) {
 }
 
 func ReadPackageCondaYaml(filename string) (*Environment, error) {
+	return ReadPackageCondaYamlFor(filename, common.Platform())
+}
+
+func ReadPackageCondaYamlFor(filename, platform string) (*Environment, error) {
 	basename := strings.ToLower(filepath.Base(filename))
 	if basename == "package.yaml" {
 		environment, err := ReadPackageYaml(filename)
@@ -84,7 +89,13 @@ func ReadPackageCondaYaml(filename string) (*Environment, error) {
 			return environment, nil
 		}
 	}
-	return readCondaYaml(filename)
+	if IsPyprojectToml(filename) {
+		return readPyprojectToml(filename, platform)
+	}
+	if IsRequirementsText(filename) {
+		return readRequirementsText(filename, platform)
+	}
+	return readCondaYaml(filename, platform)
 }
 
 func ReadPackageYaml(filename string) (*Environment, error) {
@@ -99,5 +110,9 @@ func ReadPackageYaml(filename string) (*Environment, error) {
 	if err != nil {
 		return nil, fmt.Errorf("%q: %w", filename, err)
 	}
-	return packageYamlFrom(content)
+	environment, err := packageYamlFrom(content)
+	if err != nil {
+		return nil, err
+	}
+	return environment.withSource(filename), nil
 }
@@ -0,0 +1,271 @@
+package conda
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/pretty"
+	"github.com/robocorp/rcc/settings"
+)
+
+const (
+	RuleChannel = "channel"
+	RuleIndex   = "index"
+	RuleBanned  = "banned"
+	RulePinned  = "pinned"
+	RuleLicense = "license"
+
+	defaultPypiIndex = "https://pypi.org/simple"
+)
+
+var (
+	indexOptions = map[string]bool{"--index-url": true, "-i": true, "--extra-index-url": true, "--find-links": true, "-f": true}
+)
+
+type (
+	PolicyViolation struct {
+		Rule    string `json:"rule"`
+		Subject string `json:"subject"`
+		Message string `json:"message"`
+	}
+
+	PolicyReport struct {
+		AuditOnly  bool               `json:"audit-only"`
+		Violations []*PolicyViolation `json:"violations"`
+		policy     *settings.Policy
+	}
+)
+
+func NewPolicyReport(policy *settings.Policy) *PolicyReport {
+	return &PolicyReport{
+		AuditOnly:  policy.AuditOnly(),
+		Violations: []*PolicyViolation{},
+		policy:     policy,
+	}
+}
+
+func (it *PolicyReport) add(rule, subject, form string, details ...any) {
+	it.Violations = append(it.Violations, &PolicyViolation{
+		Rule:    rule,
+		Subject: subject,
+		Message: fmt.Sprintf(form, details...),
+	})
+}
+
+func (it *PolicyReport) Blocking() bool {
+	return len(it.Violations) > 0 && !it.AuditOnly
+}
+
+func (it *PolicyReport) Show(phase string) {
+	if len(it.Violations) == 0 {
+		common.Debug("Dependency policy %s: no violations.", phase)
+		return
+	}
+	mode := "enforced"
+	if it.AuditOnly {
+		mode = "audit only"
+	}
+	pretty.Warning("Dependency policy %s found %d violations [%s]:", phase, len(it.Violations), mode)
+	for _, violation := range it.Violations {
+		pretty.Warning("- [%s] %s: %s", violation.Rule, violation.Subject, violation.Message)
+	}
+}
+
+func (it *PolicyReport) Err() error {
+	if !it.Blocking() {
+		return nil
+	}
+	return fmt.Errorf("Dependency policy blocks the build, because of %d violations. See policy section in settings.yaml.", len(it.Violations))
+}
+
+func (it *Dependency) IsPinned() bool {
+	if !it.constrained() || strings.Contains(it.Versions, "*") || strings.ContainsAny(it.Versions, ",<>|") {
+		return false
+	}
+	switch it.Qualifier {
+	case "=", "==", "===":
+		return true
+	}
+	return false
+}
+
+func normalizeLocation(location string) string {
+	return strings.TrimRight(strings.ToLower(strings.TrimSpace(location)), "/")
+}
+
+func allowedLocation(location string, allowed []string) bool {
+	wanted := normalizeLocation(location)
+	for _, candidate := range allowed {
+		accepted := normalizeLocation(candidate)
+		if wanted == accepted || strings.HasSuffix(wanted, "/"+accepted) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeLicense(license string) string {
+	flat := strings.ToLower(strings.TrimSpace(license))
+	flat = strings.NewReplacer(" ", "-", "_", "-").Replace(flat)
+	if len(flat) == 0 {
+		return "unknown"
+	}
+	return flat
+}
+
+func AllowedLicense(license string, allowed []string) bool {
+	accepted := make(map[string]bool)
+	for _, candidate := range allowed {
+		accepted[normalizeLicense(candidate)] = true
+	}
+	if accepted[normalizeLicense(license)] {
+		return true
+	}
+	expression := strings.ToLower(strings.NewReplacer("(", " ", ")", " ").Replace(license))
+	if len(strings.TrimSpace(expression)) == 0 {
+		return false
+	}
+	for _, alternative := range strings.Split(expression, " or ") {
+		all := true
+		for _, part := range strings.Split(alternative, " and ") {
+			if !accepted[normalizeLicense(part)] {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true
+		}
+	}
+	return false
+}
+
+func (it *PolicyReport) banned(name, version string, exact bool) {
+	wanted := canonicalName(strings.SplitN(name, "[", 2)[0])
+	for _, rule := range it.policy.Banned {
+		ban := AsDependency(rule)
+		if ban == nil || canonicalName(ban.Name) != wanted {
+			continue
+		}
+		specifiers := ban.Qualifier + ban.Versions
+		if len(specifiers) == 0 {
+			it.add(RuleBanned, name, "package is banned by rule %q", rule)
+			continue
+		}
+		if !exact {
+			continue
+		}
+		matches, err := MatchesSpecifiers(version, specifiers)
+		if err != nil {
+			it.add(RuleBanned, rule, "invalid banned rule: %v", err)
+			continue
+		}
+		if matches {
+			it.add(RuleBanned, name, "version %s is banned by rule %q", version, rule)
+		}
+	}
+}
+
+func (it *Environment) CheckPolicy(policy *settings.Policy) *PolicyReport {
+	report := NewPolicyReport(policy)
+	if policy.IsEmpty() {
+		return report
+	}
+	local, _ := LocalChannel()
+	if len(policy.Channels) > 0 {
+		for _, channel := range it.Channels {
+			if channel == local || allowedLocation(channel, policy.Channels) {
+				continue
+			}
+			report.add(RuleChannel, channel, "conda channel is not in allowed-channels %q", policy.Channels)
+		}
+		for _, dependency := range it.Conda {
+			if channel, _, _, ok := explicitPackage(dependency.Name); ok {
+				if !allowedLocation(channel, policy.Channels) {
+					report.add(RuleChannel, dependency.Original, "conda channel %q is not in allowed-channels %q", channel, policy.Channels)
+				}
+				continue
+			}
+			parts := strings.SplitN(dependency.Name, "::", 2)
+			if len(parts) == 2 && !allowedLocation(parts[0], policy.Channels) {
+				report.add(RuleChannel, dependency.Original, "conda channel %q is not in allowed-channels %q", parts[0], policy.Channels)
+			}
+		}
+	}
+	if len(policy.Indexes) > 0 {
+		primary := false
+		for _, dependency := range it.Pip {
+			if !indexOptions[dependency.Name] {
+				continue
+			}
+			primary = primary || dependency.Name == "--index-url" || dependency.Name == "-i"
+			if !allowedLocation(dependency.Versions, policy.Indexes) {
+				report.add(RuleIndex, dependency.Versions, "pip %s is not in allowed-indexes %q", dependency.Name, policy.Indexes)
+			}
+		}
+		index := settings.Global.PypiURL()
+		if len(index) == 0 {
+			index = defaultPypiIndex
+		}
+		if !primary && len(it.Pip) > 0 && !allowedLocation(index, policy.Indexes) {
+			report.add(RuleIndex, index, "default pip index is not in allowed-indexes %q", policy.Indexes)
+		}
+	}
+	for _, dependency := range it.Conda {
+		if _, name, version, ok := explicitPackage(dependency.Name); ok {
+			report.banned(name, version, true)
+			continue
+		}
+		name := dependency.Name
+		if parts := strings.SplitN(name, "::", 2); len(parts) == 2 {
+			name = parts[1]
+		}
+		if policy.RequirePinned && !dependency.IsPinned() {
+			report.add(RulePinned, dependency.Original, "conda dependency is not pinned to exact version")
+		}
+		report.banned(name, dependency.Versions, dependency.IsPinned())
+	}
+	for _, dependency := range it.Pip {
+		if strings.HasPrefix(dependency.Name, "-") || IsSpecialCacheable(dependency.Name, dependency.Versions) {
+			continue
+		}
+		if policy.RequirePinned && !dependency.IsPinned() {
+			report.add(RulePinned, dependency.Original, "pip dependency is not pinned to exact version")
+		}
+		report.banned(dependency.Name, dependency.Versions, dependency.IsPinned())
+	}
+	return report
+}
+
+func (it *PolicyReport) CheckInstalled(name, version, license string) {
+	if it.policy.IsEmpty() {
+		return
+	}
+	it.banned(name, version, true)
+	if len(it.policy.Licenses) > 0 && !AllowedLicense(license, it.policy.Licenses) {
+		if len(strings.TrimSpace(license)) == 0 {
+			license = "unknown"
+		}
+		it.add(RuleLicense, fmt.Sprintf("%s %s", name, version), "license %q is not in allowed-licenses", license)
+	}
+}
+
+func (it *Environment) policyDiagnostics(target *common.DiagnosticStatus) {
+	policy := settings.Global.Policy()
+	if policy.IsEmpty() {
+		return
+	}
+	diagnose := target.Diagnose("Policy")
+	notice := diagnose.Fail
+	if policy.AuditOnly() {
+		notice = diagnose.Warning
+	}
+	report := it.CheckPolicy(policy)
+	for _, violation := range report.Violations {
+		notice(0, "", "Dependency policy [%s] %s: %s", violation.Rule, violation.Subject, violation.Message)
+	}
+	if len(report.Violations) == 0 {
+		diagnose.Ok(0, "Dependencies in conda.yaml follow dependency policy.")
+	}
+}
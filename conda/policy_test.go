@@ -0,0 +1,85 @@
+package conda_test
+
+import (
+	"testing"
+
+	"github.com/robocorp/rcc/conda"
+	"github.com/robocorp/rcc/hamlet"
+	"github.com/robocorp/rcc/settings"
+)
+
+const policedEnvironment = `
+channels:
+- conda-forge
+- defaults
+dependencies:
+- python=3.10.12
+- nodejs
+- pip=23.2
+- pip:
+  - --extra-index-url https://private.example.com/simple
+  - requests==2.28.0
+  - pyyaml==5.3.1
+  - rpaframework>=27.0
+  - telnetlib3==2.0.4
+`
+
+func TestCanCheckEnvironmentAgainstPolicy(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	environment, err := conda.CondaYamlFrom([]byte(policedEnvironment))
+	must.Nil(err)
+
+	empty := environment.CheckPolicy(nil)
+	must.Equal(0, len(empty.Violations))
+	wont.True(empty.Blocking())
+	must.Nil(empty.Err())
+
+	policy := &settings.Policy{
+		Channels:      []string{"conda-forge"},
+		Indexes:       []string{"https://pypi.org/simple/", "https://mirror.example.com/simple"},
+		Banned:        []string{"pyyaml<5.4", "telnetlib3", "rpaframework<20"},
+		RequirePinned: true,
+	}
+	report := environment.CheckPolicy(policy)
+	rules := make(map[string]int)
+	for _, violation := range report.Violations {
+		rules[violation.Rule] += 1
+	}
+	must.Equal(1, rules[conda.RuleChannel])
+	must.Equal(1, rules[conda.RuleIndex])
+	must.Equal(2, rules[conda.RulePinned])
+	must.Equal(2, rules[conda.RuleBanned])
+	must.True(report.Blocking())
+	wont.Nil(report.Err())
+
+	policy.Mode = settings.PolicyAudit
+	report = environment.CheckPolicy(policy)
+	must.Equal(6, len(report.Violations))
+	wont.True(report.Blocking())
+	must.Nil(report.Err())
+
+	report = conda.NewPolicyReport(&settings.Policy{Banned: []string{"openssl>=3.0.0,<3.0.7"}, Licenses: []string{"MIT", "Apache-2.0", "BSD 3-Clause"}})
+	report.CheckInstalled("openssl", "3.0.5", "Apache-2.0")
+	report.CheckInstalled("openssl", "3.0.7", "Apache-2.0")
+	report.CheckInstalled("six", "1.16.0", "MIT")
+	report.CheckInstalled("mystery", "1.0", "")
+	report.CheckInstalled("gpl", "1.0", "GPL-3.0-only")
+	must.Equal(3, len(report.Violations))
+	must.Equal(conda.RuleBanned, report.Violations[0].Rule)
+	must.Equal("mystery 1.0", report.Violations[1].Subject)
+	must.Equal("gpl 1.0", report.Violations[2].Subject)
+}
+
+func TestCanMatchLicenseExpressions(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	allowed := []string{"MIT", "Apache-2.0", "BSD-3-Clause"}
+	must.True(conda.AllowedLicense("mit", allowed))
+	must.True(conda.AllowedLicense("BSD 3-Clause", allowed))
+	must.True(conda.AllowedLicense("MIT OR GPL-3.0-only", allowed))
+	must.True(conda.AllowedLicense("(Apache-2.0 AND MIT)", allowed))
+	wont.True(conda.AllowedLicense("Apache-2.0 AND GPL-3.0-only", allowed))
+	wont.True(conda.AllowedLicense("", allowed))
+	must.True(conda.AllowedLicense("", append(allowed, "unknown")))
+}
@@ -0,0 +1,253 @@
+package conda
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/robocorp/rcc/cloud"
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/pathlib"
+)
+
+const (
+	defaultPython = "python=3.10.12"
+	defaultPip    = "pip=23.2.1"
+)
+
+type (
+	pyprojectToml struct {
+		Project struct {
+			Name           string   `toml:"name"`
+			RequiresPython string   `toml:"requires-python"`
+			Dependencies   []string `toml:"dependencies"`
+		} `toml:"project"`
+	}
+)
+
+func IsPyprojectToml(filename string) bool {
+	return strings.ToLower(filepath.Base(filename)) == "pyproject.toml"
+}
+
+func IsRequirementsText(filename string) bool {
+	basename := strings.ToLower(filepath.Base(filename))
+	return strings.HasSuffix(basename, ".txt") && strings.Contains(basename, "requirements")
+}
+
+func pythonFromRequires(requires string) (string, error) {
+	if len(strings.TrimSpace(requires)) == 0 {
+		return defaultPython, nil
+	}
+	clauses, err := ParseSpecifiers(requires)
+	if err != nil {
+		return "", fmt.Errorf("Invalid requires-python %q: %w", requires, err)
+	}
+	parts := make([]string, 0, len(clauses)+1)
+	for _, clause := range clauses {
+		switch clause.Operator {
+		case "~=":
+			parts = append(parts, ">="+clause.Version)
+			release := strings.Split(clause.Version, ".")
+			if len(release) > 1 {
+				upper, ok := prefixUpper(strings.Join(release[:len(release)-1], "."))
+				if ok {
+					parts = append(parts, "<"+upper)
+				}
+			}
+		case "===":
+			parts = append(parts, "=="+clause.Version)
+		default:
+			parts = append(parts, clause.render(true))
+		}
+	}
+	spec := strings.Join(parts, ",")
+	if len(spec) > 0 && !strings.ContainsAny(spec[:1], "<=>!~") {
+		return "python " + spec, nil
+	}
+	return "python" + spec, nil
+}
+
+func descriptorEnvironment(name, requires string, requirements []string, platform string) (*Environment, error) {
+	python, err := pythonFromRequires(requires)
+	if err != nil {
+		return nil, err
+	}
+	pip := make([]interface{}, 0, len(requirements))
+	for _, requirement := range requirements {
+		pip = append(pip, requirement)
+	}
+	dependencies := []interface{}{python, defaultPip}
+	if len(pip) > 0 {
+		dependencies = append(dependencies, map[interface{}]interface{}{"pip": pip})
+	}
+	internal := &internalEnvironment{
+		Name:         name,
+		Channels:     []string{"conda-forge"},
+		Dependencies: dependencies,
+	}
+	err = internal.selectFor(platform)
+	if err != nil {
+		return nil, err
+	}
+	result := internal.AsEnvironment()
+	return result, result.pipPromote()
+}
+
+func readDescriptor(filename string) ([]byte, error) {
+	var content []byte
+	var err error
+
+	if pathlib.IsFile(filename) {
+		content, err = os.ReadFile(filename)
+	} else {
+		content, err = cloud.ReadFile(filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", filename, err)
+	}
+	return content, nil
+}
+
+func pyprojectFrom(content []byte, platform string) (*Environment, error) {
+	project := new(pyprojectToml)
+	err := toml.Unmarshal(content, project)
+	if err != nil {
+		return nil, err
+	}
+	return descriptorEnvironment(project.Project.Name, project.Project.RequiresPython, project.Project.Dependencies, platform)
+}
+
+func ReadPyprojectToml(filename string) (*Environment, error) {
+	return readPyprojectToml(filename, common.Platform())
+}
+
+func readPyprojectToml(filename, platform string) (*Environment, error) {
+	content, err := readDescriptor(filename)
+	if err != nil {
+		return nil, err
+	}
+	environment, err := pyprojectFrom(content, platform)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", filename, err)
+	}
+	return environment.withSource(filename), nil
+}
+
+func requirementLines(content []byte) []string {
+	result := []string{}
+	joined := strings.ReplaceAll(strings.ReplaceAll(string(content), "\r\n", "\n"), "\\\n", " ")
+	for _, line := range strings.Split(joined, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		if at := strings.Index(line, " #"); at >= 0 {
+			line = line[:at]
+		}
+		flat := strings.Join(strings.Fields(line), " ")
+		if len(flat) > 0 {
+			result = append(result, flat)
+		}
+	}
+	return result
+}
+
+func optionValue(line string, options ...string) (string, bool) {
+	for _, option := range options {
+		for _, separator := range []string{" ", "="} {
+			if strings.HasPrefix(line, option+separator) {
+				return strings.TrimSpace(line[len(option)+1:]), true
+			}
+		}
+	}
+	return "", false
+}
+
+func remoteLocation(location string) (*url.URL, bool) {
+	if pathlib.IsFile(location) {
+		return nil, false
+	}
+	link, err := url.Parse(location)
+	if err != nil || len(link.Scheme) < 2 || link.Scheme == "file" {
+		return nil, false
+	}
+	return link, true
+}
+
+func includedLocation(filename, included string) string {
+	if _, ok := remoteLocation(included); ok {
+		return included
+	}
+	if base, ok := remoteLocation(filename); ok {
+		reference, err := url.Parse(filepath.ToSlash(included))
+		if err == nil {
+			return base.ResolveReference(reference).String()
+		}
+	}
+	if filepath.IsAbs(included) {
+		return included
+	}
+	return filepath.Join(filepath.Dir(filename), included)
+}
+
+func requirementsFrom(filename string, seen map[string]bool) ([]string, error) {
+	if seen[filename] {
+		return nil, fmt.Errorf("Requirements file %q is included recursively.", filename)
+	}
+	seen[filename] = true
+	defer delete(seen, filename)
+	content, err := readDescriptor(filename)
+	if err != nil {
+		return nil, err
+	}
+	result := []string{}
+	for _, line := range requirementLines(content) {
+		if included, ok := optionValue(line, "-r", "--requirement"); ok {
+			lines, err := requirementsFrom(includedLocation(filename, included), seen)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, lines...)
+			continue
+		}
+		if _, ok := optionValue(line, "-c", "--constraint"); ok {
+			return nil, fmt.Errorf("%q: constraint files are not supported, in line %q.", filename, line)
+		}
+		if _, ok := optionValue(line, "-e", "--editable"); ok {
+			return nil, fmt.Errorf("%q: editable installs are not supported, in line %q.", filename, line)
+		}
+		result = append(result, line)
+	}
+	return result, nil
+}
+
+func ReadRequirementsText(filename string) (*Environment, error) {
+	return readRequirementsText(filename, common.Platform())
+}
+
+func uniqueLines(lines []string) []string {
+	result := make([]string, 0, len(lines))
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		if seen[line] {
+			continue
+		}
+		seen[line] = true
+		result = append(result, line)
+	}
+	return result
+}
+
+func readRequirementsText(filename, platform string) (*Environment, error) {
+	requirements, err := requirementsFrom(filename, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+	environment, err := descriptorEnvironment("", "", uniqueLines(requirements), platform)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", filename, err)
+	}
+	return environment.withSource(filename), nil
+}
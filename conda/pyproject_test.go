@@ -0,0 +1,73 @@
+package conda_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/robocorp/rcc/conda"
+	"github.com/robocorp/rcc/hamlet"
+)
+
+func TestCanReadPyprojectTomlAsEnvironment(t *testing.T) {
+	must_be, wont_be := hamlet.Specifications(t)
+
+	must_be.True(conda.IsPyprojectToml("some/where/PyProject.toml"))
+	wont_be.True(conda.IsPyprojectToml("conda.yaml"))
+
+	environment, err := conda.ReadPackageCondaYaml("testdata/pyproject/pyproject.toml")
+	must_be.Nil(err)
+	wont_be.Nil(environment)
+	must_be.Equal("example-bot", environment.Name)
+	must_be.Equal(2, len(environment.Conda))
+	must_be.Equal("python>=3.9,<3.12", environment.Conda[0].Original)
+	must_be.Equal("pip=23.2.1", environment.Conda[1].Original)
+	must_be.Equal(2, len(environment.Pip))
+	must_be.Equal("robocorp-tasks==2.1.1", environment.Pip[0].Original)
+	must_be.Equal("requests>=2.31", environment.Pip[1].Original)
+	must_be.Equal("testdata/pyproject/pyproject.toml", environment.Pip[0].Source)
+}
+
+func TestCanReadRequirementsTextAsEnvironment(t *testing.T) {
+	must_be, wont_be := hamlet.Specifications(t)
+
+	must_be.True(conda.IsRequirementsText("dev-requirements.txt"))
+	wont_be.True(conda.IsRequirementsText("notes.txt"))
+
+	environment, err := conda.ReadPackageCondaYaml("testdata/pyproject/requirements.txt")
+	must_be.Nil(err)
+	must_be.Equal("", environment.Name)
+	must_be.Equal(2, len(environment.Conda))
+	must_be.Equal("python=3.10.12", environment.Conda[0].Original)
+	must_be.Equal("pip=23.2.1", environment.Conda[1].Original)
+	must_be.Equal(5, len(environment.Pip))
+	must_be.Equal("--extra-index-url https://pypi.example.com/simple", environment.Pip[0].Original)
+	must_be.Equal("requests==2.31.0", environment.Pip[1].Original)
+	must_be.Equal("robocorp-tasks==2.1.1", environment.Pip[2].Original)
+	must_be.Equal("robocorp-browser==2.2.1", environment.Pip[3].Original)
+	must_be.Equal([]string{"sha256:aaaa"}, environment.Pip[4].Hashes)
+
+	_, err = conda.ReadPackageCondaYaml("testdata/pyproject/requirements-loop.txt")
+	wont_be.Nil(err)
+
+	environment, err = conda.ReadPackageCondaYaml("testdata/pyproject/requirements-diamond.txt")
+	must_be.Nil(err)
+	must_be.Equal(3, len(environment.Pip))
+	must_be.Equal("requests==2.31.0", environment.Pip[0].Original)
+	must_be.Equal("robocorp-tasks==2.1.1", environment.Pip[1].Original)
+	must_be.Equal("pytest==7.4.3", environment.Pip[2].Original)
+}
+
+func TestCanReadRemoteRequirementsTextWithIncludes(t *testing.T) {
+	must_be, _ := hamlet.Specifications(t)
+
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer server.Close()
+
+	environment, err := conda.ReadPackageCondaYaml(server.URL + "/pyproject/requirements-diamond.txt")
+	must_be.Nil(err)
+	must_be.Equal(3, len(environment.Pip))
+	must_be.Equal("requests==2.31.0", environment.Pip[0].Original)
+	must_be.Equal("robocorp-tasks==2.1.1", environment.Pip[1].Original)
+	must_be.Equal("pytest==7.4.3", environment.Pip[2].Original)
+}
@@ -0,0 +1,379 @@
+package conda
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/robocorp/rcc/common"
+)
+
+var (
+	selectorComment = regexp.MustCompile(`^(.*\S)\s*#\s*\[([^\]]+)\]\s*$`)
+	markerToken     = regexp.MustCompile(`^\s*(===|==|!=|<=|>=|~=|<|>|\(|\)|'[^']*'|"[^"]*"|[A-Za-z0-9_.\-]+)`)
+	markerOperators = map[string]bool{"===": true, "==": true, "!=": true, "<=": true, ">=": true, "~=": true, "<": true, ">": true}
+)
+
+type (
+	verdict struct {
+		value bool
+		known bool
+	}
+
+	markerParser struct {
+		tokens []string
+		at     int
+		flags  map[string]bool
+		facts  map[string]string
+	}
+)
+
+func platformParts(platform string) (string, string) {
+	parts := strings.SplitN(strings.ToLower(platform), "_", 2)
+	if len(parts) < 2 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+func selectorFlags(platform string) map[string]bool {
+	goos, goarch := platformParts(platform)
+	windows := goos == "windows"
+	darwin := goos == "darwin"
+	linux := goos == "linux"
+	amd64 := goarch == "amd64"
+	arm64 := goarch == "arm64"
+	result := map[string]bool{
+		"win":     windows,
+		"win64":   windows,
+		"windows": windows,
+		"osx":     darwin,
+		"darwin":  darwin,
+		"macos":   darwin,
+		"linux":   linux,
+		"linux64": linux && amd64,
+		"unix":    !windows,
+		"x86_64":  amd64,
+		"amd64":   amd64,
+		"x64":     amd64,
+		"arm64":   arm64,
+		"aarch64": arm64,
+	}
+	for name := range lockPlatforms {
+		result[name] = false
+	}
+	result[strings.ToLower(platform)] = true
+	return result
+}
+
+func markerFacts(platform string) map[string]string {
+	goos, goarch := platformParts(platform)
+	machine := map[string]string{"amd64": "x86_64", "arm64": "arm64"}[goarch]
+	switch {
+	case goos == "linux" && goarch == "arm64":
+		machine = "aarch64"
+	case goos == "windows":
+		machine = strings.ToUpper(goarch)
+	}
+	system := map[string]string{"windows": "Windows", "darwin": "Darwin", "linux": "Linux"}[goos]
+	sysPlatform := map[string]string{"windows": "win32", "darwin": "darwin", "linux": "linux"}[goos]
+	osName := "posix"
+	if goos == "windows" {
+		osName = "nt"
+	}
+	return map[string]string{
+		"sys_platform":     sysPlatform,
+		"platform_system":  system,
+		"os_name":          osName,
+		"platform_machine": machine,
+	}
+}
+
+func tokenize(expression string) ([]string, error) {
+	result := []string{}
+	rest := expression
+	for len(strings.TrimSpace(rest)) > 0 {
+		match := markerToken.FindStringSubmatch(rest)
+		if match == nil {
+			return nil, fmt.Errorf("cannot parse %q in %q", strings.TrimSpace(rest), expression)
+		}
+		result = append(result, match[1])
+		rest = rest[len(match[0]):]
+	}
+	return result, nil
+}
+
+func newMarkerParser(expression, platform string) (*markerParser, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	return &markerParser{
+		tokens: tokens,
+		flags:  selectorFlags(platform),
+		facts:  markerFacts(platform),
+	}, nil
+}
+
+func (it *markerParser) peek() string {
+	if it.at < len(it.tokens) {
+		return it.tokens[it.at]
+	}
+	return ""
+}
+
+func (it *markerParser) next() string {
+	token := it.peek()
+	it.at++
+	return token
+}
+
+func (it *markerParser) parse() (verdict, error) {
+	result, err := it.or()
+	if err != nil {
+		return result, err
+	}
+	if it.at < len(it.tokens) {
+		return result, fmt.Errorf("unexpected %q", it.peek())
+	}
+	return result, nil
+}
+
+func (it *markerParser) or() (verdict, error) {
+	left, err := it.and()
+	for err == nil && it.peek() == "or" {
+		it.next()
+		var right verdict
+		right, err = it.and()
+		switch {
+		case (left.known && left.value) || (right.known && right.value):
+			left = verdict{true, true}
+		case left.known && right.known:
+			left = verdict{false, true}
+		default:
+			left = verdict{}
+		}
+	}
+	return left, err
+}
+
+func (it *markerParser) and() (verdict, error) {
+	left, err := it.not()
+	for err == nil && it.peek() == "and" {
+		it.next()
+		var right verdict
+		right, err = it.not()
+		switch {
+		case (left.known && !left.value) || (right.known && !right.value):
+			left = verdict{false, true}
+		case left.known && right.known:
+			left = verdict{true, true}
+		default:
+			left = verdict{}
+		}
+	}
+	return left, err
+}
+
+func (it *markerParser) not() (verdict, error) {
+	if it.peek() != "not" {
+		return it.atom()
+	}
+	it.next()
+	inner, err := it.not()
+	return verdict{!inner.value, inner.known}, err
+}
+
+func (it *markerParser) value(token string) (string, bool) {
+	if len(token) > 1 && (token[0] == '"' || token[0] == '\'') {
+		return token[1 : len(token)-1], true
+	}
+	fact, ok := it.facts[token]
+	return fact, ok
+}
+
+func (it *markerParser) atom() (verdict, error) {
+	token := it.next()
+	switch {
+	case len(token) == 0:
+		return verdict{}, fmt.Errorf("unexpected end of expression")
+	case token == "(":
+		inner, err := it.or()
+		if err == nil && it.next() != ")" {
+			err = fmt.Errorf("missing closing parenthesis")
+		}
+		return inner, err
+	case token == ")" || markerOperators[token]:
+		return verdict{}, fmt.Errorf("unexpected %q", token)
+	}
+	operator := it.peek()
+	if operator == "not" && it.at+1 < len(it.tokens) && it.tokens[it.at+1] == "in" {
+		it.next()
+		operator = "not in"
+	}
+	if !markerOperators[operator] && operator != "in" && operator != "not in" {
+		flag, ok := it.flags[strings.ToLower(token)]
+		return verdict{flag, ok}, nil
+	}
+	it.next()
+	other := it.next()
+	if len(other) == 0 || other == "(" || other == ")" || markerOperators[other] {
+		return verdict{}, fmt.Errorf("missing right side for %q", operator)
+	}
+	left, leftOk := it.value(token)
+	right, rightOk := it.value(other)
+	if !leftOk || !rightOk {
+		return verdict{}, nil
+	}
+	switch operator {
+	case "==", "===":
+		return verdict{left == right, true}, nil
+	case "!=":
+		return verdict{left != right, true}, nil
+	case "in":
+		return verdict{strings.Contains(right, left), true}, nil
+	case "not in":
+		return verdict{!strings.Contains(right, left), true}, nil
+	}
+	return verdict{}, nil
+}
+
+func SelectorMatches(selector, platform string) (bool, error) {
+	parser, err := newMarkerParser(selector, platform)
+	if err != nil {
+		return false, fmt.Errorf("Invalid selector [%s]: %w", selector, err)
+	}
+	result, err := parser.parse()
+	if err != nil {
+		return false, fmt.Errorf("Invalid selector [%s]: %w", selector, err)
+	}
+	if !result.known {
+		return false, fmt.Errorf("Selector [%s] cannot be evaluated for platform %q.", selector, platform)
+	}
+	return result.value, nil
+}
+
+func EvaluateMarker(requirement, platform string) (string, bool, error) {
+	parts := strings.SplitN(requirement, ";", 2)
+	if len(parts) < 2 || strings.HasPrefix(strings.TrimSpace(requirement), "-") {
+		return requirement, true, nil
+	}
+	parser, err := newMarkerParser(parts[1], platform)
+	if err != nil {
+		return "", false, fmt.Errorf("Invalid marker in %q: %w", requirement, err)
+	}
+	result, err := parser.parse()
+	if err != nil {
+		return "", false, fmt.Errorf("Invalid marker in %q: %w", requirement, err)
+	}
+	if !result.known {
+		return requirement, true, nil
+	}
+	return strings.TrimSpace(parts[0]), result.value, nil
+}
+
+func topLevelKey(line string) (string, bool) {
+	if len(line) == 0 || strings.ContainsAny(line[:1], " \t-#") {
+		return "", false
+	}
+	key, _, found := strings.Cut(line, ":")
+	return strings.TrimSpace(key), found
+}
+
+func applySelectors(content []byte, platform string) []byte {
+	lines := strings.Split(string(content), "\n")
+	result := make([]string, 0, len(lines))
+	section := ""
+	for _, line := range lines {
+		trimmed := strings.TrimRight(line, "\r")
+		if key, ok := topLevelKey(trimmed); ok {
+			section = key
+		}
+		match := selectorComment.FindStringSubmatch(trimmed)
+		if match == nil || section != "dependencies" || !strings.HasPrefix(strings.TrimSpace(trimmed), "- ") {
+			result = append(result, line)
+			continue
+		}
+		selected, err := SelectorMatches(strings.TrimSpace(match[2]), platform)
+		if err != nil {
+			common.Debug("Keeping %q as plain comment, reason: %v", strings.TrimSpace(trimmed), err)
+			result = append(result, line)
+			continue
+		}
+		if selected {
+			result = append(result, match[1])
+		}
+	}
+	return []byte(strings.Join(result, "\n"))
+}
+
+func platformsMatch(value interface{}, platform string) (bool, error) {
+	if value == nil {
+		return true, nil
+	}
+	entries, ok := value.([]interface{})
+	if !ok {
+		entries = []interface{}{value}
+	}
+	for _, entry := range entries {
+		selected, err := SelectorMatches(fmt.Sprintf("%v", entry), platform)
+		if err != nil {
+			return false, err
+		}
+		if selected {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func selectEntries(entries []interface{}, platform string, pip bool) ([]interface{}, error) {
+	result := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		switch item := entry.(type) {
+		case string:
+			if !pip {
+				result = append(result, item)
+				continue
+			}
+			requirement, selected, err := EvaluateMarker(item, platform)
+			if err != nil {
+				return nil, err
+			}
+			if selected {
+				result = append(result, requirement)
+			}
+		case map[interface{}]interface{}:
+			if spec, ok := item["package"]; ok {
+				selected, err := platformsMatch(item["platforms"], platform)
+				if err != nil {
+					return nil, fmt.Errorf("Package %q: %w", spec, err)
+				}
+				if selected {
+					result = append(result, fmt.Sprintf("%v", spec))
+				}
+				continue
+			}
+			if values, ok := item["pip"].([]interface{}); ok && !pip {
+				selected, err := selectEntries(values, platform, true)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, map[interface{}]interface{}{"pip": selected})
+				continue
+			}
+			result = append(result, item)
+		default:
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+func (it *internalEnvironment) selectFor(platform string) (err error) {
+	it.Dependencies, err = selectEntries(it.Dependencies, platform, false)
+	return err
+}
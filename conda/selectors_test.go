@@ -0,0 +1,117 @@
+package conda_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/robocorp/rcc/conda"
+	"github.com/robocorp/rcc/hamlet"
+)
+
+func originals(dependencies []*conda.Dependency) []string {
+	result := make([]string, 0, len(dependencies))
+	for _, dependency := range dependencies {
+		result = append(result, dependency.Original)
+	}
+	return result
+}
+
+func TestCanEvaluateSelectors(t *testing.T) {
+	must_be, wont_be := hamlet.Specifications(t)
+
+	for _, selector := range []string{"win", "windows", "win64", "x86_64", "windows_amd64", "not linux", "win and (amd64 or arm64)"} {
+		selected, err := conda.SelectorMatches(selector, "windows_amd64")
+		must_be.Nil(err)
+		must_be.True(selected)
+	}
+	for _, selector := range []string{"osx", "unix", "arm64", "linux_amd64", "not win", "linux or osx"} {
+		selected, err := conda.SelectorMatches(selector, "windows_amd64")
+		must_be.Nil(err)
+		wont_be.True(selected)
+	}
+	_, err := conda.SelectorMatches("py>=38", "linux_amd64")
+	wont_be.Nil(err)
+	_, err = conda.SelectorMatches("win and", "linux_amd64")
+	wont_be.Nil(err)
+	_, err = conda.SelectorMatches("(win", "linux_amd64")
+	wont_be.Nil(err)
+}
+
+func TestCanEvaluatePep508Markers(t *testing.T) {
+	must_be, wont_be := hamlet.Specifications(t)
+
+	requirement, selected, err := conda.EvaluateMarker(`pywin32==306 ; sys_platform == "win32"`, "windows_amd64")
+	must_be.Nil(err)
+	must_be.True(selected)
+	must_be.Equal("pywin32==306", requirement)
+
+	_, selected, err = conda.EvaluateMarker(`pywin32==306 ; sys_platform == "win32"`, "linux_arm64")
+	must_be.Nil(err)
+	wont_be.True(selected)
+
+	requirement, selected, err = conda.EvaluateMarker(`uvloop ; platform_machine in "x86_64 aarch64" and os_name == 'posix'`, "linux_arm64")
+	must_be.Nil(err)
+	must_be.True(selected)
+	must_be.Equal("uvloop", requirement)
+
+	requirement, selected, err = conda.EvaluateMarker(`tomli ; python_version < "3.11"`, "linux_amd64")
+	must_be.Nil(err)
+	must_be.True(selected)
+	must_be.Equal(`tomli ; python_version < "3.11"`, requirement)
+
+	_, selected, err = conda.EvaluateMarker(`tomli ; python_version < "3.11" and sys_platform == "darwin"`, "linux_amd64")
+	must_be.Nil(err)
+	wont_be.True(selected)
+
+	_, _, err = conda.EvaluateMarker(`tomli ; sys_platform ==`, "linux_amd64")
+	wont_be.Nil(err)
+}
+
+func TestCondaYamlSelectsDependenciesByPlatform(t *testing.T) {
+	must_be, wont_be := hamlet.Specifications(t)
+
+	windows, err := conda.ReadPackageCondaYamlFor("testdata/selectors.yaml", "windows_amd64")
+	must_be.Nil(err)
+	must_be.Equal([]string{"python=3.10.12", "pip=23.2.1", "pywin32=306"}, originals(windows.Conda))
+	must_be.Equal([]string{"robocorp-tasks==2.1.1", "pywinauto==0.6.8", `tomli==2.0.1 ; python_version < "3.11"`}, originals(windows.Pip))
+
+	linux, err := conda.ReadPackageCondaYamlFor("testdata/selectors.yaml", "linux_amd64")
+	must_be.Nil(err)
+	must_be.Equal([]string{"python=3.10.12", "pip=23.2.1", "libgcc-ng=13.2.0", "nodejs=18.17.1"}, originals(linux.Conda))
+	must_be.Equal([]string{"robocorp-tasks==2.1.1", `tomli==2.0.1 ; python_version < "3.11"`, "uvloop==0.19.0"}, originals(linux.Pip))
+
+	mac, err := conda.ReadPackageCondaYamlFor("testdata/selectors.yaml", "darwin_arm64")
+	must_be.Nil(err)
+	must_be.Equal([]string{"python=3.10.12", "pip=23.2.1", "nodejs=18.17.1"}, originals(mac.Conda))
+	must_be.Equal([]string{"robocorp-tasks==2.1.1", "pyobjc==10.0", `tomli==2.0.1 ; python_version < "3.11"`, "uvloop==0.19.0"}, originals(mac.Pip))
+
+	windowsYaml, err := windows.AsYaml()
+	must_be.Nil(err)
+	linuxYaml, err := linux.AsYaml()
+	must_be.Nil(err)
+	wont_be.Equal(windowsYaml, linuxYaml)
+
+	content, err := os.ReadFile("testdata/conda.yaml")
+	must_be.Nil(err)
+	plain, err := conda.CondaYamlFrom(content)
+	must_be.Nil(err)
+	again, err := conda.ReadPackageCondaYamlFor("testdata/conda.yaml", "windows_amd64")
+	must_be.Nil(err)
+	must_be.Equal(originals(plain.Conda), originals(again.Conda))
+}
+
+func TestSelectorsOnlyApplyToDependencyItems(t *testing.T) {
+	must_be, _ := hamlet.Specifications(t)
+
+	linux, err := conda.ReadPackageCondaYamlFor("testdata/selectors-comments.yaml", "linux_amd64")
+	must_be.Nil(err)
+	must_be.Equal([]string{"python=3.10.12", "pip=23.2.1"}, originals(linux.Conda))
+	must_be.Equal([]string{"robocorp-tasks==2.1.1"}, originals(linux.Pip))
+	must_be.Equal([]string{"echo done"}, linux.PostInstall)
+
+	windows, err := conda.ReadPackageCondaYamlFor("testdata/selectors-comments.yaml", "windows_amd64")
+	must_be.Nil(err)
+	must_be.Equal([]string{"python=3.10.12", "pip=23.2.1", "pywin32=306"}, originals(windows.Conda))
+	must_be.Equal(0, len(windows.Pip))
+	must_be.Equal([]string{"echo done"}, windows.PostInstall)
+}
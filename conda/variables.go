@@ -0,0 +1,73 @@
+package conda
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+var (
+	variableName   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	spaceReference = regexp.MustCompile(`\$(?:\{(?:CONDA_PREFIX|RCC_HOLOTREE_SPACE_ROOT)\}|(?:CONDA_PREFIX|RCC_HOLOTREE_SPACE_ROOT)\b)`)
+)
+
+func validateVariables(variables map[string]string) error {
+	for name := range variables {
+		if !variableName.MatchString(name) {
+			return fmt.Errorf("Invalid variable name %q in 'variables:'.", name)
+		}
+	}
+	return nil
+}
+
+func sortedNames(variables map[string]string) []string {
+	result := make([]string, 0, len(variables))
+	for name := range variables {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func fromSource(source string) string {
+	if len(source) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (from %s)", source)
+}
+
+func (it *Environment) pushVariables(variables, sources map[string]string) error {
+	if len(variables) == 0 {
+		return nil
+	}
+	if it.Variables == nil {
+		it.Variables = make(map[string]string)
+	}
+	if it.sources == nil {
+		it.sources = make(map[string]string)
+	}
+	for _, name := range sortedNames(variables) {
+		value := variables[name]
+		existing, ok := it.Variables[name]
+		if ok && existing != value {
+			return fmt.Errorf("Conflicting values for variable %s: %q%s vs. %q%s.", name, existing, fromSource(it.sources[name]), value, fromSource(sources[name]))
+		}
+		if !ok {
+			it.Variables[name] = value
+			it.sources[name] = sources[name]
+		}
+	}
+	return nil
+}
+
+func ExpandVariable(value, location string) string {
+	return spaceReference.ReplaceAllLiteralString(value, location)
+}
+
+func expandVariables(variables map[string]string, location string) map[string]string {
+	result := make(map[string]string)
+	for name, value := range variables {
+		result[name] = ExpandVariable(value, location)
+	}
+	return result
+}
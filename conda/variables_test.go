@@ -0,0 +1,58 @@
+package conda_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/robocorp/rcc/conda"
+	"github.com/robocorp/rcc/hamlet"
+)
+
+func TestCanReadVariablesFromCondaYaml(t *testing.T) {
+	must_be, wont_be := hamlet.Specifications(t)
+
+	environment, err := conda.ReadPackageCondaYaml("testdata/variables.yaml")
+	must_be.Nil(err)
+	must_be.Equal(map[string]string{"PLAYWRIGHT_BROWSERS_PATH": "$CONDA_PREFIX/browsers", "TF_CPP_MIN_LOG_LEVEL": "2"}, environment.Variables)
+
+	blueprint, err := environment.AsYaml()
+	must_be.Nil(err)
+	must_be.True(strings.Contains(blueprint, "variables:\n  PLAYWRIGHT_BROWSERS_PATH: $CONDA_PREFIX/browsers\n  TF_CPP_MIN_LOG_LEVEL: \"2\"\n"))
+
+	plain, err := conda.ReadPackageCondaYaml("testdata/conda.yaml")
+	must_be.Nil(err)
+	plainBlueprint, err := plain.AsYaml()
+	must_be.Nil(err)
+	wont_be.True(strings.Contains(plainBlueprint, "variables"))
+
+	_, err = conda.CondaYamlFrom([]byte("dependencies: [python]\nvariables:\n  BAD-NAME: value\n"))
+	wont_be.Nil(err)
+}
+
+func TestMergingVariablesReportsConflicts(t *testing.T) {
+	must_be, wont_be := hamlet.Specifications(t)
+
+	left, err := conda.ReadPackageCondaYaml("testdata/variables.yaml")
+	must_be.Nil(err)
+	same, err := conda.CondaYamlFrom([]byte("dependencies: [python]\nvariables:\n  TF_CPP_MIN_LOG_LEVEL: '2'\n  OTHER: value\n"))
+	must_be.Nil(err)
+	merged, err := left.Merge(same)
+	must_be.Nil(err)
+	must_be.Equal(3, len(merged.Variables))
+	must_be.Equal("value", merged.Variables["OTHER"])
+
+	right, err := conda.CondaYamlFrom([]byte("dependencies: [python]\nvariables:\n  TF_CPP_MIN_LOG_LEVEL: '3'\n"))
+	must_be.Nil(err)
+	_, err = left.Merge(right)
+	wont_be.Nil(err)
+	must_be.Equal(`Conflicting values for variable TF_CPP_MIN_LOG_LEVEL: "2" (from testdata/variables.yaml) vs. "3".`, err.Error())
+}
+
+func TestCanExpandVariableValues(t *testing.T) {
+	must_be, _ := hamlet.Specifications(t)
+
+	must_be.Equal("/space/browsers", conda.ExpandVariable("$CONDA_PREFIX/browsers", "/space"))
+	must_be.Equal("/space/lib:/space", conda.ExpandVariable("${RCC_HOLOTREE_SPACE_ROOT}/lib:$CONDA_PREFIX", "/space"))
+	must_be.Equal("$HOME/cache:$CONDA_PREFIXES", conda.ExpandVariable("$HOME/cache:$CONDA_PREFIXES", "/space"))
+	must_be.Equal("plain", conda.ExpandVariable("plain", "/space"))
+}
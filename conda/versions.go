@@ -0,0 +1,291 @@
+package conda
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	pep440Pattern = regexp.MustCompile(`^v?(?:(\d+)!)?(\d+(?:\.\d+)*)` +
+		`(?:[-_.]?(a|b|c|rc|alpha|beta|pre|preview)[-_.]?(\d+)?)?` +
+		`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d+)?)?` +
+		`(?:[-_.]?(dev)[-_.]?(\d+)?)?` +
+		`(?:\+([a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+	tokenPattern = regexp.MustCompile(`\d+|[a-z]+`)
+	preReleases  = map[string]int{"dev": -4, "a": -3, "alpha": -3, "b": -2, "beta": -2, "c": -1, "rc": -1, "pre": -1, "preview": -1}
+)
+
+type Version struct {
+	Original string
+	Epoch    int
+	Release  []int
+	PreKind  int
+	PreNum   int
+	Post     int
+	Dev      int
+	Local    string
+	Pep440   bool
+}
+
+func atoi(text string, missing int) int {
+	if len(text) == 0 {
+		return missing
+	}
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		return missing
+	}
+	return value
+}
+
+func ParseVersion(text string) *Version {
+	clean := strings.ToLower(strings.TrimSpace(text))
+	result := &Version{Original: text, PreKind: 0, Post: -1, Dev: -1}
+	match := pep440Pattern.FindStringSubmatch(clean)
+	if match == nil {
+		return result
+	}
+	result.Pep440 = true
+	result.Epoch = atoi(match[1], 0)
+	for _, part := range strings.Split(match[2], ".") {
+		result.Release = append(result.Release, atoi(part, 0))
+	}
+	for len(result.Release) > 1 && result.Release[len(result.Release)-1] == 0 {
+		result.Release = result.Release[:len(result.Release)-1]
+	}
+	if len(match[3]) > 0 {
+		result.PreKind = preReleases[match[3]]
+		result.PreNum = atoi(match[4], 0)
+	}
+	switch {
+	case len(match[5]) > 0:
+		result.Post = atoi(match[5], 0)
+	case len(match[6]) > 0:
+		result.Post = atoi(match[7], 0)
+	}
+	if len(match[8]) > 0 {
+		result.Dev = atoi(match[9], 0)
+	}
+	result.Local = match[10]
+	return result
+}
+
+func compareInts(left, right int) int {
+	switch {
+	case left < right:
+		return -1
+	case left > right:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareRelease(left, right []int) int {
+	for at := 0; at < len(left) || at < len(right); at++ {
+		lefty, righty := 0, 0
+		if at < len(left) {
+			lefty = left[at]
+		}
+		if at < len(right) {
+			righty = right[at]
+		}
+		if order := compareInts(lefty, righty); order != 0 {
+			return order
+		}
+	}
+	return 0
+}
+
+func (it *Version) preKey() (int, int) {
+	if it.PreKind == 0 && it.Post < 0 && it.Dev >= 0 {
+		return -5, 0
+	}
+	return it.PreKind, it.PreNum
+}
+
+func (it *Version) devKey() int {
+	if it.Dev < 0 {
+		return int(^uint(0) >> 1)
+	}
+	return it.Dev
+}
+
+func comparePep440(left, right *Version) int {
+	if order := compareInts(left.Epoch, right.Epoch); order != 0 {
+		return order
+	}
+	if order := compareRelease(left.Release, right.Release); order != 0 {
+		return order
+	}
+	leftKind, leftNum := left.preKey()
+	rightKind, rightNum := right.preKey()
+	if order := compareInts(leftKind, rightKind); order != 0 {
+		return order
+	}
+	if order := compareInts(leftNum, rightNum); order != 0 {
+		return order
+	}
+	if order := compareInts(left.Post, right.Post); order != 0 {
+		return order
+	}
+	if order := compareInts(left.devKey(), right.devKey()); order != 0 {
+		return order
+	}
+	return compareTokens(left.Local, right.Local)
+}
+
+func tokenRank(token string) int {
+	rank, ok := preReleases[token]
+	if ok {
+		return rank
+	}
+	return 1
+}
+
+func compareTokens(left, right string) int {
+	lefties := tokenPattern.FindAllString(strings.ToLower(left), -1)
+	righties := tokenPattern.FindAllString(strings.ToLower(right), -1)
+	for at := 0; at < len(lefties) || at < len(righties); at++ {
+		switch {
+		case at >= len(lefties):
+			return -compareMissing(righties[at])
+		case at >= len(righties):
+			return compareMissing(lefties[at])
+		}
+		lefty, righty := lefties[at], righties[at]
+		leftNumber, leftErr := strconv.Atoi(lefty)
+		rightNumber, rightErr := strconv.Atoi(righty)
+		switch {
+		case leftErr == nil && rightErr == nil:
+			if order := compareInts(leftNumber, rightNumber); order != 0 {
+				return order
+			}
+		case leftErr == nil:
+			return 1
+		case rightErr == nil:
+			return -1
+		default:
+			if order := compareInts(tokenRank(lefty), tokenRank(righty)); order != 0 {
+				return order
+			}
+			if order := strings.Compare(lefty, righty); order != 0 {
+				return order
+			}
+		}
+	}
+	return 0
+}
+
+func compareMissing(token string) int {
+	number, err := strconv.Atoi(token)
+	if err == nil {
+		return compareInts(number, 0)
+	}
+	return compareInts(tokenRank(token), 0)
+}
+
+func (it *Version) Compare(other *Version) int {
+	if it.Pep440 && other.Pep440 {
+		return comparePep440(it, other)
+	}
+	return compareTokens(it.Original, other.Original)
+}
+
+func CompareVersions(left, right string) int {
+	return ParseVersion(left).Compare(ParseVersion(right))
+}
+
+var (
+	clausePattern = regexp.MustCompile(`^(===|==|!=|~=|<=|>=|<|>|=)?\s*(\S+)$`)
+)
+
+type VersionClause struct {
+	Operator string
+	Version  string
+}
+
+func ParseSpecifiers(text string) ([]*VersionClause, error) {
+	result := []*VersionClause{}
+	for _, part := range strings.Split(text, ",") {
+		trimmed := strings.TrimSpace(part)
+		if len(trimmed) == 0 {
+			continue
+		}
+		match := clausePattern.FindStringSubmatch(trimmed)
+		if match == nil {
+			return nil, fmt.Errorf("Invalid version specifier %q.", trimmed)
+		}
+		operator := match[1]
+		if len(operator) == 0 {
+			operator = "=="
+		}
+		result = append(result, &VersionClause{Operator: operator, Version: match[2]})
+	}
+	return result, nil
+}
+
+func hasVersionPrefix(version, prefix string) bool {
+	versions := tokenPattern.FindAllString(strings.ToLower(version), -1)
+	prefixes := tokenPattern.FindAllString(strings.ToLower(prefix), -1)
+	if len(prefixes) > len(versions) {
+		return false
+	}
+	for at, token := range prefixes {
+		if compareTokens(token, versions[at]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (it *VersionClause) Matches(version string) bool {
+	wildcard := strings.HasSuffix(it.Version, ".*") || strings.HasSuffix(it.Version, "*")
+	bare := strings.TrimRight(strings.TrimSuffix(it.Version, "*"), ".")
+	switch it.Operator {
+	case "===":
+		return strings.TrimSpace(version) == it.Version
+	case "=":
+		return hasVersionPrefix(version, bare)
+	case "==":
+		if wildcard {
+			return hasVersionPrefix(version, bare)
+		}
+		return CompareVersions(version, it.Version) == 0
+	case "!=":
+		if wildcard {
+			return !hasVersionPrefix(version, bare)
+		}
+		return CompareVersions(version, it.Version) != 0
+	case "~=":
+		release := strings.Split(bare, ".")
+		if len(release) > 1 {
+			release = release[:len(release)-1]
+		}
+		return CompareVersions(version, bare) >= 0 && hasVersionPrefix(version, strings.Join(release, "."))
+	case "<":
+		return CompareVersions(version, it.Version) < 0
+	case "<=":
+		return CompareVersions(version, it.Version) <= 0
+	case ">":
+		return CompareVersions(version, it.Version) > 0
+	case ">=":
+		return CompareVersions(version, it.Version) >= 0
+	}
+	return false
+}
+
+func MatchesSpecifiers(version, specifiers string) (bool, error) {
+	clauses, err := ParseSpecifiers(specifiers)
+	if err != nil {
+		return false, err
+	}
+	for _, clause := range clauses {
+		if !clause.Matches(version) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
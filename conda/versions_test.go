@@ -0,0 +1,62 @@
+package conda_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/robocorp/rcc/conda"
+	"github.com/robocorp/rcc/hamlet"
+)
+
+func TestCanCompareVersions(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	must.True(conda.ParseVersion("1.2.3").Pep440)
+	must.True(conda.ParseVersion("v2!1.0.post1.dev3+local.7").Pep440)
+	wont.True(conda.ParseVersion("1.1.1w").Pep440)
+
+	ordered := []string{"1.0.dev0", "1.0a1", "1.0a2.dev1", "1.0a2", "1.0b1", "1.0rc1", "1.0", "1.0+local", "1.0.post1", "1.0.1", "1.1", "2.0", "1!0.1"}
+	for at := 1; at < len(ordered); at++ {
+		must.Equal(-1, conda.CompareVersions(ordered[at-1], ordered[at]))
+		must.Equal(1, conda.CompareVersions(ordered[at], ordered[at-1]))
+	}
+	must.Equal(0, conda.CompareVersions("1.0", "1.0.0"))
+	must.Equal(0, conda.CompareVersions("1.0-1", "1.0.post1"))
+	must.Equal(0, conda.CompareVersions("1.0RC1", "1.0rc1"))
+
+	must.Equal(-1, conda.CompareVersions("1.1.1v", "1.1.1w"))
+	must.Equal(1, conda.CompareVersions("1.1.1w", "1.1.1"))
+	must.Equal(-1, conda.CompareVersions("1.1.1w", "3.0.7"))
+	must.Equal(-1, conda.CompareVersions("9d", "9e"))
+}
+
+func TestCanMatchVersionSpecifiers(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	expectations := map[string]bool{
+		"5.3.1|<5.4":           true,
+		"5.4|<5.4":             false,
+		"3.0.5|>=3.0.0,<3.0.7": true,
+		"3.0.7|>=3.0.0,<3.0.7": false,
+		"1.2.7|==1.2.*":        true,
+		"1.20|==1.2.*":         false,
+		"3.9.13|=3.9":          true,
+		"3.10.1|=3.9":          false,
+		"2.2.5|~=2.2":          true,
+		"3.0|~=2.2":            false,
+		"1.4.5|~=1.4.2":        true,
+		"1.5.0|~=1.4.2":        false,
+		"1.0|!=1.0.*":          false,
+		"1.1|!=1.0.*":          true,
+		"2.0|2.0":              true,
+		"2.0.0|===2.0":         false,
+	}
+	for sample, expected := range expectations {
+		parts := strings.SplitN(sample, "|", 2)
+		matches, err := conda.MatchesSpecifiers(parts[0], parts[1])
+		must.Nil(err)
+		must.Equal(expected, matches)
+	}
+	_, err := conda.MatchesSpecifiers("1.0", ">= 1.0 2.0")
+	wont.Nil(err)
+}
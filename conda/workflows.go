@@ -419,7 +419,7 @@ func newLiveInternal(yaml, condaYaml, requirementsText, key string, force, fresh
 	pretty.Progress(10, "Activate environment started phase.")
 	common.Debug("===  activate phase ===")
 	fmt.Fprintf(planWriter, "\n---  activation plan @%ss  ---\n\n", stopwatch)
-	err := Activate(planWriter, targetFolder)
+	err := Activate(planWriter, targetFolder, finalEnv.Variables)
 	if err != nil {
 		common.Log("%sActivation failure: %v%s", pretty.Yellow, err, pretty.Reset)
 	}
@@ -481,19 +481,25 @@ func finalUnifiedEnvironment(filename string) (string, *Environment, error) {
 	return yaml, right, nil
 }
 
-func temporaryConfig(condaYaml, requirementsText, filename string) (string, string, *Environment, error) {
+func temporaryConfig(condaYaml, requirementsText, filename string) (string, string, string, *Environment, error) {
 	yaml, right, err := finalUnifiedEnvironment(filename)
 	if err != nil {
-		return "", "", nil, err
+		return "", "", "", nil, err
 	}
 	hash := common.ShortDigest(yaml)
 	err = right.SaveAsRequirements(requirementsText)
 	if err != nil {
-		return "", "", nil, err
+		return "", "", "", nil, err
+	}
+	if right.IsExplicit() {
+		explicitText := strings.TrimSuffix(condaYaml, filepath.Ext(condaYaml)) + ".txt"
+		common.Debug("Using explicit conda specification from lock file, no solving needed.")
+		err = right.SaveAsExplicit(explicitText)
+		return hash, yaml, explicitText, right, err
 	}
 	pure := right.AsPureConda()
 	err = pure.SaveAs(condaYaml)
-	return hash, yaml, right, err
+	return hash, yaml, condaYaml, right, err
 }
 
 func LegacyEnvironment(recorder Recorder, force bool, skip SkipLayer, configuration string) error {
@@ -514,7 +520,7 @@ func LegacyEnvironment(recorder Recorder, force bool, skip SkipLayer, configurat
 	condaYaml := filepath.Join(pathlib.TempDir(), fmt.Sprintf("conda_%x.yaml", common.When))
 	requirementsText := filepath.Join(pathlib.TempDir(), fmt.Sprintf("require_%x.txt", common.When))
 	common.Debug("Using temporary conda.yaml file: %v and requirement.txt file: %v", condaYaml, requirementsText)
-	key, yaml, finalEnv, err := temporaryConfig(condaYaml, requirementsText, configuration)
+	key, yaml, condaYaml, finalEnv, err := temporaryConfig(condaYaml, requirementsText, configuration)
 	if err != nil {
 		return err
 	}
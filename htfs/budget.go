@@ -0,0 +1,298 @@
+package htfs
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/fail"
+	"github.com/robocorp/rcc/journal"
+	"github.com/robocorp/rcc/pathlib"
+	"github.com/robocorp/rcc/pretty"
+	"github.com/robocorp/rcc/settings"
+)
+
+const (
+	spaceGracePeriod = 1 * time.Hour
+)
+
+type (
+	Eviction struct {
+		Kind      string `json:"kind"`
+		Name      string `json:"name"`
+		Path      string `json:"path"`
+		Blueprint string `json:"blueprint"`
+		Size      int64  `json:"size"`
+		IdleDays  int    `json:"idle_days"`
+	}
+
+	BudgetReport struct {
+		MaxSize    int64       `json:"max_size"`
+		MinFree    int64       `json:"min_free"`
+		UsedBefore int64       `json:"used_before"`
+		UsedAfter  int64       `json:"used_after"`
+		FreeBefore int64       `json:"free_before"`
+		FreeAfter  int64       `json:"free_after"`
+		Evicted    []*Eviction `json:"evicted"`
+	}
+
+	evictable struct {
+		*Eviction
+		lastUsed  time.Time
+		protected bool
+	}
+)
+
+func (it *BudgetReport) Exceeded() bool {
+	return budgetExceeded(it.UsedAfter, it.FreeAfter, it.MaxSize, it.MinFree)
+}
+
+func (it *BudgetReport) evicted(eviction *Eviction) {
+	it.Evicted = append(it.Evicted, eviction)
+	it.UsedAfter -= eviction.Size
+	it.FreeAfter += eviction.Size
+}
+
+func budgetExceeded(used, free, maxSize, minFree int64) bool {
+	return (maxSize > 0 && used > maxSize) || (minFree > 0 && free < minFree)
+}
+
+func lruOrder(candidates []*evictable) []*evictable {
+	result := make([]*evictable, 0, len(candidates))
+	for _, candidate := range candidates {
+		if !candidate.protected {
+			result = append(result, candidate)
+		}
+	}
+	sort.SliceStable(result, func(left, right int) bool {
+		return result[left].lastUsed.Before(result[right].lastUsed)
+	})
+	return result
+}
+
+func lastModified(filenames ...string) time.Time {
+	result := time.Time{}
+	for _, filename := range filenames {
+		stat, err := os.Stat(filename)
+		if err == nil && stat.ModTime().After(result) {
+			result = stat.ModTime()
+		}
+	}
+	return result
+}
+
+func holotreeUsage() int64 {
+	holotree, hololib := common.HolotreeLocation(), common.HololibLocation()
+	used := pathlib.DirectorySize(holotree)
+	if !strings.HasPrefix(hololib, holotree+string(filepath.Separator)) {
+		used += pathlib.DirectorySize(hololib)
+	}
+	return used
+}
+
+func catalogUsage() map[string]time.Time {
+	result := make(map[string]time.Time)
+	entries, err := os.ReadDir(common.HololibUsageLocation())
+	if err != nil {
+		return result
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		name := entry.Name()
+		blueprint := strings.TrimSuffix(name, filepath.Ext(name))
+		if info.ModTime().After(result[blueprint]) {
+			result[blueprint] = info.ModTime()
+		}
+	}
+	return result
+}
+
+func spaceInUse(path string, lastUsed time.Time) bool {
+	if time.Since(lastUsed) < spaceGracePeriod {
+		return true
+	}
+	locker, err := pathlib.TryLocker(path+".lck", common.SharedHolotree)
+	if err != nil {
+		return true
+	}
+	locker.Release()
+	return false
+}
+
+func spaceCandidates(spaces Roots, current string) []*evictable {
+	result := make([]*evictable, 0, len(spaces))
+	for _, space := range spaces {
+		if space.Path == current {
+			continue
+		}
+		lastUsed := lastModified(space.Path+".use", space.Path+".meta")
+		result = append(result, &evictable{
+			Eviction: &Eviction{
+				Kind:      "space",
+				Name:      filepath.Base(space.Path),
+				Path:      space.Path,
+				Blueprint: space.Blueprint,
+				IdleDays:  common.DayCountSince(lastUsed),
+			},
+			lastUsed:  lastUsed,
+			protected: IsPinned(space.Path) || spaceInUse(space.Path, lastUsed),
+		})
+	}
+	return lruOrder(result)
+}
+
+func catalogCandidates(catalogs Roots, referenced map[string]bool) []*evictable {
+	used := catalogUsage()
+	result := make([]*evictable, 0, len(catalogs))
+	for _, catalog := range catalogs {
+		lastUsed, ok := used[catalog.Blueprint]
+		if !ok {
+			lastUsed = lastModified(catalog.Source())
+		}
+		result = append(result, &evictable{
+			Eviction: &Eviction{
+				Kind:      "catalog",
+				Name:      filepath.Base(catalog.Source()),
+				Path:      catalog.Source(),
+				Blueprint: catalog.Blueprint,
+				IdleDays:  common.DayCountSince(lastUsed),
+			},
+			lastUsed:  lastUsed,
+			protected: referenced[catalog.Blueprint],
+		})
+	}
+	return lruOrder(result)
+}
+
+func catalogDigests(catalog *Root) map[string]string {
+	collector := make(map[string]string)
+	DigestMapper(collector)(catalog.Path, catalog.Tree)
+	return collector
+}
+
+func evictCatalogs(report *BudgetReport, catalogs Roots, referenced map[string]bool) int {
+	counts := make(map[string]int)
+	digests := make(map[string]map[string]string)
+	for _, catalog := range catalogs {
+		digests[catalog.Source()] = catalogDigests(catalog)
+		for digest := range digests[catalog.Source()] {
+			counts[digest] += 1
+		}
+	}
+	removed := 0
+	for _, candidate := range catalogCandidates(catalogs, referenced) {
+		if !report.Exceeded() {
+			break
+		}
+		err := pathlib.TryRemove("catalog", candidate.Path)
+		if err != nil {
+			common.Debug("Could not evict catalog %q, reason: %v", candidate.Path, err)
+			continue
+		}
+		infofile := candidate.Path + ".info"
+		if pathlib.IsFile(infofile) {
+			pathlib.TryRemove("catalog info", infofile)
+		}
+		for digest := range digests[candidate.Path] {
+			counts[digest] -= 1
+			if counts[digest] > 0 {
+				continue
+			}
+			size, ok := pathlib.Size(ExactDefaultLocation(digest))
+			if ok {
+				candidate.Size += size
+			}
+		}
+		removed += 1
+		report.evicted(candidate.Eviction)
+		journal.Post("catalog-evicted", candidate.Path, "disk budget eviction of catalog with blueprint %s [%d bytes, idle %d days]", candidate.Blueprint, candidate.Size, candidate.IdleDays)
+	}
+	return removed
+}
+
+func EnforceDiskBudget(current string) (report *BudgetReport, err error) {
+	defer fail.Around(&err)
+
+	maxSize, minFree := settings.Global.DiskBudget()
+	if maxSize == 0 && minFree == 0 {
+		return nil, nil
+	}
+
+	common.TimelineBegin("holotree disk budget check [max-size: %d, min-free: %d]", maxSize, minFree)
+	defer common.TimelineEnd()
+
+	report = &BudgetReport{
+		MaxSize: maxSize,
+		MinFree: minFree,
+		Evicted: []*Eviction{},
+	}
+	if maxSize > 0 {
+		report.UsedBefore = holotreeUsage()
+	}
+	if minFree > 0 {
+		report.FreeBefore, err = pathlib.DiskFree(common.HolotreeLocation())
+		fail.On(err != nil, "Could not get free disk space for %q, reason: %v", common.HolotreeLocation(), err)
+	}
+	report.UsedAfter, report.FreeAfter = report.UsedBefore, report.FreeBefore
+	if !report.Exceeded() {
+		return report, nil
+	}
+
+	// other processes might be using holotree, so eviction happens only when no-one else is
+	lockfile := common.HolotreeLock()
+	locker, err := pathlib.TryLocker(lockfile, common.SharedHolotree)
+	if err != nil {
+		pretty.Note("Holotree disk budget exceeded, but holotree is in use. Eviction postponed to next run.")
+		return report, nil
+	}
+	defer locker.Release()
+
+	names, catalogs := LoadCatalogs()
+	fail.On(len(names) != len(catalogs), "Only %d of %d catalogs could be loaded; refusing to evict anything.", len(catalogs), len(names))
+
+	spaces := catalogs.Spaces()
+	for _, candidate := range spaceCandidates(spaces, current) {
+		if !report.Exceeded() {
+			break
+		}
+		candidate.Size = pathlib.DirectorySize(candidate.Path)
+		err = catalogs.RemoveHolotreeSpace(candidate.Name, false)
+		if err != nil {
+			common.Debug("Could not evict space %q, reason: %v", candidate.Path, err)
+			continue
+		}
+		report.evicted(candidate.Eviction)
+		journal.Post("space-evicted", candidate.Path, "disk budget eviction of space with blueprint %s [%d bytes, idle %d days]", candidate.Blueprint, candidate.Size, candidate.IdleDays)
+	}
+	referenced := make(map[string]bool)
+	for _, space := range spaces {
+		if pathlib.IsFile(space.Path + ".meta") {
+			referenced[space.Blueprint] = true
+		}
+	}
+
+	if report.Exceeded() && evictCatalogs(report, catalogs, referenced) > 0 {
+		_, err = collectGarbage(false)
+		fail.Fast(err)
+	}
+
+	if maxSize > 0 {
+		report.UsedAfter = holotreeUsage()
+	}
+	if minFree > 0 {
+		report.FreeAfter, _ = pathlib.DiskFree(common.HolotreeLocation())
+	}
+	if len(report.Evicted) > 0 {
+		pretty.Note("Holotree disk budget: evicted %d spaces/catalogs.", len(report.Evicted))
+	}
+	if report.Exceeded() {
+		pretty.Warning("Holotree disk budget is still exceeded [used: %d, free: %d] after evicting everything that could be evicted.", report.UsedAfter, report.FreeAfter)
+	}
+	return report, nil
+}
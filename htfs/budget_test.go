@@ -0,0 +1,138 @@
+package htfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/hamlet"
+	"github.com/robocorp/rcc/pathlib"
+)
+
+func TestCanDetectExceededDiskBudget(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	wont.True(budgetExceeded(100, 100, 0, 0))
+	wont.True(budgetExceeded(100, 100, 100, 100))
+	must.True(budgetExceeded(101, 100, 100, 0))
+	must.True(budgetExceeded(100, 99, 0, 100))
+	wont.True(budgetExceeded(1000, 99, 0, 50))
+}
+
+func TestSpaceEvictionIsLeastRecentlyUsedFirstAndSkipsPinned(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	folder := t.TempDir()
+	now := time.Now()
+	spaces := Roots{}
+	for at, name := range []string{"newest", "oldest", "pinned", "current", "middle"} {
+		root, err := NewRoot(filepath.Join(folder, name))
+		must.Nil(err)
+		root.Blueprint = name
+		spaces = append(spaces, root)
+		usefile := root.Path + ".use"
+		must.Nil(os.WriteFile(usefile, []byte{'.'}, 0o644))
+		stamp := now.Add(-time.Duration(at) * time.Hour)
+		switch name {
+		case "newest":
+			stamp = now.Add(-90 * time.Minute)
+		case "middle":
+			stamp = now.Add(-2 * time.Hour)
+		case "oldest":
+			stamp = now.Add(-48 * time.Hour)
+		}
+		must.Nil(os.Chtimes(usefile, stamp, stamp))
+	}
+	must.Nil(os.WriteFile(PinFile(filepath.Join(folder, "pinned")), []byte{}, 0o644))
+
+	candidates := spaceCandidates(spaces, filepath.Join(folder, "current"))
+	must.Equal(3, len(candidates))
+	must.Equal("oldest", candidates[0].Name)
+	must.Equal("middle", candidates[1].Name)
+	must.Equal("newest", candidates[2].Name)
+	must.Equal(2, candidates[0].IdleDays)
+	must.Equal("space", candidates[0].Kind)
+}
+
+func TestSpaceEvictionSkipsRecentlyUsedAndLockedSpaces(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	folder := t.TempDir()
+	now := time.Now()
+	spaces := Roots{}
+	for _, name := range []string{"idle", "recent", "locked"} {
+		root, err := NewRoot(filepath.Join(folder, name))
+		must.Nil(err)
+		root.Blueprint = name
+		spaces = append(spaces, root)
+		usefile := root.Path + ".use"
+		must.Nil(os.WriteFile(usefile, []byte{'.'}, 0o644))
+		stamp := now.Add(-24 * time.Hour)
+		if name == "recent" {
+			stamp = now.Add(-5 * time.Minute)
+		}
+		must.Nil(os.Chtimes(usefile, stamp, stamp))
+	}
+	locker, err := pathlib.TryLocker(filepath.Join(folder, "locked.lck"), false)
+	must.Nil(err)
+	defer locker.Release()
+
+	wont.True(spaceInUse(filepath.Join(folder, "idle"), now.Add(-24*time.Hour)))
+	must.True(spaceInUse(filepath.Join(folder, "recent"), now.Add(-5*time.Minute)))
+	must.True(spaceInUse(filepath.Join(folder, "locked"), now.Add(-24*time.Hour)))
+
+	candidates := spaceCandidates(spaces, "")
+	must.Equal(1, len(candidates))
+	must.Equal("idle", candidates[0].Name)
+}
+
+func TestReferencedCatalogsAreNotEvicted(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	folder := t.TempDir()
+	catalogs := Roots{}
+	for _, name := range []string{"used", "unused"} {
+		catalog, err := NewRoot(folder)
+		must.Nil(err)
+		catalog.Blueprint = name
+		catalog.source = filepath.Join(folder, name+"v12.linux_amd64")
+		must.Nil(os.WriteFile(catalog.source, []byte{}, 0o644))
+		catalogs = append(catalogs, catalog)
+	}
+	candidates := catalogCandidates(catalogs, map[string]bool{"used": true})
+	must.Equal(1, len(candidates))
+	must.Equal("unused", candidates[0].Blueprint)
+	must.Equal("catalog", candidates[0].Kind)
+}
+
+func TestCatalogEvictionRemovesInfoAndSkipsFailures(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	t.Setenv(common.Product.HomeVariable(), t.TempDir())
+	folder := t.TempDir()
+	catalogs := Roots{}
+	for _, name := range []string{"stuck", "removable"} {
+		catalog, err := NewRoot(folder)
+		must.Nil(err)
+		catalog.Blueprint = name
+		catalog.source = filepath.Join(folder, name+"v12.linux_amd64")
+		must.Nil(os.WriteFile(catalog.source+".info", []byte{}, 0o644))
+		catalogs = append(catalogs, catalog)
+	}
+	stuck, removable := catalogs[0].Source(), catalogs[1].Source()
+	must.Nil(os.MkdirAll(filepath.Join(stuck, "locked"), 0o755))
+	must.Nil(os.WriteFile(removable, []byte{}, 0o644))
+	old := time.Now().Add(-48 * time.Hour)
+	must.Nil(os.Chtimes(stuck, old, old))
+
+	report := &BudgetReport{MaxSize: 1, UsedAfter: 100, Evicted: []*Eviction{}}
+	must.Equal(1, evictCatalogs(report, catalogs, map[string]bool{}))
+	must.Equal(1, len(report.Evicted))
+	must.Equal("removable", report.Evicted[0].Blueprint)
+	must.True(pathlib.Exists(stuck))
+	must.True(pathlib.IsFile(stuck + ".info"))
+	wont.True(pathlib.Exists(removable))
+	wont.True(pathlib.Exists(removable + ".info"))
+}
@@ -77,17 +77,37 @@ func NewEnvironment(condafile, holozip string, restore, force bool, puller Catal
 		pretty.Progress(1, "Fresh [private mode] holotree environment %v. (parent/pid: %d/%d)", xviper.TrackingIdentity(), os.Getppid(), os.Getpid())
 	}
 
-	lockfile := common.HolotreeLock()
-	completed := pathlib.LockWaitMessage(lockfile, "Serialized environment creation [holotree lock]")
+	_, holotreeBlueprint, err := ComposeFinalBlueprint([]string{condafile}, "")
+	fail.Fast(err)
+
+	common.EnvironmentHash, common.FreshlyBuildEnvironment = common.BlueprintHash(holotreeBlueprint), false
+
+	// disk budget is enforced only after all locks of this build are released
+	defer func() {
+		if err == nil {
+			_, failure := EnforceDiskBudget(path)
+			if failure != nil {
+				pretty.Warning("Holotree disk budget enforcement failed, reason: %v", failure)
+			}
+		}
+	}()
+
+	// different blueprints build in parallel, same blueprint waits and reuses
+	lockfile := common.BlueprintLock(common.EnvironmentHash)
+	completed := pathlib.LockWaitMessage(lockfile, "Serialized environment creation [blueprint lock]")
 	locker, err := pathlib.Locker(lockfile, 30000, common.SharedHolotree)
 	completed()
-	fail.On(err != nil, "Could not get lock for holotree. Quiting.")
+	fail.On(err != nil, "Could not get lock for blueprint %q. Quiting.", common.EnvironmentHash)
 	defer locker.Release()
 
-	_, holotreeBlueprint, err := ComposeFinalBlueprint([]string{condafile}, "")
-	fail.Fast(err)
+	// shared holotree lock keeps exclusive maintenance (like gc) out while building
+	globalfile := common.HolotreeLock()
+	completed = pathlib.LockWaitMessage(globalfile, "Waiting holotree maintenance to finish [shared holotree lock]")
+	global, err := pathlib.SharedLocker(globalfile, 30000, common.SharedHolotree)
+	completed()
+	fail.On(err != nil, "Could not get lock for holotree. Quiting.")
+	defer global.Release()
 
-	common.EnvironmentHash, common.FreshlyBuildEnvironment = common.BlueprintHash(holotreeBlueprint), false
 	pretty.Progress(2, "Holotree blueprint is %q [%s with %d workers on %d CPUs from %q].", common.EnvironmentHash, common.Platform(), anywork.Scale(), runtime.NumCPU(), filepath.Base(condafile))
 	journal.CurrentBuildEvent().Blueprint(common.EnvironmentHash)
 
@@ -101,6 +121,7 @@ func NewEnvironment(condafile, holozip string, restore, force bool, puller Catal
 	if common.UnmanagedSpace {
 		tree = Unmanaged(tree)
 	}
+	tree.Isolate(common.EnvironmentHash)
 	fail.Fast(tree.ValidateBlueprint(holotreeBlueprint))
 	scorecard = common.NewScorecard()
 	var library Library
@@ -154,10 +175,11 @@ func RecordEnvironment(tree MutableLibrary, blueprint []byte, force bool, scorec
 	common.Debug("Has blueprint environment: %v", exists)
 
 	conda.LogUnifiedEnvironment(blueprint)
+	fail.Fast(CheckBlueprintPolicy(blueprint))
 
 	if force || !exists {
 		common.FreshlyBuildEnvironment = true
-		remoteOrigin := common.RccRemoteOrigin()
+		remoteOrigin := strings.Join(settings.Global.RemoteOrigins(), ",")
 		if len(remoteOrigin) > 0 {
 			pretty.Progress(3, "Fill hololib from RCC_REMOTE_ORIGIN.")
 			hash := common.BlueprintHash(blueprint)
@@ -166,7 +188,7 @@ func RecordEnvironment(tree MutableLibrary, blueprint []byte, force bool, scorec
 			if err != nil {
 				pretty.Warning("Failed to pull %q from %q, reason: %v", catalog, remoteOrigin, err)
 			} else {
-				return nil
+				return CheckCatalogPolicy(tree.CatalogPath(hash))
 			}
 			exists = tree.HasBlueprint(blueprint)
 		} else {
@@ -200,15 +222,18 @@ func RecordEnvironment(tree MutableLibrary, blueprint []byte, force bool, scorec
 		err = conda.LegacyEnvironment(tree, force, skip, identityfile)
 		fail.On(err != nil, "Failed to create environment, reason %w.", err)
 
+		fail.Fast(CheckInstalledPolicy(tree.Stage()))
+
 		scorecard.Midpoint()
 
 		pretty.Progress(13, "Record holotree stage to hololib [with %d workers on %d CPUs].", anywork.Scale(), runtime.NumCPU())
 		err = tree.Record(blueprint)
 		fail.On(err != nil, "Failed to record blueprint %q, reason: %w", string(blueprint), err)
 		journal.CurrentBuildEvent().RecordComplete()
+		return nil
 	}
 
-	return nil
+	return CheckCatalogPolicy(tree.CatalogPath(common.BlueprintHash(blueprint)))
 }
 
 func RestoreLayersTo(tree MutableLibrary, identityfile string, targetDir string) conda.SkipLayer {
@@ -252,7 +277,7 @@ func RobotBlueprints(userBlueprints []string, packfile string) (robot.Robot, []s
 	return config, append(blueprints, userBlueprints...)
 }
 
-func ComposeFinalBlueprint(userFiles []string, packfile string) (config robot.Robot, blueprint []byte, err error) {
+func ComposeFinalEnvironment(userFiles []string, packfile string) (config robot.Robot, environment *conda.Environment, err error) {
 	defer fail.Around(&err)
 
 	var left, right *conda.Environment
@@ -270,9 +295,49 @@ func ComposeFinalBlueprint(userFiles []string, packfile string) (config robot.Ro
 		fail.On(err != nil, "Failure: %v", err)
 	}
 	fail.On(right == nil, "Missing environment specification(s).")
-	content, err := right.AsYaml()
+	return config, right, nil
+}
+
+func LegacyBlueprint(environment *conda.Environment) ([]byte, error) {
+	content, err := environment.AsYaml()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.TrimSpace(content)), nil
+}
+
+func CanonicalBlueprint(environment *conda.Environment) ([]byte, error) {
+	content, err := environment.AsCanonicalYaml()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.TrimSpace(content)), nil
+}
+
+func SelectBlueprint(environment *conda.Environment) (blueprint []byte, canonical bool, err error) {
+	legacy, err := LegacyBlueprint(environment)
+	if err != nil || !settings.Global.CanonicalBlueprints() {
+		return legacy, false, err
+	}
+	blueprint, err = CanonicalBlueprint(environment)
+	if err != nil {
+		return nil, false, err
+	}
+	tree, err := New()
+	if err != nil || tree.HasBlueprint(blueprint) || !tree.HasBlueprint(legacy) {
+		return blueprint, true, nil
+	}
+	common.Debug("Using existing catalog %q from legacy blueprint instead of canonical %q.", common.BlueprintHash(legacy), common.BlueprintHash(blueprint))
+	return legacy, false, nil
+}
+
+func ComposeFinalBlueprint(userFiles []string, packfile string) (config robot.Robot, blueprint []byte, err error) {
+	defer fail.Around(&err)
+
+	config, right, err := ComposeFinalEnvironment(userFiles, packfile)
+	fail.Fast(err)
+	blueprint, _, err = SelectBlueprint(right)
 	fail.On(err != nil, "YAML error: %v", err)
-	blueprint = []byte(strings.TrimSpace(content))
 	if !right.IsCacheable() {
 		fingerprint := common.BlueprintHash(blueprint)
 		pretty.Warning("Holotree blueprint %q is not publicly cacheable. Use `rcc robot diagnostics` to find out more.", fingerprint)
@@ -0,0 +1,165 @@
+package htfs
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/settings"
+)
+
+const (
+	BlobRaw  = "raw"
+	BlobGzip = "gzip"
+	BlobZstd = "zstd"
+
+	defaultZstdLevel = 3
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+	zstdDecoders sync.Pool
+)
+
+func BlobCompression() string {
+	switch settings.Global.Compression() {
+	case BlobGzip:
+		return BlobGzip
+	default:
+		return BlobZstd
+	}
+}
+
+func zstdLevel() zstd.EncoderLevel {
+	level := settings.Global.CompressionLevel()
+	if level < 1 {
+		level = defaultZstdLevel
+	}
+	return zstd.EncoderLevelFromZstd(level)
+}
+
+func DetectBlobFormat(magic []byte) string {
+	switch {
+	case bytes.HasPrefix(magic, zstdMagic):
+		return BlobZstd
+	case bytes.HasPrefix(magic, gzipMagic):
+		return BlobGzip
+	default:
+		return BlobRaw
+	}
+}
+
+func BlobFormatOf(filename string) string {
+	source, err := os.Open(filename)
+	if err != nil {
+		return BlobRaw
+	}
+	defer source.Close()
+	magic := make([]byte, len(zstdMagic))
+	size, _ := io.ReadFull(source, magic)
+	return DetectBlobFormat(magic[:size])
+}
+
+func blobDigest(filename string, decode bool) (string, error) {
+	source, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+	var reader io.Reader = source
+	if decode {
+		decoded, release, err := BlobReader(source)
+		if err != nil {
+			return "", err
+		}
+		defer release()
+		reader = decoded
+	}
+	digest := common.NewDigester(Compress())
+	_, err = io.Copy(digest, reader)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%02x", digest.Sum(nil)), nil
+}
+
+func BlobContentDigest(filename, expected string) (string, error) {
+	decode := Compress()
+	digest, err := blobDigest(filename, decode)
+	if (err == nil && digest == expected) || BlobFormatOf(filename) == BlobRaw {
+		return digest, err
+	}
+	return blobDigest(filename, !decode)
+}
+
+func StoredBlobFormat(filename string) string {
+	format := BlobFormatOf(filename)
+	if format == BlobRaw {
+		return format
+	}
+	digest, err := blobDigest(filename, true)
+	if err != nil || digest != filepath.Base(filename) {
+		return BlobRaw
+	}
+	return format
+}
+
+func BlobReader(source io.Reader) (readable io.Reader, release func(), err error) {
+	buffered := bufio.NewReader(source)
+	magic, _ := buffered.Peek(len(zstdMagic))
+	switch DetectBlobFormat(magic) {
+	case BlobZstd:
+		decoder, ok := zstdDecoders.Get().(*zstd.Decoder)
+		if !ok {
+			decoder, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		err = decoder.Reset(buffered)
+		if err != nil {
+			decoder.Close()
+			return nil, nil, err
+		}
+		return decoder, func() {
+			decoder.Reset(nil)
+			zstdDecoders.Put(decoder)
+		}, nil
+	case BlobGzip:
+		unzipped, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, nil, err
+		}
+		return unzipped, func() { unzipped.Close() }, nil
+	default:
+		return buffered, func() {}, nil
+	}
+}
+
+func blobWriter(sink io.Writer, format string) (io.WriteCloser, error) {
+	switch format {
+	case BlobZstd:
+		return zstd.NewWriter(sink, zstd.WithEncoderLevel(zstdLevel()), zstd.WithEncoderConcurrency(1))
+	case BlobGzip:
+		return gzip.NewWriterLevel(sink, gzip.BestSpeed)
+	default:
+		return nopWriteCloser{sink}, nil
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (it nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,175 @@
+package htfs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/hamlet"
+)
+
+func sampleContent() []byte {
+	words := []string{"def", "return", "self", "import", "class", "value", "None", "for", "in", "if", "else", "(", ")", ":", "\n    "}
+	random := rand.New(rand.NewSource(1337))
+	buffer := bytes.NewBuffer(nil)
+	for buffer.Len() < 1<<20 {
+		fmt.Fprintf(buffer, "%s_%d ", words[random.Intn(len(words))], random.Intn(500))
+	}
+	return buffer.Bytes()
+}
+
+func writeBlob(folder, format string, content []byte) (string, error) {
+	digest := common.NewDigester(Compress())
+	digest.Write(content)
+	filename := filepath.Join(folder, fmt.Sprintf("%02x", digest.Sum(nil)))
+	buffer := bytes.NewBuffer(nil)
+	writer, err := blobWriter(buffer, format)
+	if err != nil {
+		return "", err
+	}
+	_, err = writer.Write(content)
+	if err != nil {
+		return "", err
+	}
+	err = writer.Close()
+	if err != nil {
+		return "", err
+	}
+	return filename, os.WriteFile(filename, buffer.Bytes(), 0o644)
+}
+
+func readBlob(filename string) ([]byte, error) {
+	reader, closer, err := blobDelegateOpen(filename, true)
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+	return io.ReadAll(reader)
+}
+
+func TestCanDetectBlobFormats(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	must.Equal(BlobZstd, DetectBlobFormat([]byte{0x28, 0xb5, 0x2f, 0xfd}))
+	must.Equal(BlobGzip, DetectBlobFormat([]byte{0x1f, 0x8b, 0x08, 0x00}))
+	must.Equal(BlobRaw, DetectBlobFormat([]byte("raw!")))
+	must.Equal(BlobRaw, DetectBlobFormat([]byte{0x28}))
+	must.Equal(BlobRaw, DetectBlobFormat(nil))
+	must.Equal(BlobRaw, BlobFormatOf(filepath.Join(t.TempDir(), "missing")))
+}
+
+func TestCanReadMixedFormatBlobs(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	content := sampleContent()
+	for _, format := range []string{BlobRaw, BlobGzip, BlobZstd} {
+		filename, err := writeBlob(t.TempDir(), format, content)
+		must.Nil(err)
+		must.Equal(format, BlobFormatOf(filename))
+		found, err := readBlob(filename)
+		must.Nil(err)
+		must.Equal(content, found)
+	}
+}
+
+func TestCanRecompressBlobsBetweenFormats(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	content := sampleContent()
+	filename, err := writeBlob(t.TempDir(), BlobGzip, content)
+	must.Nil(err)
+	before := BlobFormatOf(filename)
+	must.Equal(BlobGzip, before)
+
+	size, err := RecompressBlob(filename, BlobZstd)
+	must.Nil(err)
+	must.True(size > 0)
+	must.Equal(BlobZstd, BlobFormatOf(filename))
+	found, err := readBlob(filename)
+	must.Nil(err)
+	must.Equal(content, found)
+
+	_, err = RecompressBlob(filename, BlobRaw)
+	must.Nil(err)
+	must.Equal(BlobRaw, BlobFormatOf(filename))
+
+	corrupted := filepath.Join(filepath.Dir(filename), strings.Repeat("0", 64))
+	must.Nil(os.WriteFile(corrupted, content, 0o644))
+	_, err = RecompressBlob(corrupted, BlobZstd)
+	wont.Nil(err)
+	must.Equal(BlobRaw, BlobFormatOf(corrupted))
+}
+
+func benchmarkBlobRestore(b *testing.B, format string) {
+	content := sampleContent()
+	filename, err := writeBlob(b.TempDir(), format, content)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(content)))
+	b.ResetTimer()
+	for round := 0; round < b.N; round++ {
+		reader, closer, err := blobDelegateOpen(filename, true)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, err = io.Copy(io.Discard, reader)
+		closer()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRestoreGzipBlob(b *testing.B) {
+	benchmarkBlobRestore(b, BlobGzip)
+}
+
+func BenchmarkRestoreZstdBlob(b *testing.B) {
+	benchmarkBlobRestore(b, BlobZstd)
+}
+
+func BenchmarkRestoreRawBlob(b *testing.B) {
+	benchmarkBlobRestore(b, BlobRaw)
+}
+
+func TestRawBlobsWithCompressedContentStayRaw(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	gzipped := bytes.NewBuffer(nil)
+	writer, err := blobWriter(gzipped, BlobGzip)
+	must.Nil(err)
+	_, err = writer.Write(sampleContent())
+	must.Nil(err)
+	must.Nil(writer.Close())
+	content := gzipped.Bytes()
+
+	filename, err := writeBlob(t.TempDir(), BlobRaw, content)
+	must.Nil(err)
+	must.Equal(BlobGzip, BlobFormatOf(filename))
+	must.Equal(BlobRaw, StoredBlobFormat(filename))
+
+	digest, err := BlobContentDigest(filename, filepath.Base(filename))
+	must.Nil(err)
+	must.Equal(filepath.Base(filename), digest)
+
+	_, err = RecompressBlob(filename, BlobZstd)
+	must.Nil(err)
+	must.Equal(BlobZstd, StoredBlobFormat(filename))
+	found, err := readBlob(filename)
+	must.Nil(err)
+	must.Equal(content, found)
+
+	_, err = RecompressBlob(filename, BlobRaw)
+	must.Nil(err)
+	must.Equal(BlobRaw, StoredBlobFormat(filename))
+	found, err = os.ReadFile(filename)
+	must.Nil(err)
+	must.Equal(content, found)
+}
@@ -0,0 +1,258 @@
+package htfs
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/fail"
+	"github.com/robocorp/rcc/pathlib"
+)
+
+const (
+	distInfoSuffix = ".dist-info"
+	condaMetaDir   = "conda-meta"
+	sitePackages   = "site-packages"
+)
+
+type (
+	FileState struct {
+		Digest  string      `json:"digest"`
+		Size    int64       `json:"size"`
+		Mode    fs.FileMode `json:"mode"`
+		Symlink string      `json:"symlink,omitempty"`
+	}
+
+	FileChange struct {
+		Path   string     `json:"path"`
+		Before *FileState `json:"before,omitempty"`
+		After  *FileState `json:"after,omitempty"`
+	}
+
+	PackageChange struct {
+		Name   string `json:"name"`
+		Before string `json:"before,omitempty"`
+		After  string `json:"after,omitempty"`
+	}
+
+	CatalogDiff struct {
+		Left    string           `json:"left"`
+		Right   string           `json:"right"`
+		Added   []*FileChange    `json:"added"`
+		Removed []*FileChange    `json:"removed"`
+		Changed []*FileChange    `json:"changed"`
+		Python  []*PackageChange `json:"python"`
+		Conda   []*PackageChange `json:"conda"`
+	}
+
+	fileStates map[string]*FileState
+	versions   map[string]string
+)
+
+func (it *FileState) differs(other *FileState) bool {
+	return it.Digest != other.Digest || it.Size != other.Size || it.Mode != other.Mode || it.Symlink != other.Symlink
+}
+
+func (it *PackageChange) Kind() string {
+	switch {
+	case len(it.Before) == 0:
+		return "added"
+	case len(it.After) == 0:
+		return "removed"
+	default:
+		return "changed"
+	}
+}
+
+func (it *CatalogDiff) Identical() bool {
+	return len(it.Added)+len(it.Removed)+len(it.Changed) == 0
+}
+
+func ResolveCatalog(name string) string {
+	if pathlib.IsFile(name) {
+		return name
+	}
+	candidates := []string{
+		filepath.Join(common.HololibCatalogLocation(), name),
+		filepath.Join(common.HololibCatalogLocation(), CatalogName(name)),
+	}
+	for _, candidate := range candidates {
+		if pathlib.IsFile(candidate) {
+			return candidate
+		}
+	}
+	return name
+}
+
+func LoadCatalog(name string) (root *Root, err error) {
+	defer fail.Around(&err)
+
+	catalog := ResolveCatalog(name)
+	fail.On(!pathlib.IsFile(catalog), "Catalog %q not found in %q.", name, common.HololibCatalogLocation())
+	tempdir := filepath.Join(common.ProductTemp(), "shadow")
+	root, err = NewRoot(tempdir)
+	fail.On(err != nil, "Temp dir %q, reason: %v", tempdir, err)
+	err = root.LoadFrom(catalog)
+	fail.On(err != nil, "Load %q, reason: %v", catalog, err)
+	return root, nil
+}
+
+func DiffCatalogs(left, right string) (diff *CatalogDiff, err error) {
+	defer fail.Around(&err)
+
+	before, err := LoadCatalog(left)
+	fail.Fast(err)
+	after, err := LoadCatalog(right)
+	fail.Fast(err)
+	diff = DiffRoots(before, after)
+	diff.Left, diff.Right = filepath.Base(before.Source()), filepath.Base(after.Source())
+	return diff, nil
+}
+
+func DiffRoots(left, right *Root) *CatalogDiff {
+	common.TimelineBegin("catalog diff start")
+	defer common.TimelineEnd()
+
+	before, after := make(fileStates), make(fileStates)
+	left.Tree.collectStates("", before)
+	right.Tree.collectStates("", after)
+
+	diff := &CatalogDiff{
+		Left:    left.Source(),
+		Right:   right.Source(),
+		Added:   []*FileChange{},
+		Removed: []*FileChange{},
+		Changed: []*FileChange{},
+	}
+	for name, state := range before {
+		other, ok := after[name]
+		switch {
+		case !ok:
+			diff.Removed = append(diff.Removed, &FileChange{Path: name, Before: state})
+		case state.differs(other):
+			diff.Changed = append(diff.Changed, &FileChange{Path: name, Before: state, After: other})
+		}
+	}
+	for name, state := range after {
+		if _, ok := before[name]; !ok {
+			diff.Added = append(diff.Added, &FileChange{Path: name, After: state})
+		}
+	}
+	sortChanges(diff.Added)
+	sortChanges(diff.Removed)
+	sortChanges(diff.Changed)
+	diff.Python = packageChanges(left.Tree.pythonPackages(""), right.Tree.pythonPackages(""))
+	diff.Conda = packageChanges(left.Tree.condaPackages(""), right.Tree.condaPackages(""))
+	return diff
+}
+
+func sortChanges(changes []*FileChange) {
+	sort.Slice(changes, func(left, right int) bool {
+		return changes[left].Path < changes[right].Path
+	})
+}
+
+func (it *Dir) collectStates(prefix string, target fileStates) {
+	for name, file := range it.Files {
+		target[path.Join(prefix, name)] = &FileState{
+			Digest:  file.Digest,
+			Size:    file.Size,
+			Mode:    file.Mode,
+			Symlink: file.Symlink,
+		}
+	}
+	for name, dir := range it.Dirs {
+		if dir.IsSymlink() {
+			target[path.Join(prefix, name)] = &FileState{
+				Mode:    dir.Mode,
+				Symlink: dir.Symlink,
+			}
+			continue
+		}
+		dir.collectStates(path.Join(prefix, name), target)
+	}
+}
+
+func (it *Dir) pythonPackages(name string) versions {
+	result := make(versions)
+	if name == sitePackages {
+		for dirname := range it.Dirs {
+			if !strings.HasSuffix(dirname, distInfoSuffix) {
+				continue
+			}
+			core := strings.TrimSuffix(dirname, distInfoSuffix)
+			at := strings.LastIndex(core, "-")
+			if at > 0 {
+				result[normalizePackage(core[:at])] = core[at+1:]
+			}
+		}
+	}
+	for dirname, dir := range it.Dirs {
+		for key, value := range dir.pythonPackages(dirname) {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+func (it *Dir) condaPackages(name string) versions {
+	result := make(versions)
+	if name == condaMetaDir {
+		for filename := range it.Files {
+			if filepath.Ext(filename) != ".json" {
+				continue
+			}
+			parts := strings.Split(strings.TrimSuffix(filename, ".json"), "-")
+			if len(parts) < 3 {
+				continue
+			}
+			size := len(parts)
+			result[strings.Join(parts[:size-2], "-")] = parts[size-2]
+		}
+		return result
+	}
+	for dirname, dir := range it.Dirs {
+		for key, value := range dir.condaPackages(dirname) {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+func normalizePackage(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "-", ".", "-").Replace(name))
+}
+
+func packageChanges(before, after versions) []*PackageChange {
+	result := []*PackageChange{}
+	for name, version := range before {
+		other := after[name]
+		if version != other {
+			result = append(result, &PackageChange{Name: name, Before: version, After: other})
+		}
+	}
+	for name, version := range after {
+		if _, ok := before[name]; !ok {
+			result = append(result, &PackageChange{Name: name, After: version})
+		}
+	}
+	sort.Slice(result, func(left, right int) bool {
+		return result[left].Name < result[right].Name
+	})
+	return result
+}
+
+func (it *FileState) String() string {
+	if len(it.Symlink) > 0 {
+		return fmt.Sprintf("-> %s [%s]", it.Symlink, it.Mode)
+	}
+	digest := it.Digest
+	if len(digest) > 16 {
+		digest = digest[:16]
+	}
+	return fmt.Sprintf("%s %d bytes [%s]", digest, it.Size, it.Mode)
+}
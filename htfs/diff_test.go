@@ -0,0 +1,92 @@
+package htfs_test
+
+import (
+	"testing"
+
+	"github.com/robocorp/rcc/hamlet"
+	"github.com/robocorp/rcc/htfs"
+)
+
+func testDir(name string, files ...*htfs.File) *htfs.Dir {
+	result := &htfs.Dir{
+		Name:  name,
+		Dirs:  make(map[string]*htfs.Dir),
+		Files: make(map[string]*htfs.File),
+	}
+	for _, file := range files {
+		result.Files[file.Name] = file
+	}
+	return result
+}
+
+func testFile(name, digest string, size int64) *htfs.File {
+	return &htfs.File{Name: name, Digest: digest, Size: size, Mode: 0o644}
+}
+
+func testRoot(site, meta *htfs.Dir, files ...*htfs.File) *htfs.Root {
+	tree := testDir("", files...)
+	lib := testDir("lib")
+	lib.Dirs["site-packages"] = site
+	tree.Dirs["lib"] = lib
+	tree.Dirs["conda-meta"] = meta
+	return &htfs.Root{Info: &htfs.Info{}, Tree: tree}
+}
+
+func TestCanDiffCatalogRoots(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	leftSite := testDir("site-packages")
+	leftSite.Dirs["requests-2.28.0.dist-info"] = testDir("requests-2.28.0.dist-info")
+	leftSite.Dirs["Robot_Framework-6.0.dist-info"] = testDir("Robot_Framework-6.0.dist-info")
+	leftSite.Dirs["six-1.16.0.dist-info"] = testDir("six-1.16.0.dist-info")
+	leftMeta := testDir("conda-meta", testFile("python-3.9.13-h6244533_2.json", "aa", 1), testFile("ca-certificates-2022.1.1-0.json", "bb", 1))
+	left := testRoot(leftSite, leftMeta, testFile("same", "11", 1), testFile("changed", "22", 2), testFile("gone", "33", 3))
+
+	rightSite := testDir("site-packages")
+	rightSite.Dirs["requests-2.31.0.dist-info"] = testDir("requests-2.31.0.dist-info")
+	rightSite.Dirs["robot_framework-6.0.dist-info"] = testDir("robot_framework-6.0.dist-info")
+	rightSite.Dirs["urllib3-2.0.0.dist-info"] = testDir("urllib3-2.0.0.dist-info")
+	rightMeta := testDir("conda-meta", testFile("python-3.10.12-h6244533_0.json", "cc", 1), testFile("ca-certificates-2022.1.1-0.json", "bb", 1))
+	moded := testFile("moded", "44", 4)
+	right := testRoot(rightSite, rightMeta, testFile("same", "11", 1), testFile("changed", "23", 2), testFile("new", "55", 5), moded)
+	leftModed := testFile("moded", "44", 4)
+	leftModed.Mode = 0o755
+	left.Tree.Files["moded"] = leftModed
+
+	diff := htfs.DiffRoots(left, right)
+	wont.Nil(diff)
+	wont.True(diff.Identical())
+
+	must.Equal(2, len(diff.Added))
+	must.Equal("conda-meta/python-3.10.12-h6244533_0.json", diff.Added[0].Path)
+	must.Equal("new", diff.Added[1].Path)
+	must.Equal(2, len(diff.Removed))
+	must.Equal("conda-meta/python-3.9.13-h6244533_2.json", diff.Removed[0].Path)
+	must.Equal("gone", diff.Removed[1].Path)
+
+	changed := []string{}
+	for _, change := range diff.Changed {
+		changed = append(changed, change.Path)
+	}
+	must.Equal(2, len(changed))
+	must.Equal("changed", changed[0])
+	must.Equal("moded", changed[1])
+
+	must.Equal(3, len(diff.Python))
+	must.Equal("requests", diff.Python[0].Name)
+	must.Equal("2.28.0", diff.Python[0].Before)
+	must.Equal("2.31.0", diff.Python[0].After)
+	must.Equal("removed", diff.Python[1].Kind())
+	must.Equal("six", diff.Python[1].Name)
+	must.Equal("added", diff.Python[2].Kind())
+	must.Equal("urllib3", diff.Python[2].Name)
+
+	must.Equal(1, len(diff.Conda))
+	must.Equal("python", diff.Conda[0].Name)
+	must.Equal("3.9.13", diff.Conda[0].Before)
+	must.Equal("3.10.12", diff.Conda[0].After)
+
+	same := htfs.DiffRoots(left, left)
+	must.True(same.Identical())
+	must.Equal(0, len(same.Python)+len(same.Conda))
+}
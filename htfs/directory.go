@@ -92,17 +92,22 @@ func (it *Info) saveAs(filename string) error {
 	if err != nil {
 		return err
 	}
-	sink, err := pathlib.Create(filename)
+	partname := fmt.Sprintf("%s.part%s", filename, <-common.Identities)
+	defer os.Remove(partname)
+	sink, err := pathlib.Create(partname)
 	if err != nil {
 		return err
 	}
-	defer sink.Close()
-	defer sink.Sync()
 	_, err = sink.Write(content)
 	if err != nil {
+		sink.Close()
 		return err
 	}
-	return nil
+	err = sink.Close()
+	if err != nil {
+		return err
+	}
+	return pathlib.TryRename("catalog info", partname, filename)
 }
 
 func (it Roots) BaseFolders() []string {
@@ -162,7 +167,7 @@ func (it Roots) InstallationPlan(hash string) (string, bool) {
 	return "", false
 }
 
-func (it Roots) RemoveHolotreeSpace(label string) (err error) {
+func (it Roots) RemoveHolotreeSpace(label string, includePinned bool) (err error) {
 	defer fail.Around(&err)
 
 	for directory, metafile := range it.Spacemap() {
@@ -170,6 +175,8 @@ func (it Roots) RemoveHolotreeSpace(label string) (err error) {
 		if name != label {
 			continue
 		}
+		fail.On(!includePinned && IsPinned(directory), "Space %q is pinned. Unpin it first, or use --include-pinned to remove it anyway.", name)
+		pathlib.TryRemove("pinfile", PinFile(directory))
 		pathlib.TryRemove("metafile", metafile)
 		pathlib.TryRemove("lockfile", directory+".lck")
 		err = pathlib.TryRemoveAll("space", directory)
@@ -314,22 +321,38 @@ func (it *Root) SaveAs(filename string) error {
 	if err != nil {
 		return err
 	}
+	partname := fmt.Sprintf("%s.part%s", filename, <-common.Identities)
+	defer os.Remove(partname)
+	err = it.writeCatalog(partname, content)
+	if err != nil {
+		return err
+	}
+	err = pathlib.TryRename("catalog", partname, filename)
+	if err != nil {
+		return err
+	}
+	return it.Info.saveAs(filename + ".info")
+}
+
+func (it *Root) writeCatalog(filename string, content []byte) error {
 	sink, err := pathlib.Create(filename)
 	if err != nil {
 		return err
 	}
 	defer sink.Close()
-	defer sink.Sync()
 	writer, err := gzip.NewWriterLevel(sink, gzip.BestSpeed)
 	if err != nil {
 		return err
 	}
-	defer writer.Close()
 	_, err = writer.Write(content)
 	if err != nil {
 		return err
 	}
-	return it.Info.saveAs(filename + ".info")
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+	return sink.Sync()
 }
 
 func (it *Root) ReadFrom(source io.Reader) error {
@@ -356,7 +379,7 @@ func (it *Root) LoadFrom(filename string) error {
 func showFile(filename string) (content []byte, err error) {
 	defer fail.Around(&err)
 
-	reader, closer, err := gzDelegateOpen(filename, true)
+	reader, closer, err := blobDelegateOpen(filename, true)
 	fail.On(err != nil, "Failed to open %q, reason: %v", filename, err)
 	defer closer()
 
@@ -463,6 +486,11 @@ func (it *File) Match(info fs.FileInfo) bool {
 	return name && size && mode
 }
 
+func (it *File) MatchLinked(info fs.FileInfo) bool {
+	linked := len(it.Rewrite) == 0 && info.Mode() == readonly(it.Mode)
+	return linked && it.Name == info.Name() && it.Size == info.Size()
+}
+
 func newDir(name, symlink string, shadow bool) *Dir {
 	return &Dir{
 		Name:    name,
@@ -1,7 +1,6 @@
 package htfs
 
 import (
-	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
@@ -98,27 +97,12 @@ func CheckHasher(known map[string]map[string]bool) Filetask {
 			if !ok {
 				defer anywork.Backlog(RemoveFile(fullpath))
 			}
-			source, err := os.Open(fullpath)
-			if err != nil {
-				anywork.Backlog(RemoveFile(fullpath))
-				panic(fmt.Sprintf("Open[check] %q, reason: %v", fullpath, err))
-			}
-			defer source.Close()
-
-			var reader io.ReadCloser
-			reader, err = gzip.NewReader(source)
-			if err != nil {
-				_, err = source.Seek(0, 0)
-				fail.On(err != nil, "Failed to seek %q -> %v", fullpath, err)
-				reader = source
-			}
-			digest := common.NewDigester(Compress())
-			_, err = io.Copy(digest, reader)
+			digest, err := BlobContentDigest(fullpath, details.Name)
 			if err != nil {
 				anywork.Backlog(RemoveFile(fullpath))
-				panic(fmt.Sprintf("Copy[check] %q, reason: %v", fullpath, err))
+				panic(fmt.Sprintf("Digest[check] %q, reason: %v", fullpath, err))
 			}
-			details.Digest = fmt.Sprintf("%02x", digest.Sum(nil))
+			details.Digest = digest
 		}
 	}
 }
@@ -233,22 +217,27 @@ func LiftFile(sourcename, sinkname string, compress bool) anywork.Work {
 
 		defer sink.Close()
 
-		var writer io.WriteCloser
-		writer = sink
+		format := BlobRaw
 		if compress {
-			writer, err = gzip.NewWriterLevel(sink, gzip.BestSpeed)
-			anywork.OnErrPanicCloseAll(err, sink)
+			format = BlobCompression()
 		}
+		writer, err := blobWriter(sink, format)
+		anywork.OnErrPanicCloseAll(err, sink)
 
 		_, err = io.Copy(writer, source)
 		anywork.OnErrPanicCloseAll(err, sink)
 
-		if compress {
-			anywork.OnErrPanicCloseAll(writer.Close(), sink)
-		}
+		anywork.OnErrPanicCloseAll(writer.Close(), sink)
 
 		anywork.OnErrPanicCloseAll(sink.Close())
 
+		if !compress {
+			info, err := source.Stat()
+			if err == nil {
+				settleBlob(partname, info.Mode())
+			}
+		}
+
 		runtime.Gosched()
 
 		anywork.OnErrPanicCloseAll(pathlib.TryRename("liftfile", partname, sinkname))
@@ -362,6 +351,7 @@ func restoreSymlink(source, target string) error {
 }
 
 func RestoreDirectory(library Library, fs *Root, current map[string]string, stats *stats) Dirtask {
+	restorer := newRestorer(library, stats)
 	return func(path string, it *Dir) anywork.Work {
 		return func() {
 			if it.Shadow {
@@ -406,11 +396,11 @@ func RestoreDirectory(library Library, fs *Root, current map[string]string, stat
 				golden := !ok || found.Digest == shadow
 				info, err := part.Info()
 				anywork.OnErrPanicCloseAll(err)
-				ok = golden && found.Match(info)
+				ok = golden && (found.Match(info) || found.MatchLinked(info))
 				stats.Dirty(!ok)
 				if !ok {
 					common.Trace("* Holotree: update changed file    %q", directpath)
-					anywork.Backlog(DropFile(library, found.Digest, directpath, found, fs.Rewrite()))
+					anywork.Backlog(restorer.Drop(found.Digest, directpath, found, fs.Rewrite()))
 				}
 			}
 			for name, found := range it.Files {
@@ -419,7 +409,7 @@ func RestoreDirectory(library Library, fs *Root, current map[string]string, stat
 				if !seen {
 					stats.Dirty(true)
 					common.Trace("* Holotree: add missing file       %q", directpath)
-					anywork.Backlog(DropFile(library, found.Digest, directpath, found, fs.Rewrite()))
+					anywork.Backlog(restorer.Drop(found.Digest, directpath, found, fs.Rewrite()))
 				}
 			}
 		}
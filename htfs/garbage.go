@@ -0,0 +1,106 @@
+package htfs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/fail"
+	"github.com/robocorp/rcc/journal"
+	"github.com/robocorp/rcc/pathlib"
+)
+
+type GarbageReport struct {
+	DryRun    bool   `json:"dryrun"`
+	Catalogs  int    `json:"catalogs"`
+	Live      int    `json:"live"`
+	Examined  int    `json:"examined"`
+	Orphans   int    `json:"orphans"`
+	Reclaimed int64  `json:"reclaimed"`
+	Failures  int    `json:"failures"`
+	Library   string `json:"library"`
+}
+
+func (it *GarbageReport) Humane() string {
+	value, suffix := pathlib.HumaneSizer(it.Reclaimed)
+	return fmt.Sprintf("%3.1f%s", value, suffix)
+}
+
+func LiveDigests() (live map[string]bool, catalogs int, err error) {
+	defer fail.Around(&err)
+
+	names, roots := LoadCatalogs()
+	fail.On(len(names) != len(roots), "Only %d of %d catalogs could be loaded; refusing to decide what is garbage.", len(roots), len(names))
+	live = make(map[string]bool)
+	for _, root := range roots {
+		collector := make(map[string]string)
+		err = DigestMapper(collector)(root.Path, root.Tree)
+		fail.On(err != nil, "Collecting digests from %q failed, reason: %v", root.Source(), err)
+		for digest, _ := range collector {
+			live[digest] = true
+		}
+	}
+	return live, len(roots), nil
+}
+
+func CollectGarbage(dryrun bool) (report *GarbageReport, err error) {
+	defer fail.Around(&err)
+
+	lockfile := common.HolotreeLock()
+	completed := pathlib.LockWaitMessage(lockfile, "Serialized hololib garbage collection [holotree lock]")
+	locker, err := pathlib.Locker(lockfile, 30000, common.SharedHolotree)
+	completed()
+	fail.On(err != nil, "Could not get lock for holotree. Quiting.")
+	defer locker.Release()
+
+	return collectGarbage(dryrun)
+}
+
+func collectGarbage(dryrun bool) (report *GarbageReport, err error) {
+	defer fail.Around(&err)
+
+	common.TimelineBegin("hololib garbage collection start [dryrun: %v]", dryrun)
+	defer common.TimelineEnd()
+
+	live, catalogs, err := LiveDigests()
+	fail.Fast(err)
+
+	report = &GarbageReport{
+		DryRun:   dryrun,
+		Catalogs: catalogs,
+		Live:     len(live),
+		Library:  common.HololibLibraryLocation(),
+	}
+
+	common.Timeline("hololib mark done: %d live digests in %d catalogs", len(live), catalogs)
+	err = pathlib.Walk(report.Library, pathlib.IgnoreNothing, func(fullpath, relative string, details os.FileInfo) {
+		report.Examined += 1
+		if live[filepath.Base(fullpath)] {
+			return
+		}
+		report.Orphans += 1
+		if dryrun {
+			common.Debug("Would remove orphan %q [%d bytes].", relative, details.Size())
+			report.Reclaimed += details.Size()
+			return
+		}
+		err := pathlib.TryRemove("orphan", fullpath)
+		if err != nil {
+			common.Debug("Failed to remove orphan %q, reason: %v", fullpath, err)
+			report.Failures += 1
+			return
+		}
+		common.Trace("Removed orphan %q [%d bytes].", relative, details.Size())
+		report.Reclaimed += details.Size()
+	})
+	fail.On(err != nil, "Sweeping %q failed, reason: %v", report.Library, err)
+	common.Timeline("hololib sweep done: %d/%d orphans", report.Orphans, report.Examined)
+
+	if !dryrun {
+		err = pathlib.RemoveEmptyDirectores(report.Library)
+		fail.On(err != nil, "%s", err)
+		journal.Post("hololib-gc", report.Library, "removed %d orphans, reclaimed %d bytes, %d failures", report.Orphans, report.Reclaimed, report.Failures)
+	}
+	return report, nil
+}
@@ -34,6 +34,8 @@ type stats struct {
 	dirty     uint64
 	links     uint64
 	duplicate uint64
+	reflinked uint64
+	hardlink  uint64
 }
 
 func (it *stats) Dirtyness() float64 {
@@ -60,6 +62,20 @@ func (it *stats) Link() {
 	it.links++
 }
 
+func (it *stats) Reflinked() {
+	it.Lock()
+	defer it.Unlock()
+
+	it.reflinked++
+}
+
+func (it *stats) Hardlinked() {
+	it.Lock()
+	defer it.Unlock()
+
+	it.hardlink++
+}
+
 func (it *stats) Dirty(dirty bool) {
 	it.Lock()
 	defer it.Unlock()
@@ -92,12 +108,14 @@ type MutableLibrary interface {
 	Location(string) string
 	Record([]byte) error
 	Stage() string
+	Isolate(string)
 	CatalogPath(string) string
 	WriteIdentity([]byte) error
 }
 
 type hololib struct {
 	identity   uint64
+	isolation  uint64
 	basedir    string
 	queryCache map[string]bool
 }
@@ -125,10 +143,21 @@ func (it *hololib) ExactLocation(digest string) string {
 }
 
 func (it *hololib) Identity() string {
-	suffix := fmt.Sprintf("%016x", it.identity)
+	suffix := fmt.Sprintf("%016x", it.identity^it.isolation)
 	return fmt.Sprintf("h%s_%st", common.UserHomeIdentity(), suffix[:14])
 }
 
+func (it *hololib) Isolate(key string) {
+	it.isolation = isolationFor(key)
+}
+
+func isolationFor(key string) uint64 {
+	if len(key) == 0 {
+		return 0
+	}
+	return common.Sipit([]byte(key))
+}
+
 func (it *hololib) WriteIdentity(yaml []byte) error {
 	markerFile := filepath.Join(it.Stage(), "identity.yaml")
 	return pathlib.WriteFile(markerFile, yaml, 0o644)
@@ -333,13 +362,17 @@ func (it *hololib) queryBlueprint(key string) bool {
 func CatalogNames() []string {
 	result := make([]string, 0, 10)
 	for _, catalog := range pathlib.Glob(common.HololibCatalogLocation(), "[0-9a-f]*v12.*") {
-		if filepath.Ext(catalog) != ".info" {
+		if filepath.Ext(catalog) != ".info" && !isPartial(catalog) {
 			result = append(result, filepath.Base(catalog))
 		}
 	}
 	return set.Set(result)
 }
 
+func isPartial(filename string) bool {
+	return strings.Contains(filepath.Base(filename), ".part")
+}
+
 func ControllerSpaceName(client, tag []byte) string {
 	prefix := common.Textual(common.Sipit(client), 7)
 	suffix := common.Textual(common.Sipit(tag), 8)
@@ -429,7 +462,7 @@ func (it *hololib) RestoreTo(blueprint []byte, label, controller, space string,
 	err = fs.AllDirs(RestoreDirectory(it, fs, currentstate, score))
 	fail.On(err != nil, "Failed to restore directories -> %v", err)
 	common.TimelineEnd()
-	defer common.Timeline("- dirty %d/%d (duplicate: %d, links: %d)", score.dirty, score.total, score.duplicate, score.links)
+	defer common.Timeline("- dirty %d/%d (duplicate: %d, links: %d, reflinked: %d, hardlinked: %d)", score.dirty, score.total, score.duplicate, score.links, score.reflinked, score.hardlink)
 	common.Debug("Holotree dirty workload: %d/%d\n", score.dirty, score.total)
 	journal.CurrentBuildEvent().Dirty(score.Dirtyness())
 	fs.Controller = controller
@@ -0,0 +1,63 @@
+package htfs_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robocorp/rcc/hamlet"
+	"github.com/robocorp/rcc/htfs"
+)
+
+func TestIsolatedLibrariesHaveSeparateStages(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	tree, err := htfs.New()
+	must.Nil(err)
+	original := tree.Identity()
+
+	tree.Isolate("cafebabe12345678")
+	first := tree.Identity()
+	wont.Equal(original, first)
+	must.Equal(len(original), len(first))
+	must.Equal(original[:1], first[:1])
+	must.Equal(original[len(original)-1:], first[len(first)-1:])
+
+	tree.Isolate("deadbeef12345678")
+	second := tree.Identity()
+	wont.Equal(first, second)
+	must.Equal(len(first), len(second))
+
+	other, err := htfs.New()
+	must.Nil(err)
+	other.Isolate("cafebabe12345678")
+	must.Equal(first, other.Identity())
+
+	tree.Isolate("")
+	must.Equal(original, tree.Identity())
+
+	virtual := htfs.Virtual()
+	plain := virtual.Identity()
+	virtual.Isolate("cafebabe12345678")
+	wont.Equal(plain, virtual.Identity())
+	must.Equal(len(plain), len(virtual.Identity()))
+}
+
+func TestCatalogSaveLeavesNoPartialFiles(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	folder := t.TempDir()
+	catalog := filepath.Join(folder, "cafebabe12345678v12.linux_amd64")
+	root, err := htfs.NewRoot(folder)
+	must.Nil(err)
+	must.Nil(root.SaveAs(catalog))
+
+	entries, err := os.ReadDir(folder)
+	must.Nil(err)
+	must.Equal(2, len(entries))
+
+	loaded, err := htfs.NewRoot(folder)
+	must.Nil(err)
+	must.Nil(loaded.LoadFrom(catalog))
+	wont.Nil(loaded.Tree)
+}
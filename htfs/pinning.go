@@ -0,0 +1,75 @@
+package htfs
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/fail"
+	"github.com/robocorp/rcc/journal"
+	"github.com/robocorp/rcc/pathlib"
+)
+
+func PinFile(space string) string {
+	return fmt.Sprintf("%s.pin", space)
+}
+
+func IsPinned(space string) bool {
+	return pathlib.IsFile(PinFile(space))
+}
+
+func (it Roots) findSpace(label string) (string, bool) {
+	for directory := range it.Spacemap() {
+		if filepath.Base(directory) == label {
+			return directory, true
+		}
+	}
+	return "", false
+}
+
+func (it Roots) PinHolotreeSpace(label string) (err error) {
+	defer fail.Around(&err)
+
+	directory, ok := it.findSpace(label)
+	fail.On(!ok, "Space %q not found.", label)
+	note := fmt.Sprintf("pinned at %s by %s\n", time.Now().Format(time.RFC3339), common.ControllerIdentity())
+	err = pathlib.WriteFile(PinFile(directory), []byte(note), 0o644)
+	fail.On(err != nil, "Could not pin %q, reason: %v", directory, err)
+	journal.Post("space-pinned", directory, "holotree space %s pinned", label)
+	return nil
+}
+
+func (it Roots) UnpinHolotreeSpace(label string) (err error) {
+	defer fail.Around(&err)
+
+	directory, ok := it.findSpace(label)
+	fail.On(!ok, "Space %q not found.", label)
+	if !IsPinned(directory) {
+		return nil
+	}
+	err = pathlib.TryRemove("pinfile", PinFile(directory))
+	fail.On(err != nil, "Could not unpin %q, reason: %v", directory, err)
+	journal.Post("space-unpinned", directory, "holotree space %s unpinned", label)
+	return nil
+}
+
+func (it Roots) PinnedBlueprints() map[string]bool {
+	result := make(map[string]bool)
+	for _, space := range it.Spaces() {
+		if IsPinned(space.Path) {
+			result[space.Blueprint] = true
+		}
+	}
+	return result
+}
+
+func (it Roots) PinnedSpaces() map[string]bool {
+	result := make(map[string]bool)
+	for directory := range it.Spacemap() {
+		if IsPinned(directory) {
+			result[filepath.Base(directory)] = true
+		}
+	}
+	return result
+}
@@ -0,0 +1,45 @@
+package htfs_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robocorp/rcc/hamlet"
+	"github.com/robocorp/rcc/htfs"
+	"github.com/robocorp/rcc/pathlib"
+)
+
+func TestPinnedSpacesAreProtectedFromRemoval(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	base := t.TempDir()
+	catalog, err := htfs.NewRoot(filepath.Join(base, "catalog"))
+	must.Nil(err)
+	roots := htfs.Roots{catalog}
+	for _, name := range []string{"first", "second"} {
+		space := filepath.Join(base, name)
+		must.Nil(os.MkdirAll(space, 0o755))
+		must.Nil(os.WriteFile(space+".meta", []byte{}, 0o644))
+	}
+	first := filepath.Join(base, "first")
+
+	must.Nil(roots.PinHolotreeSpace("first"))
+	must.True(htfs.IsPinned(first))
+	must.Equal(map[string]bool{"first": true}, roots.PinnedSpaces())
+	wont.Nil(roots.PinHolotreeSpace("missing"))
+
+	wont.Nil(roots.RemoveHolotreeSpace("first", false))
+	must.True(pathlib.Exists(first))
+	must.Nil(roots.RemoveHolotreeSpace("second", false))
+	wont.True(pathlib.Exists(filepath.Join(base, "second")))
+
+	must.Nil(roots.UnpinHolotreeSpace("first"))
+	wont.True(htfs.IsPinned(first))
+	must.Nil(roots.UnpinHolotreeSpace("first"))
+
+	must.Nil(roots.PinHolotreeSpace("first"))
+	must.Nil(roots.RemoveHolotreeSpace("first", true))
+	wont.True(pathlib.Exists(first))
+	wont.True(htfs.IsPinned(first))
+}
@@ -0,0 +1,78 @@
+package htfs
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/conda"
+	"github.com/robocorp/rcc/fail"
+	"github.com/robocorp/rcc/settings"
+)
+
+func StageReader(root *Root) SbomReader {
+	locations := make(map[*File]string)
+	root.Tree.eachFile("", func(location string, file *File) {
+		locations[file] = filepath.Join(root.Path, filepath.FromSlash(location))
+	})
+	return func(file *File) ([]byte, error) {
+		return os.ReadFile(locations[file])
+	}
+}
+
+func CheckBlueprintPolicy(blueprint []byte) (err error) {
+	defer fail.Around(&err)
+
+	policy := settings.Global.Policy()
+	if policy.IsEmpty() {
+		return nil
+	}
+	environment, err := conda.CondaYamlFrom(blueprint)
+	fail.On(err != nil, "Could not parse blueprint for dependency policy, reason: %v", err)
+	report := environment.CheckPolicy(policy)
+	report.Show("check of declared dependencies")
+	return report.Err()
+}
+
+func CheckInstalledPolicy(location string) (err error) {
+	defer fail.Around(&err)
+
+	policy := settings.Global.Policy()
+	if policy.IsEmpty() || (len(policy.Banned) == 0 && len(policy.Licenses) == 0) {
+		return nil
+	}
+	common.TimelineBegin("dependency policy check of installed packages")
+	defer common.TimelineEnd()
+
+	root, err := NewRoot(location)
+	fail.Fast(err)
+	fail.Fast(root.Lift())
+	report := CheckSbomPolicy(NewSbom(root, StageReader(root), false), policy)
+	report.Show("check of installed packages")
+	return report.Err()
+}
+
+func CheckCatalogPolicy(catalog string) (err error) {
+	defer fail.Around(&err)
+
+	policy := settings.Global.Policy()
+	if policy.IsEmpty() || (len(policy.Banned) == 0 && len(policy.Licenses) == 0) {
+		return nil
+	}
+	common.TimelineBegin("dependency policy check of cataloged packages")
+	defer common.TimelineEnd()
+
+	root, err := LoadCatalog(catalog)
+	fail.On(err != nil, "Could not load catalog %q for dependency policy, reason: %v", catalog, err)
+	report := CheckSbomPolicy(NewSbom(root, LibraryBlob, false), policy)
+	report.Show("check of cataloged packages")
+	return report.Err()
+}
+
+func CheckSbomPolicy(sbom *Sbom, policy *settings.Policy) *conda.PolicyReport {
+	report := conda.NewPolicyReport(policy)
+	for _, found := range sbom.Packages {
+		report.CheckInstalled(found.Name, found.Version, found.License)
+	}
+	return report
+}
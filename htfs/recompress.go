@@ -0,0 +1,158 @@
+package htfs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/robocorp/rcc/anywork"
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/fail"
+	"github.com/robocorp/rcc/journal"
+	"github.com/robocorp/rcc/pathlib"
+)
+
+type RecompressReport struct {
+	DryRun    bool           `json:"dryrun"`
+	Target    string         `json:"target"`
+	Library   string         `json:"library"`
+	Examined  int            `json:"examined"`
+	Converted int            `json:"converted"`
+	Formats   map[string]int `json:"formats"`
+	Before    int64          `json:"before"`
+	After     int64          `json:"after"`
+	Failures  int            `json:"failures"`
+	guard     sync.Mutex
+}
+
+func (it *RecompressReport) Saved() int64 {
+	return it.Before - it.After
+}
+
+func (it *RecompressReport) converted(before, after int64) {
+	it.guard.Lock()
+	defer it.guard.Unlock()
+	it.Converted += 1
+	it.Before += before
+	it.After += after
+}
+
+func (it *RecompressReport) failed() {
+	it.guard.Lock()
+	defer it.guard.Unlock()
+	it.Failures += 1
+}
+
+func RecompressTarget() string {
+	if !Compress() {
+		return BlobRaw
+	}
+	return BlobCompression()
+}
+
+func Recompress(dryrun bool) (report *RecompressReport, err error) {
+	defer fail.Around(&err)
+
+	lockfile := common.HolotreeLock()
+	completed := pathlib.LockWaitMessage(lockfile, "Serialized hololib recompression [holotree lock]")
+	locker, err := pathlib.Locker(lockfile, 30000, common.SharedHolotree)
+	completed()
+	fail.On(err != nil, "Could not get lock for holotree. Quiting.")
+	defer locker.Release()
+
+	report = &RecompressReport{
+		DryRun:  dryrun,
+		Target:  RecompressTarget(),
+		Library: common.HololibLibraryLocation(),
+		Formats: make(map[string]int),
+	}
+
+	common.TimelineBegin("hololib recompress start [target: %s, dryrun: %v]", report.Target, dryrun)
+	defer common.TimelineEnd()
+
+	err = pathlib.Walk(report.Library, pathlib.IgnoreNothing, func(fullpath, relative string, details os.FileInfo) {
+		if isPartial(fullpath) {
+			return
+		}
+		report.Examined += 1
+		format := StoredBlobFormat(fullpath)
+		report.Formats[format] += 1
+		if format == report.Target {
+			return
+		}
+		if dryrun {
+			common.Debug("Would recompress %q from %s to %s [%d bytes].", relative, format, report.Target, details.Size())
+			report.converted(details.Size(), details.Size())
+			return
+		}
+		anywork.Backlog(recompressWork(report, fullpath, format, details.Size()))
+	})
+	fail.On(err != nil, "Walking %q failed, reason: %v", report.Library, err)
+	err = anywork.Sync()
+	fail.On(err != nil, "Recompression failed, reason: %v", err)
+	common.Timeline("hololib recompress done: %d/%d blobs", report.Converted, report.Examined)
+
+	if !dryrun {
+		journal.Post("hololib-recompress", report.Library, "converted %d blobs to %s, %d -> %d bytes, %d failures", report.Converted, report.Target, report.Before, report.After, report.Failures)
+	}
+	return report, nil
+}
+
+func recompressWork(report *RecompressReport, fullpath, stored string, before int64) anywork.Work {
+	return func() {
+		after, err := recompressBlob(fullpath, stored, report.Target)
+		if err != nil {
+			common.Debug("Failed to recompress %q, reason: %v", fullpath, err)
+			report.failed()
+			return
+		}
+		report.converted(before, after)
+	}
+}
+
+func RecompressBlob(fullpath, format string) (size int64, err error) {
+	return recompressBlob(fullpath, StoredBlobFormat(fullpath), format)
+}
+
+func recompressBlob(fullpath, stored, format string) (size int64, err error) {
+	defer fail.Around(&err)
+
+	source, err := os.Open(fullpath)
+	fail.On(err != nil, "Open %q, reason: %v", fullpath, err)
+	defer source.Close()
+
+	var reader io.Reader = source
+	if stored != BlobRaw {
+		decoded, release, err := BlobReader(source)
+		fail.On(err != nil, "Decompress %q, reason: %v", fullpath, err)
+		defer release()
+		reader = decoded
+	}
+
+	partname := fmt.Sprintf("%s.part%s", fullpath, <-common.Identities)
+	defer os.Remove(partname)
+	sink, err := os.Create(partname)
+	fail.On(err != nil, "Create %q, reason: %v", partname, err)
+	defer sink.Close()
+
+	writer, err := blobWriter(sink, format)
+	fail.On(err != nil, "Compress %q, reason: %v", partname, err)
+	digest := common.NewDigester(Compress())
+	_, err = io.Copy(io.MultiWriter(writer, digest), reader)
+	fail.On(err != nil, "Copy %q, reason: %v", fullpath, err)
+	fail.On(writer.Close() != nil, "Flush %q failed.", partname)
+	fail.On(sink.Close() != nil, "Close %q failed.", partname)
+
+	expected := filepath.Base(fullpath)
+	actual := fmt.Sprintf("%02x", digest.Sum(nil))
+	fail.On(actual != expected, "Content of %q does not match its digest %q.", fullpath, actual)
+
+	stat, err := os.Stat(partname)
+	fail.On(err != nil, "Stat %q, reason: %v", partname, err)
+	err = pathlib.TryRename("recompress", partname, fullpath)
+	fail.On(err != nil, "Rename %q, reason: %v", partname, err)
+	pathlib.MakeSharedFile(fullpath)
+	return stat.Size(), nil
+}
@@ -0,0 +1,14 @@
+//go:build darwin
+// +build darwin
+
+package htfs
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+const hardlinkSupported = true
+
+func cloneFile(source, target string) error {
+	return unix.Clonefile(source, target, unix.CLONE_NOFOLLOW)
+}
@@ -0,0 +1,30 @@
+//go:build linux
+// +build linux
+
+package htfs
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+const hardlinkSupported = true
+
+func cloneFile(source, target string) error {
+	reader, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	writer, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	err = unix.IoctlFileClone(int(writer.Fd()), int(reader.Fd()))
+	if err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package htfs
+
+import (
+	"fmt"
+	"runtime"
+)
+
+const hardlinkSupported = runtime.GOOS != "windows"
+
+func cloneFile(source, target string) error {
+	return fmt.Errorf("reflinks are not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,169 @@
+package htfs
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/robocorp/rcc/anywork"
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/pathlib"
+	"github.com/robocorp/rcc/settings"
+)
+
+const (
+	RestoreCopy     = "copy"
+	RestoreReflink  = "reflink"
+	RestoreHardlink = "hardlink"
+)
+
+type restorer struct {
+	library    Library
+	mode       string
+	stats      *stats
+	noReflink  atomic.Bool
+	noHardlink atomic.Bool
+}
+
+func RestoreMode(library Library) string {
+	wanted := settings.Global.RestoreMode()
+	switch wanted {
+	case "":
+		return RestoreCopy
+	case RestoreCopy, RestoreReflink, RestoreHardlink:
+	default:
+		common.Debug("Unknown holotree restore mode %q in settings, using %q instead.", wanted, RestoreCopy)
+		return RestoreCopy
+	}
+	if _, ok := library.(*hololib); !ok || Compress() {
+		return RestoreCopy
+	}
+	return wanted
+}
+
+func newRestorer(library Library, stats *stats) *restorer {
+	mode := RestoreMode(library)
+	common.Timeline("holotree restore mode: %s [compression: %v]", mode, Compress())
+	return &restorer{
+		library: library,
+		mode:    mode,
+		stats:   stats,
+	}
+}
+
+func (it *restorer) Drop(digest, sinkname string, details *File, rewrite []byte) anywork.Work {
+	if it.mode == RestoreCopy || details.IsSymlink() {
+		return DropFile(it.library, digest, sinkname, details, rewrite)
+	}
+	return func() {
+		source := ExactDefaultLocation(digest)
+		if it.reflink(source, sinkname, details, rewrite) {
+			it.stats.Reflinked()
+			return
+		}
+		if it.mode == RestoreHardlink && len(details.Rewrite) == 0 && it.hardlink(source, sinkname, details) {
+			it.stats.Hardlinked()
+			return
+		}
+		DropFile(it.library, digest, sinkname, details, rewrite)()
+	}
+}
+
+func (it *restorer) reflink(source, sinkname string, details *File, rewrite []byte) bool {
+	if it.noReflink.Load() || isCompressed(source) {
+		return false
+	}
+	partname := fmt.Sprintf("%s.part%s", sinkname, <-common.Identities)
+	defer os.Remove(partname)
+	err := cloneFile(source, partname)
+	if err != nil {
+		if !it.noReflink.Swap(true) {
+			common.Debug("Reflink restore not available, falling back [%s], reason: %v", sinkname, err)
+		}
+		return false
+	}
+	err = rewriteFile(partname, details.Rewrite, rewrite)
+	if err != nil {
+		common.Trace("Reflink rewrite of %q failed, reason: %v", sinkname, err)
+		return false
+	}
+	return finalizeDrop(partname, sinkname, details.Mode) == nil
+}
+
+func (it *restorer) hardlink(source, sinkname string, details *File) bool {
+	if !hardlinkSupported || it.noHardlink.Load() || !linkable(source, details) {
+		return false
+	}
+	partname := fmt.Sprintf("%s.part%s", sinkname, <-common.Identities)
+	defer os.Remove(partname)
+	err := os.Link(source, partname)
+	if err != nil {
+		if !it.noHardlink.Swap(true) {
+			common.Debug("Hardlink restore not available, falling back [%s], reason: %v", sinkname, err)
+		}
+		return false
+	}
+	return pathlib.TryRename("dropfile", partname, sinkname) == nil
+}
+
+func linkable(source string, details *File) bool {
+	info, err := os.Stat(source)
+	if err != nil {
+		return false
+	}
+	return info.Mode() == readonly(details.Mode) && info.ModTime().Equal(motherTime)
+}
+
+func settleBlob(partname string, mode os.FileMode) {
+	if !hardlinkSupported {
+		return
+	}
+	err := os.Chmod(partname, readonly(mode))
+	if err == nil {
+		err = os.Chtimes(partname, motherTime, motherTime)
+	}
+	if err != nil {
+		common.Trace("Could not settle blob %q for hardlinking, reason: %v", partname, err)
+	}
+}
+
+func finalizeDrop(partname, sinkname string, mode os.FileMode) error {
+	err := os.Chmod(partname, mode)
+	if err != nil {
+		return err
+	}
+	err = os.Chtimes(partname, motherTime, motherTime)
+	if err != nil {
+		return err
+	}
+	return pathlib.TryRename("dropfile", partname, sinkname)
+}
+
+func rewriteFile(filename string, positions []int64, rewrite []byte) error {
+	if len(positions) == 0 {
+		return nil
+	}
+	sink, err := os.OpenFile(filename, os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	for _, position := range positions {
+		_, err = sink.WriteAt(rewrite, position)
+		if err != nil {
+			sink.Close()
+			return err
+		}
+	}
+	return sink.Close()
+}
+
+func readonly(mode os.FileMode) os.FileMode {
+	return mode &^ 0o222
+}
+
+func isCompressed(filename string) bool {
+	if !pathlib.IsFile(filename) {
+		return true
+	}
+	return BlobFormatOf(filename) != BlobRaw
+}
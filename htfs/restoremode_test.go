@@ -0,0 +1,143 @@
+package htfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/hamlet"
+	"github.com/robocorp/rcc/settings"
+)
+
+func TestCanRewriteFileInPlace(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	filename := filepath.Join(t.TempDir(), "sample.txt")
+	must.Nil(os.WriteFile(filename, []byte("aaaa-bbbb-aaaa"), 0o644))
+	must.Nil(rewriteFile(filename, []int64{0, 10}, []byte("cccc")))
+	content, err := os.ReadFile(filename)
+	must.Nil(err)
+	must.Equal("cccc-bbbb-cccc", string(content))
+	must.Nil(rewriteFile(filename+".missing", nil, []byte("x")))
+}
+
+func TestHardlinkRestoreIsReadonlyAndShared(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	if !hardlinkSupported {
+		t.Skip("hardlinks are not used on this platform")
+	}
+	folder := t.TempDir()
+	source := filepath.Join(folder, "library.blob")
+	sink := filepath.Join(folder, "space", "file.py")
+	must.Nil(os.WriteFile(source, []byte("print('hello')\n"), 0o644))
+	must.Nil(os.MkdirAll(filepath.Dir(sink), 0o755))
+
+	details := &File{Name: "file.py", Size: 15, Mode: 0o644}
+	it := &restorer{mode: RestoreHardlink, stats: &stats{}}
+	wont.True(it.hardlink(source, sink, details))
+	settleBlob(source, 0o644)
+	must.True(it.hardlink(source, sink, details))
+
+	left, err := os.Stat(source)
+	must.Nil(err)
+	right, err := os.Stat(sink)
+	must.Nil(err)
+	must.True(os.SameFile(left, right))
+	must.Equal(os.FileMode(0o444), right.Mode())
+	wont.True(details.Match(right))
+	must.True(details.MatchLinked(right))
+
+	relocated := &File{Name: "file.py", Size: 15, Mode: 0o644, Rewrite: []int64{3}}
+	wont.True(relocated.MatchLinked(right))
+}
+
+func TestHardlinkRestoreNeverChangesSharedBlob(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	if !hardlinkSupported {
+		t.Skip("hardlinks are not used on this platform")
+	}
+	folder := t.TempDir()
+	source := filepath.Join(folder, "library.blob")
+	plain := filepath.Join(folder, "space", "plain.sh")
+	executable := filepath.Join(folder, "space", "executable.sh")
+	must.Nil(os.WriteFile(source, []byte("echo hello\n"), 0o644))
+	must.Nil(os.MkdirAll(filepath.Dir(plain), 0o755))
+	settleBlob(source, 0o644)
+
+	it := &restorer{mode: RestoreHardlink, stats: &stats{}}
+	plainDetails := &File{Name: "plain.sh", Size: 11, Mode: 0o644}
+	executableDetails := &File{Name: "executable.sh", Size: 11, Mode: 0o755}
+	for round := 0; round < 2; round++ {
+		must.True(it.hardlink(source, plain, plainDetails))
+		wont.True(it.hardlink(source, executable, executableDetails))
+		blob, err := os.Stat(source)
+		must.Nil(err)
+		must.Equal(os.FileMode(0o444), blob.Mode())
+		must.True(blob.ModTime().Equal(motherTime))
+		linked, err := os.Stat(plain)
+		must.Nil(err)
+		must.True(plainDetails.MatchLinked(linked))
+	}
+	_, err := os.Stat(executable)
+	wont.Nil(err)
+}
+
+func TestReflinkRestoreRewritesOrFallsBack(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	folder := t.TempDir()
+	source := filepath.Join(folder, "library.blob")
+	sink := filepath.Join(folder, "target.txt")
+	must.Nil(os.WriteFile(source, []byte("path=XXXX/bin"), 0o644))
+
+	details := &File{Name: "target.txt", Size: 13, Mode: 0o640, Rewrite: []int64{5}}
+	it := &restorer{mode: RestoreReflink, stats: &stats{}}
+	if !it.reflink(source, sink, details, []byte("YYYY")) {
+		must.True(it.noReflink.Load())
+		wont.True(it.reflink(source, sink, details, []byte("YYYY")))
+		return
+	}
+	content, err := os.ReadFile(sink)
+	must.Nil(err)
+	must.Equal("path=YYYY/bin", string(content))
+	original, err := os.ReadFile(source)
+	must.Nil(err)
+	must.Equal("path=XXXX/bin", string(original))
+}
+
+func TestCompressedBlobsAreNotCloned(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	folder := t.TempDir()
+	gzipped := filepath.Join(folder, "gzipped")
+	zstded := filepath.Join(folder, "zstded")
+	plain := filepath.Join(folder, "plain")
+	must.Nil(os.WriteFile(gzipped, []byte{0x1f, 0x8b, 0x08, 0x00}, 0o644))
+	must.Nil(os.WriteFile(zstded, []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}, 0o644))
+	must.Nil(os.WriteFile(plain, []byte("plain"), 0o644))
+	must.True(isCompressed(gzipped))
+	must.True(isCompressed(zstded))
+	wont.True(isCompressed(plain))
+	must.True(isCompressed(filepath.Join(folder, "missing")))
+}
+
+func TestOnlyHololibSupportsLinkingRestoreModes(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	must.Equal(RestoreCopy, RestoreMode(Virtual()))
+}
+
+func TestRestoreModeDefaultsToCopy(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	t.Setenv(common.Product.HomeVariable(), t.TempDir())
+	must.Nil(os.MkdirAll(common.HololibCatalogLocation(), 0o755))
+	must.Nil(os.WriteFile(common.HololibCompressMarker(), []byte{}, 0o644))
+	library, err := New()
+	must.Nil(err)
+	must.Equal("", settings.Global.RestoreMode())
+	must.Equal(RestoreCopy, RestoreMode(library))
+}
@@ -0,0 +1,346 @@
+package htfs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/conda"
+	"github.com/robocorp/rcc/fail"
+	"github.com/robocorp/rcc/pathlib"
+)
+
+const (
+	EcosystemConda = "conda"
+	EcosystemPypi  = "pypi"
+	goldenMaster   = "golden-ee.yaml"
+)
+
+type (
+	SbomPackage struct {
+		Name      string            `json:"name"`
+		Version   string            `json:"version"`
+		Ecosystem string            `json:"ecosystem"`
+		Origin    string            `json:"origin"`
+		License   string            `json:"license,omitempty"`
+		Homepage  string            `json:"homepage,omitempty"`
+		Hashes    map[string]string `json:"hashes,omitempty"`
+		Files     []string          `json:"files,omitempty"`
+	}
+
+	SbomFile struct {
+		Path      string `json:"path"`
+		Algorithm string `json:"algorithm"`
+		Digest    string `json:"digest"`
+		Size      int64  `json:"size"`
+	}
+
+	Sbom struct {
+		Name      string         `json:"name"`
+		Blueprint string         `json:"blueprint"`
+		Platform  string         `json:"platform"`
+		Source    string         `json:"source"`
+		Packages  []*SbomPackage `json:"packages"`
+		Files     []*SbomFile    `json:"files,omitempty"`
+	}
+
+	SbomReader func(*File) ([]byte, error)
+
+	condaRecord struct {
+		Name    string   `json:"name"`
+		Version string   `json:"version"`
+		Build   string   `json:"build"`
+		Channel string   `json:"channel"`
+		Subdir  string   `json:"subdir"`
+		License string   `json:"license"`
+		Md5     string   `json:"md5"`
+		Sha256  string   `json:"sha256"`
+		Url     string   `json:"url"`
+		Files   []string `json:"files"`
+	}
+
+	directUrl struct {
+		Url string `json:"url"`
+	}
+)
+
+func (it *SbomPackage) key() string {
+	return it.Ecosystem + ":" + normalizePackage(it.Name)
+}
+
+func (it *SbomPackage) Purl() string {
+	if it.Ecosystem == EcosystemPypi {
+		return fmt.Sprintf("pkg:pypi/%s@%s", normalizePackage(it.Name), it.Version)
+	}
+	purl := fmt.Sprintf("pkg:conda/%s@%s", strings.ToLower(it.Name), it.Version)
+	if len(it.Origin) > 0 {
+		purl = fmt.Sprintf("%s?channel=%s", purl, url.QueryEscape(it.Origin))
+	}
+	return purl
+}
+
+func LibraryBlob(file *File) ([]byte, error) {
+	return showFile(filepath.Join(common.HololibLibraryLocation(), guessLocation(file.Digest)))
+}
+
+func DigestAlgorithm(digest string) string {
+	if len(digest) == 64 {
+		return "SHA-256"
+	}
+	return "SIPHASH-128"
+}
+
+func (it *Dir) lookup(parts []string) (*File, bool) {
+	if len(parts) == 1 {
+		file, ok := it.Files[parts[0]]
+		return file, ok
+	}
+	subdir, ok := it.Dirs[parts[0]]
+	if !ok {
+		return nil, false
+	}
+	return subdir.lookup(parts[1:])
+}
+
+func (it *Dir) Lookup(location string) (*File, bool) {
+	parts := strings.Split(path.Clean(filepath.ToSlash(location)), "/")
+	return it.lookup(parts)
+}
+
+func (it *Dir) eachFile(prefix string, visit func(string, *File)) {
+	for name, file := range it.Files {
+		visit(path.Join(prefix, name), file)
+	}
+	for name, dir := range it.Dirs {
+		if !dir.IsSymlink() {
+			dir.eachFile(path.Join(prefix, name), visit)
+		}
+	}
+}
+
+func (it *Dir) eachDir(prefix, wanted string, visit func(string, *Dir)) {
+	for name, dir := range it.Dirs {
+		location := path.Join(prefix, name)
+		if name == wanted {
+			visit(location, dir)
+			continue
+		}
+		if !dir.IsSymlink() {
+			dir.eachDir(location, wanted, visit)
+		}
+	}
+}
+
+func (it *Root) GoldenMaster(reader SbomReader) ([]byte, error) {
+	golden, ok := it.Tree.Lookup(goldenMaster)
+	if !ok {
+		return nil, fmt.Errorf("No %s in environment %q.", goldenMaster, it.Source())
+	}
+	return reader(golden)
+}
+
+func ResolveSbomSource(name string) (root *Root, err error) {
+	defer fail.Around(&err)
+
+	if pathlib.IsFile(name) && strings.HasSuffix(strings.ToLower(name), ".yaml") {
+		_, blueprint, err := ComposeFinalBlueprint([]string{name}, "")
+		fail.Fast(err)
+		hash := common.BlueprintHash(blueprint)
+		catalog := filepath.Join(common.HololibCatalogLocation(), CatalogName(hash))
+		fail.On(!pathlib.IsFile(catalog), "Environment %q [blueprint %s] is not in hololib. Build it first (for example with `rcc holotree prebuild`).", name, hash)
+		return LoadCatalog(catalog)
+	}
+	_, roots := LoadCatalogs()
+	for directory, metafile := range roots.Spacemap() {
+		if filepath.Base(directory) != name && directory != name {
+			continue
+		}
+		root, err = NewRoot(directory)
+		fail.Fast(err)
+		err = root.LoadFrom(metafile)
+		fail.On(err != nil, "Load %q, reason: %v", metafile, err)
+		return root, nil
+	}
+	return LoadCatalog(name)
+}
+
+func NewSbom(root *Root, reader SbomReader, withFiles bool) *Sbom {
+	common.TimelineBegin("sbom collection start [%s]", root.Blueprint)
+	defer common.TimelineEnd()
+
+	result := &Sbom{
+		Name:      filepath.Base(root.Source()),
+		Blueprint: root.Blueprint,
+		Platform:  root.Platform,
+		Source:    root.Source(),
+		Packages:  []*SbomPackage{},
+	}
+	known := make(map[string]*SbomPackage)
+	remember := func(found *SbomPackage) *SbomPackage {
+		previous, ok := known[found.key()]
+		if ok {
+			return previous
+		}
+		known[found.key()] = found
+		result.Packages = append(result.Packages, found)
+		return found
+	}
+
+	content, err := root.GoldenMaster(reader)
+	if err == nil {
+		for _, dependency := range conda.ParseWantedDependencies(content) {
+			ecosystem, origin := EcosystemConda, dependency.Origin
+			if origin == EcosystemPypi {
+				ecosystem = EcosystemPypi
+			}
+			remember(&SbomPackage{Name: dependency.Name, Version: dependency.Version, Ecosystem: ecosystem, Origin: origin})
+		}
+	}
+
+	root.Tree.eachDir("", condaMetaDir, func(location string, dir *Dir) {
+		prefix := path.Dir(location)
+		for name, file := range dir.Files {
+			if path.Ext(name) != ".json" {
+				continue
+			}
+			content, err := reader(file)
+			if err != nil {
+				continue
+			}
+			record := &condaRecord{}
+			if json.Unmarshal(content, record) != nil || len(record.Name) == 0 {
+				continue
+			}
+			found := remember(&SbomPackage{Name: record.Name, Version: record.Version, Ecosystem: EcosystemConda})
+			found.enrichConda(record, prefix)
+		}
+	})
+
+	root.Tree.eachDir("", sitePackages, func(location string, dir *Dir) {
+		for name, subdir := range dir.Dirs {
+			if !strings.HasSuffix(name, distInfoSuffix) {
+				continue
+			}
+			metadata, ok := subdir.Files["METADATA"]
+			if !ok || installedBy(subdir, reader) == EcosystemConda {
+				continue
+			}
+			content, err := reader(metadata)
+			if err != nil {
+				continue
+			}
+			headers, err := mail.ReadMessage(bytes.NewReader(append(content, '\n', '\n')))
+			if err != nil {
+				continue
+			}
+			found := remember(&SbomPackage{Name: headers.Header.Get("Name"), Version: headers.Header.Get("Version"), Ecosystem: EcosystemPypi, Origin: EcosystemPypi})
+			found.enrichPypi(headers.Header, subdir, location, reader)
+		}
+	})
+
+	if withFiles {
+		result.Files = []*SbomFile{}
+		root.Tree.eachFile("", func(location string, file *File) {
+			if file.IsSymlink() {
+				return
+			}
+			result.Files = append(result.Files, &SbomFile{
+				Path:      location,
+				Algorithm: DigestAlgorithm(file.Digest),
+				Digest:    file.Digest,
+				Size:      file.Size,
+			})
+		})
+		sort.Slice(result.Files, func(left, right int) bool {
+			return result.Files[left].Path < result.Files[right].Path
+		})
+	}
+	for _, found := range result.Packages {
+		sort.Strings(found.Files)
+	}
+	sort.SliceStable(result.Packages, func(left, right int) bool {
+		return result.Packages[left].key() < result.Packages[right].key()
+	})
+	return result
+}
+
+func (it *SbomPackage) enrichConda(record *condaRecord, prefix string) {
+	if len(it.Version) == 0 {
+		it.Version = record.Version
+	}
+	if len(record.Channel) > 0 {
+		it.Origin = record.Channel
+	}
+	if len(record.License) > 0 {
+		it.License = record.License
+	}
+	it.Hashes = make(map[string]string)
+	if len(record.Sha256) > 0 {
+		it.Hashes["SHA-256"] = record.Sha256
+	}
+	if len(record.Md5) > 0 {
+		it.Hashes["MD5"] = record.Md5
+	}
+	for _, file := range record.Files {
+		it.Files = append(it.Files, path.Join(prefix, filepath.ToSlash(file)))
+	}
+}
+
+func (it *SbomPackage) enrichPypi(headers mail.Header, distinfo *Dir, location string, reader SbomReader) {
+	it.License = headers.Get("License-Expression")
+	if len(it.License) == 0 {
+		it.License = headers.Get("License")
+	}
+	if len(it.License) == 0 || strings.Contains(it.License, "\n") {
+		it.License = ""
+		for _, classifier := range headers["Classifier"] {
+			if strings.HasPrefix(classifier, "License ::") {
+				parts := strings.Split(classifier, "::")
+				it.License = strings.TrimSpace(parts[len(parts)-1])
+			}
+		}
+	}
+	it.Homepage = headers.Get("Home-page")
+	direct, ok := distinfo.Files["direct_url.json"]
+	if ok {
+		content, err := reader(direct)
+		origin := &directUrl{}
+		if err == nil && json.Unmarshal(content, origin) == nil && len(origin.Url) > 0 {
+			it.Origin = origin.Url
+		}
+	}
+	record, ok := distinfo.Files["RECORD"]
+	if !ok {
+		return
+	}
+	content, err := reader(record)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), ",", 2)
+		if len(parts[0]) == 0 || strings.HasPrefix(parts[0], "..") {
+			continue
+		}
+		it.Files = append(it.Files, path.Join(location, parts[0]))
+	}
+}
+
+func installedBy(distinfo *Dir, reader SbomReader) string {
+	installer, ok := distinfo.Files["INSTALLER"]
+	if !ok {
+		return ""
+	}
+	content, err := reader(installer)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
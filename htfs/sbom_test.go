@@ -0,0 +1,104 @@
+package htfs_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/robocorp/rcc/hamlet"
+	"github.com/robocorp/rcc/htfs"
+)
+
+const sha256digest = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func sbomRoot() (*htfs.Root, map[string]string) {
+	contents := map[string]string{
+		"golden":  "- name: python\n  version: 3.10.12\n  origin: conda-forge\n- name: requests\n  version: 2.31.0\n  origin: pypi\n",
+		"python":  `{"name": "python", "version": "3.10.12", "channel": "https://conda.anaconda.org/conda-forge", "license": "Python-2.0", "sha256": "feed", "md5": "beef", "files": ["bin/python3"]}`,
+		"pip":     `{"name": "pip", "version": "23.2", "channel": "conda-forge", "license": "MIT"}`,
+		"meta":    "Metadata-Version: 2.1\nName: requests\nVersion: 2.31.0\nHome-page: https://requests.readthedocs.io\nLicense: Apache 2.0\n",
+		"record":  "requests/__init__.py,sha256=abc,100\n../../../bin/normalizer,,\n",
+		"direct":  `{"url": "https://example.com/wheels/requests-2.31.0-py3-none-any.whl"}`,
+		"pipmeta": "Metadata-Version: 2.1\nName: pip\nVersion: 23.2\n",
+		"conda":   "conda\n",
+	}
+	site := testDir("site-packages")
+	requests := testDir("requests-2.31.0.dist-info", testFile("METADATA", "meta", 1), testFile("RECORD", "record", 1), testFile("direct_url.json", "direct", 1))
+	site.Dirs["requests-2.31.0.dist-info"] = requests
+	site.Dirs["pip-23.2.dist-info"] = testDir("pip-23.2.dist-info", testFile("METADATA", "pipmeta", 1), testFile("INSTALLER", "conda", 1))
+	module := testDir("requests", testFile("__init__.py", "cafe", 100))
+	site.Dirs["requests"] = module
+	meta := testDir("conda-meta", testFile("python-3.10.12-h6244533_0.json", "python", 1), testFile("pip-23.2-pyhd8ed1ab_0.json", "pip", 1))
+	root := testRoot(site, meta, testFile("golden-ee.yaml", "golden", 1))
+	root.Blueprint = "0123456789abcdef"
+	bin := testDir("bin", testFile("python3", sha256digest, 42))
+	root.Tree.Dirs["bin"] = bin
+	return root, contents
+}
+
+func TestCanCollectSbomFromCatalogRoot(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	root, contents := sbomRoot()
+	reader := func(file *htfs.File) ([]byte, error) {
+		content, ok := contents[file.Digest]
+		if !ok {
+			return nil, fmt.Errorf("no content for %q", file.Digest)
+		}
+		return []byte(content), nil
+	}
+	sbom := htfs.NewSbom(root, reader, true)
+	wont.Nil(sbom)
+
+	must.Equal(3, len(sbom.Packages))
+	pip, python, requests := sbom.Packages[0], sbom.Packages[1], sbom.Packages[2]
+
+	must.Equal("pip", pip.Name)
+	must.Equal("conda-forge", pip.Origin)
+	must.Equal("MIT", pip.License)
+
+	must.Equal("python", python.Name)
+	must.Equal("3.10.12", python.Version)
+	must.Equal("https://conda.anaconda.org/conda-forge", python.Origin)
+	must.Equal("Python-2.0", python.License)
+	must.Equal("feed", python.Hashes["SHA-256"])
+	must.Equal([]string{"bin/python3"}, python.Files)
+	must.Equal("pkg:conda/python@3.10.12?channel=https%3A%2F%2Fconda.anaconda.org%2Fconda-forge", python.Purl())
+
+	must.Equal("requests", requests.Name)
+	must.Equal(htfs.EcosystemPypi, requests.Ecosystem)
+	must.Equal("Apache 2.0", requests.License)
+	must.Equal("https://requests.readthedocs.io", requests.Homepage)
+	must.Equal("https://example.com/wheels/requests-2.31.0-py3-none-any.whl", requests.Origin)
+	must.Equal([]string{"lib/site-packages/requests/__init__.py"}, requests.Files)
+	must.Equal("pkg:pypi/requests@2.31.0", requests.Purl())
+
+	must.True(len(sbom.Files) > 5)
+	must.Equal("SHA-256", sbom.Files[0].Algorithm)
+	must.Equal("bin/python3", sbom.Files[0].Path)
+	must.Equal("SIPHASH-128", htfs.DigestAlgorithm("cafe"))
+
+	cyclone, err := json.Marshal(sbom.CycloneDX())
+	must.Nil(err)
+	decoded := make(map[string]any)
+	must.Nil(json.Unmarshal(cyclone, &decoded))
+	must.Equal("CycloneDX", decoded["bomFormat"])
+	must.Equal(3+len(sbom.Files), len(decoded["components"].([]any)))
+
+	spdx := sbom.Spdx()
+	must.Equal("SPDX-2.3", spdx.Version)
+	must.Equal(3, len(spdx.Packages))
+	must.Equal("Python-2.0", spdx.Packages[1].LicenseDeclared)
+	must.Equal("LicenseRef-Apache-2.0", spdx.Packages[2].LicenseDeclared)
+	must.Equal("https://example.com/wheels/requests-2.31.0-py3-none-any.whl", spdx.Packages[2].DownloadLocation)
+	contains := 0
+	for _, relation := range spdx.Relationships {
+		if relation.Type == "CONTAINS" {
+			contains++
+		}
+	}
+	must.Equal(2, contains)
+
+	packagesOnly := htfs.NewSbom(root, reader, false)
+	must.Equal(0, len(packagesOnly.Files))
+}
@@ -0,0 +1,304 @@
+package htfs
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robocorp/rcc/common"
+)
+
+var (
+	spdxLicensePattern = regexp.MustCompile(`^[A-Za-z0-9.+-]+$`)
+)
+
+const (
+	SbomCycloneDX = "cyclonedx"
+	SbomSpdx      = "spdx"
+)
+
+type (
+	cdxHash struct {
+		Algorithm string `json:"alg"`
+		Content   string `json:"content"`
+	}
+
+	cdxLicense struct {
+		License map[string]string `json:"license"`
+	}
+
+	cdxProperty struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	cdxReference struct {
+		Type string `json:"type"`
+		Url  string `json:"url"`
+	}
+
+	cdxComponent struct {
+		Type       string          `json:"type"`
+		Reference  string          `json:"bom-ref"`
+		Name       string          `json:"name"`
+		Version    string          `json:"version,omitempty"`
+		Purl       string          `json:"purl,omitempty"`
+		Hashes     []*cdxHash      `json:"hashes,omitempty"`
+		Licenses   []*cdxLicense   `json:"licenses,omitempty"`
+		References []*cdxReference `json:"externalReferences,omitempty"`
+		Properties []*cdxProperty  `json:"properties,omitempty"`
+	}
+
+	cdxDependency struct {
+		Reference string   `json:"ref"`
+		DependsOn []string `json:"dependsOn"`
+	}
+
+	CycloneDX struct {
+		Format       string           `json:"bomFormat"`
+		SpecVersion  string           `json:"specVersion"`
+		SerialNumber string           `json:"serialNumber"`
+		Version      int              `json:"version"`
+		Metadata     map[string]any   `json:"metadata"`
+		Components   []*cdxComponent  `json:"components"`
+		Dependencies []*cdxDependency `json:"dependencies,omitempty"`
+	}
+
+	spdxChecksum struct {
+		Algorithm string `json:"algorithm"`
+		Value     string `json:"checksumValue"`
+	}
+
+	spdxReference struct {
+		Category string `json:"referenceCategory"`
+		Type     string `json:"referenceType"`
+		Locator  string `json:"referenceLocator"`
+	}
+
+	spdxPackage struct {
+		Identifier       string           `json:"SPDXID"`
+		Name             string           `json:"name"`
+		Version          string           `json:"versionInfo"`
+		Supplier         string           `json:"supplier,omitempty"`
+		DownloadLocation string           `json:"downloadLocation"`
+		Homepage         string           `json:"homepage,omitempty"`
+		FilesAnalyzed    bool             `json:"filesAnalyzed"`
+		LicenseConcluded string           `json:"licenseConcluded"`
+		LicenseDeclared  string           `json:"licenseDeclared"`
+		Copyright        string           `json:"copyrightText"`
+		Checksums        []*spdxChecksum  `json:"checksums,omitempty"`
+		References       []*spdxReference `json:"externalRefs,omitempty"`
+	}
+
+	spdxFile struct {
+		Identifier string          `json:"SPDXID"`
+		Name       string          `json:"fileName"`
+		Checksums  []*spdxChecksum `json:"checksums"`
+		License    string          `json:"licenseConcluded"`
+		Copyright  string          `json:"copyrightText"`
+	}
+
+	spdxRelationship struct {
+		Element string `json:"spdxElementId"`
+		Type    string `json:"relationshipType"`
+		Related string `json:"relatedSpdxElement"`
+	}
+
+	Spdx struct {
+		Version       string              `json:"spdxVersion"`
+		DataLicense   string              `json:"dataLicense"`
+		Identifier    string              `json:"SPDXID"`
+		Name          string              `json:"name"`
+		Namespace     string              `json:"documentNamespace"`
+		CreationInfo  map[string]any      `json:"creationInfo"`
+		Packages      []*spdxPackage      `json:"packages"`
+		Files         []*spdxFile         `json:"files,omitempty"`
+		Relationships []*spdxRelationship `json:"relationships"`
+	}
+)
+
+func sbomTool() string {
+	return fmt.Sprintf("rcc-%s", common.Version)
+}
+
+func sbomUuid(it *Sbom) string {
+	digest := common.Digest(it.Blueprint + it.Source)
+	return fmt.Sprintf("%s-%s-%s-%s-%s", digest[:8], digest[8:12], digest[12:16], digest[16:20], digest[20:32])
+}
+
+func spdxIdentifier(prefix, name string) string {
+	clean := strings.Map(func(letter rune) rune {
+		switch {
+		case letter >= 'a' && letter <= 'z', letter >= 'A' && letter <= 'Z', letter >= '0' && letter <= '9', letter == '.', letter == '-':
+			return letter
+		default:
+			return '-'
+		}
+	}, name)
+	return fmt.Sprintf("SPDXRef-%s-%s", prefix, clean)
+}
+
+func spdxAlgorithm(algorithm string) (string, bool) {
+	switch algorithm {
+	case "SHA-256":
+		return "SHA256", true
+	case "MD5":
+		return "MD5", true
+	default:
+		return "", false
+	}
+}
+
+func spdxLicense(license string) string {
+	if spdxLicensePattern.MatchString(license) {
+		return license
+	}
+	return strings.Replace(spdxIdentifier("License", license), "SPDXRef-License-", "LicenseRef-", 1)
+}
+
+func sortedHashes(hashes map[string]string) []string {
+	result := make([]string, 0, len(hashes))
+	for algorithm := range hashes {
+		result = append(result, algorithm)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (it *Sbom) CycloneDX() *CycloneDX {
+	result := &CycloneDX{
+		Format:       "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + sbomUuid(it),
+		Version:      1,
+		Metadata: map[string]any{
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+			"tools":     []map[string]string{{"vendor": "robocorp", "name": "rcc", "version": common.Version}},
+			"component": map[string]any{
+				"type":    "application",
+				"bom-ref": "environment",
+				"name":    it.Name,
+				"version": it.Blueprint,
+				"properties": []*cdxProperty{
+					{Name: "rcc:platform", Value: it.Platform},
+					{Name: "rcc:source", Value: it.Source},
+				},
+			},
+		},
+		Components: make([]*cdxComponent, 0, len(it.Packages)+len(it.Files)),
+	}
+	root := &cdxDependency{Reference: "environment", DependsOn: []string{}}
+	for _, found := range it.Packages {
+		component := &cdxComponent{
+			Type:      "library",
+			Reference: found.Purl(),
+			Name:      found.Name,
+			Version:   found.Version,
+			Purl:      found.Purl(),
+			Properties: []*cdxProperty{
+				{Name: "rcc:ecosystem", Value: found.Ecosystem},
+				{Name: "rcc:origin", Value: found.Origin},
+			},
+		}
+		for _, algorithm := range sortedHashes(found.Hashes) {
+			component.Hashes = append(component.Hashes, &cdxHash{Algorithm: algorithm, Content: found.Hashes[algorithm]})
+		}
+		if len(found.License) > 0 {
+			component.Licenses = []*cdxLicense{{License: map[string]string{"name": found.License}}}
+		}
+		if len(found.Homepage) > 0 {
+			component.References = []*cdxReference{{Type: "website", Url: found.Homepage}}
+		}
+		result.Components = append(result.Components, component)
+		root.DependsOn = append(root.DependsOn, component.Reference)
+	}
+	for _, file := range it.Files {
+		component := &cdxComponent{
+			Type:      "file",
+			Reference: "file:" + file.Path,
+			Name:      file.Path,
+		}
+		if file.Algorithm == "SHA-256" {
+			component.Hashes = []*cdxHash{{Algorithm: file.Algorithm, Content: file.Digest}}
+		} else {
+			component.Properties = []*cdxProperty{{Name: "rcc:digest:" + strings.ToLower(file.Algorithm), Value: file.Digest}}
+		}
+		result.Components = append(result.Components, component)
+	}
+	result.Dependencies = []*cdxDependency{root}
+	return result
+}
+
+func (it *Sbom) Spdx() *Spdx {
+	result := &Spdx{
+		Version:     "SPDX-2.3",
+		DataLicense: "CC0-1.0",
+		Identifier:  "SPDXRef-DOCUMENT",
+		Name:        it.Name,
+		Namespace:   fmt.Sprintf("https://robocorp.com/spdxdocs/rcc/%s-%s", it.Blueprint, sbomUuid(it)),
+		CreationInfo: map[string]any{
+			"created":  time.Now().UTC().Format(time.RFC3339),
+			"creators": []string{"Tool: " + sbomTool()},
+		},
+		Packages:      make([]*spdxPackage, 0, len(it.Packages)),
+		Relationships: []*spdxRelationship{},
+	}
+	files := make(map[string]string)
+	for _, file := range it.Files {
+		entry := &spdxFile{
+			Identifier: spdxIdentifier("File", file.Path),
+			Name:       "./" + file.Path,
+			Checksums:  []*spdxChecksum{},
+			License:    "NOASSERTION",
+			Copyright:  "NOASSERTION",
+		}
+		algorithm, ok := spdxAlgorithm(file.Algorithm)
+		if ok {
+			entry.Checksums = append(entry.Checksums, &spdxChecksum{Algorithm: algorithm, Value: file.Digest})
+		}
+		files[file.Path] = entry.Identifier
+		result.Files = append(result.Files, entry)
+	}
+	for _, found := range it.Packages {
+		entry := &spdxPackage{
+			Identifier:       spdxIdentifier("Package", found.Ecosystem+"-"+found.Name+"-"+found.Version),
+			Name:             found.Name,
+			Version:          found.Version,
+			DownloadLocation: "NOASSERTION",
+			Homepage:         found.Homepage,
+			FilesAnalyzed:    false,
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  "NOASSERTION",
+			Copyright:        "NOASSERTION",
+			References: []*spdxReference{
+				{Category: "PACKAGE-MANAGER", Type: "purl", Locator: found.Purl()},
+			},
+		}
+		if len(found.Origin) > 0 {
+			entry.Supplier = "Organization: " + found.Origin
+		}
+		if strings.Contains(found.Origin, "://") {
+			entry.DownloadLocation = found.Origin
+		}
+		if len(found.License) > 0 {
+			entry.LicenseDeclared = spdxLicense(found.License)
+		}
+		for _, name := range sortedHashes(found.Hashes) {
+			algorithm, ok := spdxAlgorithm(name)
+			if ok {
+				entry.Checksums = append(entry.Checksums, &spdxChecksum{Algorithm: algorithm, Value: found.Hashes[name]})
+			}
+		}
+		result.Packages = append(result.Packages, entry)
+		result.Relationships = append(result.Relationships, &spdxRelationship{Element: "SPDXRef-DOCUMENT", Type: "DESCRIBES", Related: entry.Identifier})
+		for _, path := range found.Files {
+			identifier, ok := files[path]
+			if ok {
+				result.Relationships = append(result.Relationships, &spdxRelationship{Element: entry.Identifier, Type: "CONTAINS", Related: identifier})
+			}
+		}
+	}
+	return result
+}
@@ -34,6 +34,10 @@ func (it *unmanaged) Stage() string {
 	return it.delegate.Stage()
 }
 
+func (it *unmanaged) Isolate(key string) {
+	it.delegate.Isolate(key)
+}
+
 func (it *unmanaged) WriteIdentity([]byte) error {
 	return fmt.Errorf("Not supported yet on virtual holotree.")
 }
@@ -14,10 +14,11 @@ import (
 )
 
 type virtual struct {
-	identity uint64
-	root     *Root
-	registry map[string]string
-	key      string
+	identity  uint64
+	isolation uint64
+	root      *Root
+	registry  map[string]string
+	key       string
 }
 
 func Virtual() MutableLibrary {
@@ -31,10 +32,14 @@ func (it *virtual) Compress() bool {
 }
 
 func (it *virtual) Identity() string {
-	suffix := fmt.Sprintf("%016x", it.identity)
+	suffix := fmt.Sprintf("%016x", it.identity^it.isolation)
 	return fmt.Sprintf("v%s_%sh", common.UserHomeIdentity(), suffix[:14])
 }
 
+func (it *virtual) Isolate(key string) {
+	it.isolation = isolationFor(key)
+}
+
 func (it *virtual) Stage() string {
 	stage := filepath.Join(common.HolotreeLocation(), it.Identity())
 	err := os.MkdirAll(stage, 0o755)
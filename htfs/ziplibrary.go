@@ -2,7 +2,6 @@ package htfs
 
 import (
 	"archive/zip"
-	"compress/gzip"
 	"fmt"
 	"io"
 	"path/filepath"
@@ -59,12 +58,13 @@ func (it *ziplibrary) openFile(filename string) (readable io.Reader, closer Clos
 	if err != nil {
 		return nil, nil, err
 	}
-	wrapper, err := gzip.NewReader(file)
+	wrapper, release, err := BlobReader(file)
 	if err != nil {
+		file.Close()
 		return nil, nil, err
 	}
 	closer = func() error {
-		wrapper.Close()
+		release()
 		return file.Close()
 	}
 	return wrapper, closer, nil
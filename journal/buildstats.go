@@ -28,7 +28,11 @@ type (
 
 	Numbers     []float64
 	BuildEvents []*BuildEvent
-	BuildEvent  struct {
+	LayerTiming struct {
+		Name    string  `json:"name"`
+		Seconds float64 `json:"seconds"`
+	}
+	BuildEvent struct {
 		Version       string `json:"version"`
 		When          int64  `json:"when"`
 		What          string `json:"what"`
@@ -75,6 +79,11 @@ func CurrentBuildEvent() *BuildEvent {
 	return buildevent
 }
 
+func ResetBuildEvent() *BuildEvent {
+	buildevent = NewBuildEvent()
+	return buildevent
+}
+
 func BuildEventStats(label string) {
 	err := serialize(buildevent.finished(label))
 	if err != nil {
@@ -424,6 +433,30 @@ func (it *BuildEvent) finished(label string) *BuildEvent {
 	return it
 }
 
+func (it *BuildEvent) LayerTimings() []*LayerTiming {
+	milestones := []struct {
+		name  string
+		value float64
+	}{
+		{"prepare", it.Prepared},
+		{"micromamba", it.MicromambaDone},
+		{"pip", it.PipDone},
+		{"postinstall", it.PostInstallDone},
+		{"record", it.RecordDone},
+		{"restore", it.RestoreDone},
+	}
+	result := []*LayerTiming{}
+	previous := it.Started
+	for _, milestone := range milestones {
+		if milestone.value <= 0 || milestone.value < previous {
+			continue
+		}
+		result = append(result, &LayerTiming{Name: milestone.name, Seconds: milestone.value - previous})
+		previous = milestone.value
+	}
+	return result
+}
+
 func (it *BuildEvent) Successful() {
 	it.Success = true
 }
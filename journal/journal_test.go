@@ -24,3 +24,24 @@ func TestJounalCanBeCalled(t *testing.T) {
 	second, err := journal.Events()
 	must.True(len(second) > len(events))
 }
+
+func TestBuildEventLayerTimings(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	event := &journal.BuildEvent{
+		Started:        1.0,
+		Prepared:       2.0,
+		MicromambaDone: 12.0,
+		RecordDone:     15.5,
+	}
+	timings := event.LayerTimings()
+	must.Equal(3, len(timings))
+	must.Equal("prepare", timings[0].Name)
+	must.Equal(1.0, timings[0].Seconds)
+	must.Equal("micromamba", timings[1].Name)
+	must.Equal(10.0, timings[1].Seconds)
+	must.Equal("record", timings[2].Name)
+	must.Equal(3.5, timings[2].Seconds)
+
+	must.Equal(0, len((&journal.BuildEvent{Started: 1.0}).LayerTimings()))
+}
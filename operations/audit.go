@@ -0,0 +1,127 @@
+package operations
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/conda"
+	"github.com/robocorp/rcc/htfs"
+	"github.com/robocorp/rcc/pathlib"
+	"github.com/robocorp/rcc/pretty"
+	"github.com/robocorp/rcc/settings"
+)
+
+func OsvDatabaseLocation(location string) string {
+	if len(location) > 0 {
+		return location
+	}
+	return common.OsvLocation()
+}
+
+func ValidFailOn(severity string) bool {
+	if len(severity) == 0 {
+		return true
+	}
+	rank, ok := conda.SeverityRank(severity)
+	return ok && rank > 0
+}
+
+func PrintAuditReport(report *conda.AuditReport, asJson bool) error {
+	if asJson {
+		body, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		common.Stdout("%s\n", body)
+		return nil
+	}
+	common.WaitLogs()
+	if len(report.Findings) > 0 {
+		tabbed := tabwriter.NewWriter(os.Stderr, 2, 4, 2, ' ', 0)
+		tabbed.Write([]byte("Severity\tPackage\tVersion\tOrigin\tAdvisory\tAliases\tFixed in\n"))
+		tabbed.Write([]byte("--------\t-------\t-------\t------\t--------\t-------\t--------\n"))
+		for _, finding := range report.Findings {
+			fixed := strings.Join(finding.Fixed, ", ")
+			if len(fixed) == 0 {
+				fixed = "-"
+			}
+			data := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\n", finding.Severity, finding.Package, finding.Version, finding.Origin, finding.Advisory, strings.Join(finding.Aliases, ", "), fixed)
+			tabbed.Write([]byte(data))
+		}
+		tabbed.Flush()
+	}
+	common.Log("Audited %d packages against %d advisories from %q, found %d vulnerabilities.", report.Packages, report.Advisories, report.Database, len(report.Findings))
+	return nil
+}
+
+func GuardAuditReport(report *conda.AuditReport, failOn string) {
+	if len(failOn) == 0 {
+		return
+	}
+	found := report.AtLeast(failOn)
+	pretty.Guard(len(found) == 0, 7, "Found %d vulnerabilities with %s or higher severity.", len(found), failOn)
+}
+
+func addInstalledPolicyDiagnostics(condafile string, target *common.DiagnosticStatus) {
+	policy := settings.Global.Policy()
+	if policy.IsEmpty() || (len(policy.Banned) == 0 && len(policy.Licenses) == 0) {
+		return
+	}
+	root, err := htfs.ResolveSbomSource(condafile)
+	if err != nil {
+		return
+	}
+	diagnose := target.Diagnose("Policy")
+	notice := diagnose.Fail
+	if policy.AuditOnly() {
+		notice = diagnose.Warning
+	}
+	report := htfs.CheckSbomPolicy(htfs.NewSbom(root, htfs.LibraryBlob, false), policy)
+	for _, violation := range report.Violations {
+		notice(0, "", "Dependency policy [%s] %s: %s", violation.Rule, violation.Subject, violation.Message)
+	}
+	if len(report.Violations) == 0 {
+		diagnose.Ok(0, "Installed packages follow dependency policy.")
+	}
+}
+
+func addAuditDiagnostics(condafile string, target *common.DiagnosticStatus) {
+	diagnose := target.Diagnose("Audit")
+	location := OsvDatabaseLocation("")
+	if !pathlib.Exists(location) {
+		diagnose.Ok(0, "Vulnerability audit skipped, there is no OSV database at %q.", location)
+		return
+	}
+	root, err := htfs.ResolveSbomSource(condafile)
+	if err != nil {
+		diagnose.Ok(0, "Vulnerability audit skipped, environment is not available: %v", err)
+		return
+	}
+	golden, err := root.GoldenMaster(htfs.LibraryBlob)
+	if err != nil {
+		diagnose.Ok(0, "Vulnerability audit skipped, reason: %v", err)
+		return
+	}
+	database, err := conda.LoadVulnerabilityDatabase(location)
+	if err != nil {
+		diagnose.Warning(0, "", "Vulnerability audit failed, reason: %v", err)
+		return
+	}
+	report := conda.ParseWantedDependencies(golden).Audit(database)
+	highest, _ := conda.SeverityRank(conda.SeverityHigh)
+	for _, finding := range report.Findings {
+		notice := diagnose.Warning
+		rank, _ := conda.SeverityRank(finding.Severity)
+		if rank >= highest {
+			notice = diagnose.Fail
+		}
+		notice(0, finding.Link, "Dependency %s %s has %s severity vulnerability %s (fixed in: %s).", finding.Package, finding.Version, finding.Severity, finding.Advisory, strings.Join(finding.Fixed, ", "))
+	}
+	if len(report.Findings) == 0 {
+		diagnose.Ok(0, "No known vulnerabilities in %d dependencies [%d advisories].", report.Packages, report.Advisories)
+	}
+}
@@ -686,6 +686,10 @@ func addRobotDiagnostics(robotfile string, target *common.DiagnosticStatus, prod
 		diagnose.Fail(0, supportGeneralUrl, "About robot.yaml: %v", err)
 	} else {
 		config.Diagnostics(target, production)
+		if production && len(config.CondaConfigFile()) > 0 {
+			addInstalledPolicyDiagnostics(config.CondaConfigFile(), target)
+			addAuditDiagnostics(config.CondaConfigFile(), target)
+		}
 	}
 	addFileDiagnostics(filepath.Dir(robotfile), target)
 }
@@ -163,15 +163,18 @@ func unpack(content []byte, directory string) error {
 	if err != nil {
 		return err
 	}
+	err = DefaultZipLimits().Check(reader.File)
+	if err != nil {
+		return err
+	}
 	success := true
 	for _, entry := range reader.File {
 		if entry.FileInfo().IsDir() {
 			continue
 		}
-		target := filepath.Join(directory, entry.Name)
-		todo := WriteTarget{
-			Source: entry,
-			Target: target,
+		todo, err := guardedTarget(directory, entry, entry.Name)
+		if err != nil {
+			return err
 		}
 		success = todo.Execute() && success
 	}
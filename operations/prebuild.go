@@ -0,0 +1,349 @@
+package operations
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/fail"
+	"github.com/robocorp/rcc/journal"
+	"github.com/robocorp/rcc/pathlib"
+	"github.com/robocorp/rcc/pretty"
+	"github.com/robocorp/rcc/shell"
+)
+
+const (
+	prebuildLogTail = 50
+)
+
+var (
+	prebuildPassthrough      = []string{"--debug", "--trace", "--robocorp", "--sema4ai", "--no-build", "--no-retry-build", "--strict", "--colorless", "--no-temp-management", "--no-pyc-management"}
+	prebuildValuePassthrough = []string{"--controller", "--tag", "--config", "--log-hide"}
+)
+
+type (
+	PrebuildResult struct {
+		Config    string                 `json:"config"`
+		Blueprint string                 `json:"blueprint"`
+		Success   bool                   `json:"success"`
+		Skipped   bool                   `json:"skipped"`
+		Seconds   float64                `json:"seconds"`
+		Layers    []*journal.LayerTiming `json:"layers"`
+		Error     string                 `json:"error,omitempty"`
+		LogTail   []string               `json:"log_tail,omitempty"`
+		Catalogs  []string               `json:"catalogs"`
+	}
+
+	PrebuildReport struct {
+		Started  string            `json:"started"`
+		Parallel int               `json:"parallel"`
+		Total    int               `json:"total"`
+		Failed   int               `json:"failed"`
+		Skipped  int               `json:"skipped"`
+		Seconds  float64           `json:"seconds"`
+		Results  []*PrebuildResult `json:"results"`
+	}
+
+	junitSuites struct {
+		XMLName xml.Name     `xml:"testsuites"`
+		Suites  []junitSuite `xml:"testsuite"`
+	}
+
+	junitSuite struct {
+		Name     string      `xml:"name,attr"`
+		Tests    int         `xml:"tests,attr"`
+		Failures int         `xml:"failures,attr"`
+		Skipped  int         `xml:"skipped,attr"`
+		Time     string      `xml:"time,attr"`
+		Started  string      `xml:"timestamp,attr"`
+		Cases    []junitCase `xml:"testcase"`
+	}
+
+	junitCase struct {
+		Classname  string          `xml:"classname,attr"`
+		Name       string          `xml:"name,attr"`
+		Time       string          `xml:"time,attr"`
+		Properties []junitProperty `xml:"properties>property,omitempty"`
+		Failure    *junitMessage   `xml:"failure,omitempty"`
+		Skipped    *junitMessage   `xml:"skipped,omitempty"`
+		SystemOut  string          `xml:"system-out,omitempty"`
+	}
+
+	junitProperty struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value,attr"`
+	}
+
+	junitMessage struct {
+		Message string `xml:"message,attr"`
+		Body    string `xml:",chardata"`
+	}
+)
+
+func NewPrebuildReport(parallel int) *PrebuildReport {
+	return &PrebuildReport{
+		Started:  time.Now().Format(time.RFC3339),
+		Parallel: parallel,
+		Results:  []*PrebuildResult{},
+	}
+}
+
+func (it *PrebuildReport) Add(results ...*PrebuildResult) {
+	for _, result := range results {
+		it.Results = append(it.Results, result)
+		it.Total += 1
+		switch {
+		case result.Skipped:
+			it.Skipped += 1
+		case !result.Success:
+			it.Failed += 1
+		}
+	}
+}
+
+func (it *PrebuildReport) Catalogs() []string {
+	result := []string{}
+	seen := make(map[string]bool)
+	for _, entry := range it.Results {
+		if !entry.Success {
+			continue
+		}
+		for _, catalog := range entry.Catalogs {
+			if !seen[catalog] {
+				seen[catalog] = true
+				result = append(result, catalog)
+			}
+		}
+	}
+	return result
+}
+
+func (it *PrebuildReport) WriteJson(filename string) error {
+	content, err := json.MarshalIndent(it, "", "  ")
+	if err != nil {
+		return err
+	}
+	return pathlib.WriteFile(filename, content, 0o644)
+}
+
+func LoadPrebuildReport(filename string) (*PrebuildReport, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	report := &PrebuildReport{}
+	err = json.Unmarshal(content, report)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (it *PrebuildReport) junit() *junitSuites {
+	suite := junitSuite{
+		Name:     "holotree prebuild",
+		Tests:    it.Total,
+		Failures: it.Failed,
+		Skipped:  it.Skipped,
+		Time:     fmt.Sprintf("%.3f", it.Seconds),
+		Started:  it.Started,
+		Cases:    make([]junitCase, 0, len(it.Results)),
+	}
+	for _, result := range it.Results {
+		testcase := junitCase{
+			Classname:  "holotree.prebuild",
+			Name:       result.Config,
+			Time:       fmt.Sprintf("%.3f", result.Seconds),
+			Properties: []junitProperty{{Name: "blueprint", Value: result.Blueprint}},
+		}
+		for _, layer := range result.Layers {
+			testcase.Properties = append(testcase.Properties, junitProperty{Name: "layer." + layer.Name, Value: fmt.Sprintf("%.3f", layer.Seconds)})
+		}
+		for _, catalog := range result.Catalogs {
+			testcase.Properties = append(testcase.Properties, junitProperty{Name: "catalog", Value: catalog})
+		}
+		switch {
+		case result.Skipped:
+			testcase.Skipped = &junitMessage{Message: result.Error}
+		case !result.Success:
+			testcase.Failure = &junitMessage{Message: result.Error, Body: strings.Join(result.LogTail, "\n")}
+		default:
+			testcase.SystemOut = fmt.Sprintf("blueprint: %s\ncatalogs: %s\n", result.Blueprint, strings.Join(result.Catalogs, ", "))
+		}
+		suite.Cases = append(suite.Cases, testcase)
+	}
+	return &junitSuites{Suites: []junitSuite{suite}}
+}
+
+func (it *PrebuildReport) WriteJunit(filename string) error {
+	content, err := xml.MarshalIndent(it.junit(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return pathlib.WriteFile(filename, append([]byte(xml.Header), content...), 0o644)
+}
+
+func tailLines(filename string, count int) []string {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return []string{}
+	}
+	lines := strings.Split(strings.TrimRight(string(content), "\r\n"), "\n")
+	if len(lines) > count {
+		lines = lines[len(lines)-count:]
+	}
+	for at, line := range lines {
+		lines[at] = strings.TrimRight(line, "\r")
+	}
+	return lines
+}
+
+func passthroughArguments(arguments []string) []string {
+	result := []string{}
+	for _, flag := range prebuildPassthrough {
+		for _, arg := range arguments {
+			if arg == "--" {
+				break
+			}
+			if strings.EqualFold(arg, flag) {
+				result = append(result, flag)
+				break
+			}
+		}
+	}
+	for at := 0; at < len(arguments) && arguments[at] != "--"; at++ {
+		for _, flag := range prebuildValuePassthrough {
+			switch {
+			case strings.HasPrefix(arguments[at], flag+"="):
+				result = append(result, arguments[at])
+			case arguments[at] == flag && at+1 < len(arguments):
+				result = append(result, flag, arguments[at+1])
+				at++
+			}
+		}
+	}
+	return result
+}
+
+func prebuildArguments(configfile, reportfile string, force bool) []string {
+	args := []string{common.BinRcc(), "holotree", "prebuild", "--report-to", reportfile}
+	if force {
+		args = append(args, "--force")
+	}
+	args = append(args, passthroughArguments(os.Args[1:])...)
+	return append(args, "--", configfile)
+}
+
+func prebuildWorker(at, total int, configfile string, force bool) (result *PrebuildResult) {
+	identity := <-common.Identities
+	workdir := common.ProductTemp()
+	reportfile := filepath.Join(workdir, fmt.Sprintf("prebuild_%s.json", identity))
+	logfile := filepath.Join(workdir, fmt.Sprintf("prebuild_%s.log", identity))
+	defer pathlib.TryRemove("prebuild", reportfile)
+	defer pathlib.TryRemove("prebuild", logfile)
+
+	started := time.Now()
+	result = &PrebuildResult{Config: configfile, Layers: []*journal.LayerTiming{}, Catalogs: []string{}}
+	defer func() {
+		if !result.Success && !result.Skipped && len(result.LogTail) == 0 {
+			result.LogTail = tailLines(logfile, prebuildLogTail)
+		}
+		if result.Seconds == 0 {
+			result.Seconds = time.Since(started).Seconds()
+		}
+	}()
+
+	sink, err := pathlib.Create(logfile)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	args := prebuildArguments(configfile, reportfile, force)
+	common.Debug("%d/%d: starting %q", at+1, total, args)
+	code, err := shell.New(os.Environ(), "", args...).Tracked(sink, false)
+	sink.Close()
+
+	report, failure := LoadPrebuildReport(reportfile)
+	if failure == nil && len(report.Results) == 1 {
+		result = report.Results[0]
+		result.Config = configfile
+		return result
+	}
+	switch {
+	case err != nil:
+		result.Error = fmt.Sprintf("Prebuild process failed with exit code %d, reason: %v", code, err)
+	case failure != nil:
+		result.Error = fmt.Sprintf("Prebuild process did not produce report, reason: %v", failure)
+	default:
+		result.Error = fmt.Sprintf("Prebuild process reported %d results, expected one.", len(report.Results))
+	}
+	return result
+}
+
+func ParallelPrebuild(configurations []string, workers int, force bool) *PrebuildReport {
+	if workers < 1 {
+		workers = 1
+	}
+	common.TimelineBegin("parallel prebuild of %d configurations with %d workers", len(configurations), workers)
+	defer common.TimelineEnd()
+
+	started := time.Now()
+	total := len(configurations)
+	results := make([]*PrebuildResult, total)
+	todo := make(chan int, total)
+	for at := range configurations {
+		todo <- at
+	}
+	close(todo)
+
+	var progress sync.Mutex
+	var group sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for at := range todo {
+				result := prebuildWorker(at, total, configurations[at], force)
+				results[at] = result
+				progress.Lock()
+				switch {
+				case result.Skipped:
+					pretty.Warning("%d/%d: Skipped %q, reason: %s", at+1, total, result.Config, result.Error)
+				case result.Success:
+					pretty.Note("%d/%d: Built %q [%s] in %.1fs.", at+1, total, result.Config, result.Blueprint, result.Seconds)
+				default:
+					pretty.Warning("%d/%d: Failed %q in %.1fs, reason: %s", at+1, total, result.Config, result.Seconds, result.Error)
+				}
+				progress.Unlock()
+			}
+		}()
+	}
+	group.Wait()
+
+	report := NewPrebuildReport(workers)
+	report.Add(results...)
+	report.Seconds = time.Since(started).Seconds()
+	return report
+}
+
+func WritePrebuildReports(report *PrebuildReport, jsonfile, junitfile string) (err error) {
+	defer fail.Around(&err)
+
+	if len(jsonfile) > 0 {
+		err = report.WriteJson(jsonfile)
+		fail.On(err != nil, "Could not write JSON report %q, reason: %v", jsonfile, err)
+		common.Log("Wrote JSON prebuild report to %q.", jsonfile)
+	}
+	if len(junitfile) > 0 {
+		err = report.WriteJunit(junitfile)
+		fail.On(err != nil, "Could not write JUnit report %q, reason: %v", junitfile, err)
+		common.Log("Wrote JUnit prebuild report to %q.", junitfile)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package operations
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robocorp/rcc/hamlet"
+	"github.com/robocorp/rcc/journal"
+)
+
+func samplePrebuildReport() *PrebuildReport {
+	report := NewPrebuildReport(4)
+	report.Add(&PrebuildResult{
+		Config:    "first/conda.yaml",
+		Blueprint: "cafebabe12345678",
+		Success:   true,
+		Seconds:   12.5,
+		Layers:    []*journal.LayerTiming{{Name: "micromamba", Seconds: 10}, {Name: "pip", Seconds: 2.5}},
+		Catalogs:  []string{"aaav12.linux_amd64", "bbbv12.linux_amd64"},
+	}, &PrebuildResult{
+		Config:    "second/conda.yaml",
+		Blueprint: "deadbeef12345678",
+		Error:     "pip failed",
+		LogTail:   []string{"ERROR: no matching distribution", "exit 1"},
+		Catalogs:  []string{"cccv12.linux_amd64"},
+	}, &PrebuildResult{
+		Config:  "third/conda.yaml",
+		Skipped: true,
+		Error:   "no such file",
+	}, &PrebuildResult{
+		Config:   "fourth/conda.yaml",
+		Success:  true,
+		Catalogs: []string{"bbbv12.linux_amd64"},
+	})
+	return report
+}
+
+func TestPrebuildReportCountsAndCatalogs(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	report := samplePrebuildReport()
+	must.Equal(4, report.Total)
+	must.Equal(1, report.Failed)
+	must.Equal(1, report.Skipped)
+	must.Equal([]string{"aaav12.linux_amd64", "bbbv12.linux_amd64"}, report.Catalogs())
+
+	filename := filepath.Join(t.TempDir(), "report.json")
+	must.Nil(report.WriteJson(filename))
+	loaded, err := LoadPrebuildReport(filename)
+	must.Nil(err)
+	must.Equal(4, len(loaded.Results))
+	must.Equal("deadbeef12345678", loaded.Results[1].Blueprint)
+	must.Equal(2, len(loaded.Results[0].Layers))
+}
+
+func TestPrebuildReportAsJunit(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	filename := filepath.Join(t.TempDir(), "junit.xml")
+	must.Nil(samplePrebuildReport().WriteJunit(filename))
+	content, err := os.ReadFile(filename)
+	must.Nil(err)
+	must.True(strings.HasPrefix(string(content), "<?xml"))
+
+	suites := &junitSuites{}
+	must.Nil(xml.Unmarshal(content, suites))
+	must.Equal(1, len(suites.Suites))
+	suite := suites.Suites[0]
+	must.Equal(4, suite.Tests)
+	must.Equal(1, suite.Failures)
+	must.Equal(1, suite.Skipped)
+	must.Equal(4, len(suite.Cases))
+	must.Equal("12.500", suite.Cases[0].Time)
+	must.Nil(suite.Cases[0].Failure)
+	must.Equal("layer.micromamba", suite.Cases[0].Properties[1].Name)
+	wont.Nil(suite.Cases[1].Failure)
+	must.Equal("pip failed", suite.Cases[1].Failure.Message)
+	must.True(strings.Contains(suite.Cases[1].Failure.Body, "no matching distribution"))
+	wont.Nil(suite.Cases[2].Skipped)
+}
+
+func TestCanTailLogFiles(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	filename := filepath.Join(t.TempDir(), "build.log")
+	must.Nil(os.WriteFile(filename, []byte("one\r\ntwo\nthree\nfour\n\n"), 0o644))
+	must.Equal([]string{"three", "four"}, tailLines(filename, 2))
+	must.Equal([]string{"one", "two", "three", "four"}, tailLines(filename, 10))
+	must.Equal(0, len(tailLines(filename+".missing", 10)))
+}
+
+func TestPrebuildArgumentsForwardGlobalFlags(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	original := os.Args
+	defer func() { os.Args = original }()
+	os.Args = []string{"rcc", "holotree", "prebuild", "--debug", "--controller", "citests", "--config=other.yaml", "--tag", "ci", "--log-hide", "secret", "--parallel", "4", "--", "--controller", "ignored"}
+
+	args := prebuildArguments("conda.yaml", "report.json", true)
+	must.Equal([]string{"holotree", "prebuild", "--report-to", "report.json", "--force", "--debug", "--controller", "citests", "--config=other.yaml", "--tag", "ci", "--log-hide", "secret", "--", "conda.yaml"}, args[1:])
+
+	os.Args = []string{"rcc", "holotree", "prebuild", "--controller"}
+	args = prebuildArguments("conda.yaml", "report.json", false)
+	must.Equal([]string{"holotree", "prebuild", "--report-to", "report.json", "--", "conda.yaml"}, args[1:])
+}
@@ -9,7 +9,10 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/robocorp/rcc/cloud"
 	"github.com/robocorp/rcc/common"
@@ -22,6 +25,7 @@ import (
 
 const (
 	X_RCC_RANDOM_IDENTITY = `X-Rcc-Random-Identity`
+	X_RCC_CATALOGS        = `X-Rcc-Catalogs`
 	AUTHORIZATION         = "Authorization"
 )
 
@@ -76,9 +80,9 @@ func downloadMissingEnvironmentParts(count int, origin, catalogName, selection s
 	url := fmt.Sprintf("%s/delta/%s", origin, catalogName)
 
 	body := strings.NewReader(selection)
-	filename = filepath.Join(pathlib.TempDir(), fmt.Sprintf("rccremote_%x.zip", os.Getpid()))
+	filename = filepath.Join(pathlib.TempDir(), fmt.Sprintf("rccremote_%x_%s.zip", os.Getpid(), <-common.Identities))
 
-	client := &http.Client{Transport: settings.Global.ConfiguredHttpTransport()}
+	client := &http.Client{Transport: settings.Global.RemoteHttpTransport()}
 	request, err := http.NewRequest("POST", url, body)
 	fail.On(err != nil, "Failed create request to %q failed, reason: %v", url, err)
 
@@ -138,29 +142,92 @@ func ProtectedImport(filename string) (err error) {
 	return Unzip(common.HololibLocation(), filename, true, false, false)
 }
 
-func PullCatalog(origin, catalogName string, useLock bool) (err error) {
+type OriginProbe struct {
+	Origin       string
+	Fingerprints string
+	Count        int
+	Elapsed      time.Duration
+	Err          error
+}
+
+func probeOrigin(origin, catalogName string) *OriginProbe {
+	started := time.Now()
+	fingerprints, count, err := pullOriginFingerprints(origin, catalogName)
+	return &OriginProbe{
+		Origin:       origin,
+		Fingerprints: fingerprints,
+		Count:        count,
+		Elapsed:      time.Since(started),
+		Err:          err,
+	}
+}
+
+func fastestOrigins(origins []string, catalogName string) []*OriginProbe {
+	probes := make([]*OriginProbe, len(origins))
+	group := sync.WaitGroup{}
+	for at, origin := range origins {
+		group.Add(1)
+		go func(at int, origin string) {
+			defer group.Done()
+			probes[at] = probeOrigin(origin, catalogName)
+		}(at, origin)
+	}
+	group.Wait()
+	sort.SliceStable(probes, func(left, right int) bool {
+		if (probes[left].Err == nil) != (probes[right].Err == nil) {
+			return probes[left].Err == nil
+		}
+		return probes[left].Elapsed < probes[right].Elapsed
+	})
+	for _, probe := range probes {
+		common.Debug("Origin %q probe for %q took %s [error: %v]", probe.Origin, catalogName, probe.Elapsed, probe.Err)
+	}
+	return probes
+}
+
+func pullFromProbe(probe *OriginProbe, catalogName string, useLock bool) (err error) {
 	defer fail.Around(&err)
 
-	common.TimelineBegin("hololib+catalog pull start")
-	defer common.TimelineEnd()
+	fail.On(probe.Err != nil, "%v", probe.Err)
 
-	common.Timeline("pulling %q parts from %q", catalogName, origin)
+	return pullBatches(probe.Origin, catalogName, probe.Fingerprints, useLock)
+}
 
-	unknownSelected, count, err := pullOriginFingerprints(origin, catalogName)
-	fail.On(err != nil, "%v", err)
+func PullCatalogFrom(origins []string, catalogName string, useLock, fastest bool) (origin string, err error) {
+	defer fail.Around(&err)
 
-	filename, err := downloadMissingEnvironmentParts(count, origin, catalogName, unknownSelected)
-	fail.On(err != nil, "%v", err)
+	common.TimelineBegin("hololib+catalog pull start")
+	defer common.TimelineEnd()
 
-	common.Debug("Temporary content based filename is: %q", filename)
-	defer pathlib.TryRemove("temporary", filename)
+	fail.On(len(origins) == 0, "No remote origins given for pulling %q.", catalogName)
 
-	if useLock {
-		err = ProtectedImport(filename)
-	} else {
-		err = Unzip(common.HololibLocation(), filename, true, false, false)
+	var probes []*OriginProbe
+	if fastest && len(origins) > 1 {
+		probes = fastestOrigins(origins, catalogName)
 	}
-	fail.On(err != nil, "Failed to unzip %v to hololib, reason: %v", filename, err)
 
-	return nil
+	failures := make([]string, 0, len(origins))
+	for at, origin := range origins {
+		var probe *OriginProbe
+		if probes != nil {
+			probe = probes[at]
+		} else {
+			common.Timeline("pulling %q parts from %q", catalogName, origin)
+			probe = probeOrigin(origin, catalogName)
+		}
+		err = pullFromProbe(probe, catalogName, useLock)
+		if err == nil {
+			common.Timeline("pulled %q from %q", catalogName, probe.Origin)
+			return probe.Origin, nil
+		}
+		common.Debug("Pull of %q from %q failed, reason: %v", catalogName, probe.Origin, err)
+		failures = append(failures, fmt.Sprintf("%s: %v", probe.Origin, err))
+	}
+	fail.On(len(failures) == 1, "%s", strings.Join(failures, ""))
+	return "", fmt.Errorf("All %d origins failed; %s", len(failures), strings.Join(failures, "; "))
+}
+
+func PullCatalog(origin, catalogName string, useLock bool) error {
+	_, err := PullCatalogFrom(common.SplitOrigins(origin), catalogName, useLock, settings.Global.FastestRemoteOrigin())
+	return err
 }
@@ -0,0 +1,114 @@
+package operations
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/robocorp/rcc/hamlet"
+)
+
+func partsServer(delay time.Duration, status int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		time.Sleep(delay)
+		if !strings.HasPrefix(request.URL.Path, "/parts/") {
+			response.WriteHeader(http.StatusNotFound)
+			return
+		}
+		response.WriteHeader(status)
+		response.Write([]byte(strings.Repeat("f", 64) + "\n"))
+	}))
+}
+
+func TestFastestOriginsAreProbedAndOrdered(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	slow := partsServer(200*time.Millisecond, http.StatusOK)
+	defer slow.Close()
+	fast := partsServer(0, http.StatusOK)
+	defer fast.Close()
+	missing := partsServer(0, http.StatusNotFound)
+	defer missing.Close()
+
+	probes := fastestOrigins([]string{missing.URL, slow.URL, fast.URL}, "cafebabe12345678v12.linux_amd64")
+	must.Equal(3, len(probes))
+	must.Equal(fast.URL, probes[0].Origin)
+	must.Nil(probes[0].Err)
+	must.Equal(1, probes[0].Count)
+	must.Equal(slow.URL, probes[1].Origin)
+	must.Nil(probes[1].Err)
+	must.Equal(missing.URL, probes[2].Origin)
+	wont.Nil(probes[2].Err)
+}
+
+func TestPullCatalogFromNeedsOrigins(t *testing.T) {
+	_, wont := hamlet.Specifications(t)
+
+	_, err := PullCatalogFrom([]string{}, "cafebabe12345678v12.linux_amd64", false, false)
+	wont.Nil(err)
+}
+
+func TestCanSplitPartsIntoBatches(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	batches := splitBatches("", 3)
+	must.Equal(1, len(batches))
+	must.Equal(0, len(batches[0]))
+
+	batches = splitBatches("a\nb\n\nc\nd\ne\n", 2)
+	must.Equal(3, len(batches))
+	must.Equal(2, len(batches[0]))
+	must.Equal(2, len(batches[1]))
+	must.Equal(1, len(batches[2]))
+	must.Equal("e", batches[2][0])
+
+	batches = splitBatches("a\nb", 0)
+	must.Equal(2, len(batches))
+}
+
+func TestCanVerifyPartContentAgainstDigest(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	content := []byte("hello, hololib")
+	expected := fmt.Sprintf("%02x", sha256.Sum256(content))
+	folder := t.TempDir()
+
+	plain := filepath.Join(folder, "plain")
+	must.Nil(os.WriteFile(plain, content, 0o644))
+	ok, err := partMatches(plain, expected)
+	must.Nil(err)
+	must.True(ok)
+
+	buffer := &bytes.Buffer{}
+	writer := gzip.NewWriter(buffer)
+	writer.Write(content)
+	writer.Close()
+	gzipped := filepath.Join(folder, "gzipped")
+	must.Nil(os.WriteFile(gzipped, buffer.Bytes(), 0o644))
+	ok, err = partMatches(gzipped, expected)
+	must.Nil(err)
+	must.True(ok)
+
+	encoder, err := zstd.NewWriter(nil)
+	must.Nil(err)
+	zstded := filepath.Join(folder, "zstded")
+	must.Nil(os.WriteFile(zstded, encoder.EncodeAll(content, nil), 0o644))
+	ok, err = partMatches(zstded, expected)
+	must.Nil(err)
+	must.True(ok)
+
+	corrupted := filepath.Join(folder, "corrupted")
+	must.Nil(os.WriteFile(corrupted, content[:5], 0o644))
+	ok, err = partMatches(corrupted, expected)
+	must.Nil(err)
+	wont.True(ok)
+}
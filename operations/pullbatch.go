@@ -0,0 +1,248 @@
+package operations
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/fail"
+	"github.com/robocorp/rcc/htfs"
+	"github.com/robocorp/rcc/pathlib"
+)
+
+const (
+	pullRetries = 3
+)
+
+var (
+	PullBatchSize = 1000
+	PullWorkers   = 4
+)
+
+type (
+	pullBatch struct {
+		index    int
+		parts    []string
+		filename string
+		err      error
+	}
+)
+
+func splitBatches(fingerprints string, size int) [][]string {
+	if size < 1 {
+		size = 1
+	}
+	parts := []string{}
+	for _, line := range strings.Split(fingerprints, "\n") {
+		flat := strings.TrimSpace(line)
+		if len(flat) > 0 {
+			parts = append(parts, flat)
+		}
+	}
+	batches := [][]string{}
+	for len(parts) > size {
+		batches = append(batches, parts[:size])
+		parts = parts[size:]
+	}
+	return append(batches, parts)
+}
+
+func downloadBatch(origin, catalogName string, batch *pullBatch) {
+	selection := strings.Join(batch.parts, "\n")
+	for attempt := 1; attempt <= pullRetries; attempt++ {
+		batch.filename, batch.err = downloadMissingEnvironmentParts(len(batch.parts), origin, catalogName, selection)
+		if batch.err == nil {
+			return
+		}
+		common.Debug("Batch #%d download attempt %d/%d from %q failed, reason: %v", batch.index+1, attempt, pullRetries, origin, batch.err)
+	}
+}
+
+func pullWorker(origin, catalogName string, todo <-chan *pullBatch, done chan<- *pullBatch) {
+	for batch := range todo {
+		downloadBatch(origin, catalogName, batch)
+		done <- batch
+	}
+}
+
+func pullBatches(origin, catalogName, fingerprints string, useLock bool) (err error) {
+	defer fail.Around(&err)
+
+	batches := splitBatches(fingerprints, PullBatchSize)
+	total := len(batches)
+	workers := PullWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > total {
+		workers = total
+	}
+
+	common.TimelineBegin("pull %d batches with %d workers from %q", total, workers, origin)
+	defer common.TimelineEnd()
+
+	todo := make(chan *pullBatch, total)
+	done := make(chan *pullBatch, total)
+	for at, parts := range batches {
+		todo <- &pullBatch{index: at, parts: parts}
+	}
+	close(todo)
+	for worker := 0; worker < workers; worker++ {
+		go pullWorker(origin, catalogName, todo, done)
+	}
+
+	var failure error
+	catalogZip := ""
+	for completed := 1; completed <= total; completed++ {
+		batch := <-done
+		if batch.err != nil {
+			common.Timeline("batch %d failed [%d parts]", batch.index+1, len(batch.parts))
+			failure = batch.err
+			continue
+		}
+		err = importBatch(batch.filename, false, useLock)
+		if err != nil {
+			common.Timeline("batch %d import failed [%d parts]", batch.index+1, len(batch.parts))
+			failure = err
+			pathlib.TryRemove("temporary", batch.filename)
+			continue
+		}
+		common.Timeline("batch %d imported, %d/%d done [%d parts]", batch.index+1, completed, total, len(batch.parts))
+		if len(catalogZip) == 0 {
+			catalogZip = batch.filename
+			continue
+		}
+		pathlib.TryRemove("temporary", batch.filename)
+	}
+	if len(catalogZip) > 0 {
+		defer pathlib.TryRemove("temporary", catalogZip)
+	}
+	fail.On(failure != nil, "Pull of %q from %q failed (already imported parts are kept, so retry will resume), reason: %v", catalogName, origin, failure)
+	err = importBatch(catalogZip, true, useLock)
+	fail.On(err != nil, "Pull of %q from %q failed on catalog import, reason: %v", catalogName, origin, err)
+	return nil
+}
+
+func importBatch(filename string, withCatalog, useLock bool) (err error) {
+	defer fail.Around(&err)
+
+	if useLock {
+		lockfile := common.HolotreeLock()
+		completed := pathlib.LockWaitMessage(lockfile, "Serialized environment import [holotree lock]")
+		locker, err := pathlib.Locker(lockfile, 30000, common.SharedHolotree)
+		completed()
+		fail.On(err != nil, "Could not get lock for holotree. Quiting.")
+		defer locker.Release()
+	}
+
+	return ImportHololibParts(filename, withCatalog)
+}
+
+func ImportHololibParts(zipfile string, withCatalog bool) (err error) {
+	defer fail.Around(&err)
+
+	common.TimelineBegin("import parts %q [size: %s, catalog: %v]", zipfile, pathlib.HumaneSize(zipfile), withCatalog)
+	defer common.TimelineEnd()
+
+	unzip, err := newUnzipper(zipfile, false)
+	fail.On(err != nil, "%v", err)
+	defer unzip.Close()
+
+	errors := unzip.VerifyShape(HololibZipShape)
+	fail.On(len(errors) > 0, "Zip %q is not valid hololib zip, reason: %v", zipfile, firstProblem(errors))
+	err = unzip.limits.Check(unzip.reader.File)
+	fail.On(err != nil, "Zip %q: %v", zipfile, err)
+
+	library := common.HololibLocation()
+
+	catalogs := make([]*zip.File, 0, 1)
+	for _, entry := range unzip.reader.File {
+		if catalogPattern.MatchString(entry.Name) {
+			catalogs = append(catalogs, entry)
+			continue
+		}
+		target, err := SafeTarget(library, entry.Name)
+		fail.On(err != nil, "%v", err)
+		if pathlib.IsFile(target) {
+			continue
+		}
+		err = extractVerified(entry, target, true)
+		fail.On(err != nil, "%v", err)
+	}
+	if !withCatalog {
+		return nil
+	}
+	fail.On(len(catalogs) == 0, "Zip %q does not contain catalog.", zipfile)
+	for _, entry := range catalogs {
+		target, err := SafeTarget(library, entry.Name)
+		fail.On(err != nil, "%v", err)
+		err = extractVerified(entry, target, false)
+		fail.On(err != nil, "%v", err)
+	}
+	return nil
+}
+
+func firstProblem(errors []error) error {
+	if len(errors) == 0 {
+		return nil
+	}
+	return errors[0]
+}
+
+func extractVerified(entry *zip.File, target string, verify bool) (err error) {
+	defer fail.Around(&err)
+
+	err = pathlib.EnsureDirectoryExists(filepath.Dir(target))
+	fail.On(err != nil, "Could not create directory for %q, reason: %v", target, err)
+
+	partname := fmt.Sprintf("%s.part%s", target, <-common.Identities)
+	defer os.Remove(partname)
+
+	err = (&WriteTarget{Source: entry, Target: partname}).execute()
+	fail.On(err != nil, "Could not extract %q, reason: %v", entry.Name, err)
+
+	expected := strings.ToLower(filepath.Base(target))
+	if verify && len(expected) == 64 {
+		ok, err := partMatches(partname, expected)
+		fail.On(err != nil, "Could not verify %q, reason: %v", entry.Name, err)
+		fail.On(!ok, "Part %q is corrupted, its content does not match its digest.", entry.Name)
+	}
+
+	err = os.Rename(partname, target)
+	fail.On(err != nil, "Could not rename %q to %q, reason: %v", partname, target, err)
+	return nil
+}
+
+func partMatches(filename, expected string) (bool, error) {
+	source, err := os.Open(filename)
+	if err != nil {
+		return false, err
+	}
+	defer source.Close()
+
+	raw := sha256.New()
+	buffered := bufio.NewReader(io.TeeReader(source, raw))
+	magic, _ := buffered.Peek(4)
+	if htfs.DetectBlobFormat(magic) != htfs.BlobRaw {
+		unzipped, release, err := htfs.BlobReader(buffered)
+		if err == nil {
+			defer release()
+			digest := sha256.New()
+			_, err = io.Copy(digest, unzipped)
+			if err == nil && fmt.Sprintf("%02x", digest.Sum(nil)) == expected {
+				return true, nil
+			}
+		}
+	}
+	_, err = io.Copy(io.Discard, buffered)
+	if err != nil {
+		return false, err
+	}
+	return fmt.Sprintf("%02x", raw.Sum(nil)) == expected, nil
+}
@@ -0,0 +1,69 @@
+package operations
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/fail"
+	"github.com/robocorp/rcc/pathlib"
+	"github.com/robocorp/rcc/settings"
+	"github.com/robocorp/rcc/xviper"
+)
+
+func PushHololibZip(origin, zipfile string) (catalogs []string, err error) {
+	defer fail.Around(&err)
+
+	common.TimelineBegin("push %q [size: %s] to %q", zipfile, pathlib.HumaneSize(zipfile), origin)
+	defer common.TimelineEnd()
+
+	errors := VerifyZip(zipfile, HololibZipShape)
+	for _, problem := range errors {
+		common.Debug("- %v", problem)
+	}
+	fail.On(len(errors) > 0, "Zip %q is not valid hololib zip, it has %d problems (see debug output).", zipfile, len(errors))
+	declared, err := ZipCatalogs(zipfile)
+	fail.On(err != nil, "Could not list catalogs of %q, reason: %v", zipfile, err)
+
+	source, err := os.Open(zipfile)
+	fail.On(err != nil, "Could not open %q, reason: %v", zipfile, err)
+	defer source.Close()
+
+	stat, err := source.Stat()
+	fail.On(err != nil, "Could not stat %q, reason: %v", zipfile, err)
+
+	url := fmt.Sprintf("%s/upload/", strings.TrimRight(origin, "/"))
+	request, err := http.NewRequest(http.MethodPost, url, source)
+	fail.On(err != nil, "Failed create request to %q failed, reason: %v", url, err)
+	request.ContentLength = stat.Size()
+
+	request.Header.Add("Content-Type", "application/zip")
+	request.Header.Add(X_RCC_CATALOGS, strings.Join(declared, ","))
+	request.Header.Add("robocorp-installation-id", xviper.TrackingIdentity())
+	request.Header.Add("User-Agent", common.UserAgent())
+	request.Header.Add(X_RCC_RANDOM_IDENTITY, common.RandomIdentifier())
+	authorization, ok := common.RccRemoteAuthorization()
+	if ok {
+		request.Header.Add(AUTHORIZATION, authorization)
+	}
+
+	client := &http.Client{Transport: settings.Global.RemoteHttpTransport()}
+	response, err := client.Do(request)
+	fail.On(err != nil, "Web request to %q failed, reason: %v", url, err)
+	defer response.Body.Close()
+
+	common.Timeline("status %d from POST %q", response.StatusCode, url)
+
+	body, err := io.ReadAll(response.Body)
+	fail.On(err != nil, "Reading response from %q failed, reason: %v", url, err)
+	fail.On(response.StatusCode < 200 || 299 < response.StatusCode, "%s (%s): %s", response.Status, url, strings.TrimSpace(string(body)))
+
+	err = json.Unmarshal(body, &catalogs)
+	fail.On(err != nil, "Could not parse response from %q, reason: %v", url, err)
+
+	return catalogs, nil
+}
@@ -0,0 +1,159 @@
+package operations
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/robocorp/rcc/common"
+)
+
+const (
+	defaultZipEntries = 1000000
+	defaultZipSize    = 64 * 1024 // megabytes
+	defaultZipRatio   = 1000
+	ratioThreshold    = 1024 * 1024
+	megabyte          = 1024 * 1024
+	maxSymlinkLength  = 4096
+)
+
+type ZipLimits struct {
+	Entries   int
+	TotalSize uint64
+	Ratio     uint64
+}
+
+func environmentLimit(name string, fallback uint64) uint64 {
+	value := strings.TrimSpace(os.Getenv(name))
+	if len(value) == 0 {
+		return fallback
+	}
+	parsed, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || parsed == 0 {
+		common.Debug("Ignoring invalid %s value %q, using %d instead.", name, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+func DefaultZipLimits() *ZipLimits {
+	return &ZipLimits{
+		Entries:   int(environmentLimit(common.RCC_ZIP_MAX_ENTRIES, defaultZipEntries)),
+		TotalSize: environmentLimit(common.RCC_ZIP_MAX_SIZE, defaultZipSize) * megabyte,
+		Ratio:     environmentLimit(common.RCC_ZIP_MAX_RATIO, defaultZipRatio),
+	}
+}
+
+func (it *ZipLimits) Check(files []*zip.File) error {
+	entries, total := 0, uint64(0)
+	for _, entry := range files {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		entries += 1
+		if entries > it.Entries {
+			return fmt.Errorf("Zip has more than %d entries (limit can be changed with %s).", it.Entries, common.RCC_ZIP_MAX_ENTRIES)
+		}
+		total += entry.UncompressedSize64
+		if total > it.TotalSize {
+			return fmt.Errorf("Zip expands to more than %d megabytes (limit can be changed with %s).", it.TotalSize/megabyte, common.RCC_ZIP_MAX_SIZE)
+		}
+		if entry.UncompressedSize64 < ratioThreshold {
+			continue
+		}
+		if entry.CompressedSize64 == 0 || entry.UncompressedSize64/entry.CompressedSize64 > it.Ratio {
+			return fmt.Errorf("Zip entry %q has suspicious compression ratio (%d -> %d bytes, limit is %d:1, and can be changed with %s).", entry.Name, entry.CompressedSize64, entry.UncompressedSize64, it.Ratio, common.RCC_ZIP_MAX_RATIO)
+		}
+	}
+	return nil
+}
+
+func unsafeEntry(name, reason string) error {
+	return fmt.Errorf("Unsafe zip entry %q rejected, reason: %s.", name, reason)
+}
+
+func isAbsoluteEntry(name string) bool {
+	if strings.HasPrefix(name, slash) || filepath.IsAbs(name) {
+		return true
+	}
+	return len(name) > 1 && name[1] == ':'
+}
+
+func SafeTarget(directory, name string) (string, error) {
+	if strings.ContainsRune(name, 0) {
+		return "", unsafeEntry(name, "name contains NUL character")
+	}
+	flat := slashed(name)
+	if isAbsoluteEntry(flat) {
+		return "", unsafeEntry(name, "absolute path")
+	}
+	for _, part := range strings.Split(flat, slash) {
+		if part == ".." {
+			return "", unsafeEntry(name, "path traversal")
+		}
+	}
+	target := filepath.Join(directory, filepath.FromSlash(flat))
+	relative, err := filepath.Rel(directory, target)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", unsafeEntry(name, "path escapes target directory")
+	}
+	if relative == "." {
+		return "", unsafeEntry(name, "empty path")
+	}
+	cursor := directory
+	for _, part := range strings.Split(relative, string(filepath.Separator)) {
+		cursor = filepath.Join(cursor, part)
+		stat, err := os.Lstat(cursor)
+		if err != nil {
+			break
+		}
+		if stat.Mode()&os.ModeSymlink != 0 {
+			return "", unsafeEntry(name, fmt.Sprintf("existing symbolic link %q on the path", cursor))
+		}
+	}
+	return target, nil
+}
+
+func checkSymlinkEntry(entry *zip.File, name string) error {
+	if entry.Mode()&os.ModeSymlink == 0 {
+		return nil
+	}
+	if entry.UncompressedSize64 > maxSymlinkLength {
+		return unsafeEntry(entry.Name, "symbolic link target is too long")
+	}
+	source, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	link, err := io.ReadAll(io.LimitReader(source, maxSymlinkLength))
+	if err != nil {
+		return err
+	}
+	destination := slashed(string(link))
+	if isAbsoluteEntry(destination) {
+		return unsafeEntry(entry.Name, "symbolic link points to absolute path")
+	}
+	resolved := path.Join(path.Dir(slashed(name)), destination)
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return unsafeEntry(entry.Name, "symbolic link points outside of target directory")
+	}
+	return nil
+}
+
+func guardedTarget(directory string, entry *zip.File, name string) (*WriteTarget, error) {
+	target, err := SafeTarget(directory, name)
+	if err != nil {
+		return nil, err
+	}
+	err = checkSymlinkEntry(entry, name)
+	if err != nil {
+		return nil, err
+	}
+	return &WriteTarget{Source: entry, Target: target}, nil
+}
@@ -0,0 +1,132 @@
+package operations
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robocorp/rcc/hamlet"
+)
+
+type zipEntry struct {
+	name    string
+	content []byte
+	mode    os.FileMode
+}
+
+func writeCraftedZip(t *testing.T, entries ...zipEntry) string {
+	filename := filepath.Join(t.TempDir(), "crafted.zip")
+	handle, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handle.Close()
+	sink := zip.NewWriter(handle)
+	defer sink.Close()
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		if entry.mode != 0 {
+			header.SetMode(entry.mode)
+		}
+		writer, err := sink.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer.Write(entry.content)
+	}
+	return filename
+}
+
+func plainEntry(name string) zipEntry {
+	return zipEntry{name: name, content: []byte(name)}
+}
+
+func extractCrafted(t *testing.T, limits *ZipLimits, entries ...zipEntry) (string, error) {
+	zipfile := writeCraftedZip(t, entries...)
+	target := filepath.Join(t.TempDir(), "outer", "target")
+	unzip, err := newUnzipper(zipfile, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unzip.Close()
+	if limits != nil {
+		unzip.limits = limits
+	}
+	return filepath.Dir(target), unzip.Extract(target)
+}
+
+func TestSafeTargetRejectsEscapingNames(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	directory := t.TempDir()
+	for _, name := range []string{"../evil", "/abs", "C:/x", `C:\x`, "a/../../x", "a/../b", "", ".", `..\evil`, "a\x00b"} {
+		_, err := SafeTarget(directory, name)
+		wont.Nil(err)
+	}
+	target, err := SafeTarget(directory, "a/b/c.txt")
+	must.Nil(err)
+	must.Equal(filepath.Join(directory, "a", "b", "c.txt"), target)
+}
+
+func TestSafeTargetRejectsExistingSymlinkOnPath(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	directory := t.TempDir()
+	outside := t.TempDir()
+	err := os.Symlink(outside, filepath.Join(directory, "link"))
+	if err != nil {
+		t.Skip("symbolic links not available:", err)
+	}
+	_, err = SafeTarget(directory, "link/evil")
+	wont.Nil(err)
+	_, err = SafeTarget(directory, "other/fine")
+	must.Nil(err)
+}
+
+func TestExtractRejectsMaliciousZips(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	for _, name := range []string{"../evil", "/abs", "C:/x", "a/../../x"} {
+		outer, err := extractCrafted(t, nil, plainEntry("ok.txt"), plainEntry(name))
+		wont.Nil(err)
+		must.True(!isFileOrLink(filepath.Join(outer, "evil")))
+		must.True(!isFileOrLink(filepath.Join(outer, "x")))
+	}
+
+	symlink := zipEntry{name: "link", content: []byte("../../escape"), mode: os.ModeSymlink | 0o777}
+	_, err := extractCrafted(t, nil, symlink)
+	wont.Nil(err)
+
+	absolute := zipEntry{name: "link", content: []byte("/etc"), mode: os.ModeSymlink | 0o777}
+	_, err = extractCrafted(t, nil, absolute)
+	wont.Nil(err)
+
+	_, err = extractCrafted(t, nil, plainEntry("fine/a.txt"), plainEntry("fine/b.txt"))
+	must.Nil(err)
+}
+
+func TestExtractEnforcesZipLimits(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	many := []zipEntry{plainEntry("a"), plainEntry("b"), plainEntry("c")}
+	_, err := extractCrafted(t, &ZipLimits{Entries: 2, TotalSize: megabyte, Ratio: 1000}, many...)
+	wont.Nil(err)
+	_, err = extractCrafted(t, &ZipLimits{Entries: 3, TotalSize: megabyte, Ratio: 1000}, many...)
+	must.Nil(err)
+
+	big := zipEntry{name: "big", content: bytes.Repeat([]byte{'x'}, 2*megabyte)}
+	_, err = extractCrafted(t, &ZipLimits{Entries: 10, TotalSize: megabyte, Ratio: 100000}, big)
+	wont.Nil(err)
+	_, err = extractCrafted(t, &ZipLimits{Entries: 10, TotalSize: 4 * megabyte, Ratio: 10}, big)
+	wont.Nil(err)
+	outer, err := extractCrafted(t, &ZipLimits{Entries: 10, TotalSize: 4 * megabyte, Ratio: 100000}, big)
+	must.Nil(err)
+	must.True(isFileOrLink(filepath.Join(outer, "target", "big")))
+}
+
+func isFileOrLink(filename string) bool {
+	_, err := os.Lstat(filename)
+	return err == nil
+}
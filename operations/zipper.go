@@ -77,10 +77,14 @@ func (it *WriteTarget) execute() error {
 	}
 	defer target.Close()
 	common.Trace("- %v", it.Target)
-	_, err = io.Copy(target, source)
+	limit := int64(it.Source.UncompressedSize64)
+	size, err := io.Copy(target, io.LimitReader(source, limit+1))
 	if err != nil {
 		return err
 	}
+	if size > limit {
+		return fmt.Errorf("Zip entry %q has more content than its declared size %d.", it.Source.Name, limit)
+	}
 	os.Chtimes(it.Target, it.Source.Modified, it.Source.Modified)
 	return nil
 }
@@ -89,6 +93,7 @@ type unzipper struct {
 	reader  *zip.Reader
 	closer  io.Closer
 	flatten bool
+	limits  *ZipLimits
 }
 
 func (it *unzipper) Close() {
@@ -108,6 +113,7 @@ func newPayloadUnzipper(filename string) (*unzipper, error) {
 		reader:  reader,
 		closer:  payloader,
 		flatten: false,
+		limits:  DefaultZipLimits(),
 	}, nil
 }
 
@@ -120,6 +126,7 @@ func newUnzipper(filename string, flatten bool) (*unzipper, error) {
 		reader:  &reader.Reader,
 		closer:  reader,
 		flatten: flatten,
+		limits:  DefaultZipLimits(),
 	}, nil
 }
 
@@ -150,6 +157,22 @@ func (it *unzipper) Explode(workers int, directory string) error {
 	// This is PoC code, for parallel extraction
 	common.Debug("Exploding:")
 
+	err := it.limits.Check(it.reader.File)
+	if err != nil {
+		return err
+	}
+	targets := make([]*WriteTarget, 0, len(it.reader.File))
+	for _, entry := range it.reader.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		todo, err := guardedTarget(directory, entry, entry.Name)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, todo)
+	}
+
 	todo := make(CommandChannel)
 	done := make(CompletedChannel)
 
@@ -157,14 +180,8 @@ func (it *unzipper) Explode(workers int, directory string) error {
 		go loopExecutor(todo, done)
 	}
 
-	for _, entry := range it.reader.File {
-		if entry.FileInfo().IsDir() {
-			continue
-		}
-		todo <- &WriteTarget{
-			Source: entry,
-			Target: filepath.Join(directory, slashed(entry.Name)),
-		}
+	for _, target := range targets {
+		todo <- target
 	}
 
 	close(todo)
@@ -242,16 +259,19 @@ func (it *unzipper) Extract(directory string) error {
 	if limit > 0 {
 		pretty.Note("Flattening path %q out from extracted files.", prefix)
 	}
+	err := it.limits.Check(it.reader.File)
+	if err != nil {
+		return err
+	}
 	for _, entry := range it.reader.File {
 		if entry.FileInfo().IsDir() {
 			continue
 		}
-		target := filepath.Join(directory, slashed(entry.Name)[limit:])
-		todo := WriteTarget{
-			Source: entry,
-			Target: target,
+		todo, err := guardedTarget(directory, entry, slashed(entry.Name)[limit:])
+		if err != nil {
+			return err
 		}
-		err := todo.execute()
+		err = todo.execute()
 		if err != nil {
 			return fmt.Errorf("Problem while extracting zip, reason: %v", err)
 		}
@@ -388,6 +408,22 @@ func VerifyZip(zipfile string, verifier Verifier) []error {
 	return unzip.VerifyShape(verifier)
 }
 
+func ZipCatalogs(zipfile string) ([]string, error) {
+	unzip, err := newUnzipper(zipfile, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unzip.Close()
+
+	result := make([]string, 0, 4)
+	for _, entry := range unzip.reader.File {
+		if catalogPattern.MatchString(entry.Name) {
+			result = append(result, filepath.Base(slashed(entry.Name)))
+		}
+	}
+	return set.Set(result), nil
+}
+
 func Unzip(directory, zipfile string, force, temporary, flatten bool) error {
 	common.TimelineBegin("unzip %q [size: %s] to %q", zipfile, pathlib.HumaneSize(zipfile), directory)
 	defer common.TimelineEnd()
@@ -1,6 +1,9 @@
 package operations
 
 import (
+	"archive/zip"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/robocorp/rcc/hamlet"
@@ -18,3 +21,43 @@ func TestCanConvertSlashes(t *testing.T) {
 	must.Equal(3, len(wintestpath))
 	must.Equal(slashed(wintestpath), nixtestpath)
 }
+
+func writeTestZip(t *testing.T, names ...string) string {
+	filename := filepath.Join(t.TempDir(), "hololib.zip")
+	handle, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handle.Close()
+	sink := zip.NewWriter(handle)
+	defer sink.Close()
+	for _, name := range names {
+		writer, err := sink.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer.Write([]byte(name))
+	}
+	return filename
+}
+
+func TestCanListCatalogsAndVerifyHololibZip(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	valid := writeTestZip(t,
+		"catalog/cafebabe12345678v12.linux_amd64",
+		"catalog/deadbeef12345678v12.windows_amd64",
+		"library/ab/cd/ef/abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789")
+	must.Equal(0, len(VerifyZip(valid, HololibZipShape)))
+	catalogs, err := ZipCatalogs(valid)
+	must.Nil(err)
+	must.Equal(2, len(catalogs))
+	must.Equal("cafebabe12345678v12.linux_amd64", catalogs[0])
+	must.Equal("deadbeef12345678v12.windows_amd64", catalogs[1])
+
+	invalid := writeTestZip(t, "catalog/cafebabe12345678v12.linux_amd64", "robot.yaml")
+	must.Equal(1, len(VerifyZip(invalid, HololibZipShape)))
+
+	_, err = ZipCatalogs(filepath.Join(t.TempDir(), "missing.zip"))
+	wont.Nil(err)
+}
@@ -0,0 +1,17 @@
+//go:build darwin || linux || !windows
+// +build darwin linux !windows
+
+package pathlib
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+func DiskFree(location string) (int64, error) {
+	var stat unix.Statfs_t
+	err := unix.Statfs(location, &stat)
+	if err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
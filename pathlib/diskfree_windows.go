@@ -0,0 +1,21 @@
+//go:build windows
+// +build windows
+
+package pathlib
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+func DiskFree(location string) (int64, error) {
+	directory, err := windows.UTF16PtrFromString(location)
+	if err != nil {
+		return 0, err
+	}
+	var available, total, free uint64
+	err = windows.GetDiskFreeSpaceEx(directory, &available, &total, &free)
+	if err != nil {
+		return 0, err
+	}
+	return int64(available), nil
+}
@@ -6,6 +6,8 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/robocorp/rcc/common"
@@ -153,6 +155,32 @@ func HumaneSizer(rawsize int64) (float64, string) {
 	return gigas, "G"
 }
 
+func ParseSize(text string) (int64, error) {
+	clean := strings.ToUpper(strings.TrimSpace(text))
+	clean = strings.TrimSuffix(strings.TrimSuffix(clean, "B"), "I")
+	multiplier := int64(1)
+	for at, suffix := range []string{"K", "M", "G", "T"} {
+		if strings.HasSuffix(clean, suffix) {
+			multiplier = int64(1) << (10 * (at + 1))
+			clean = strings.TrimSpace(strings.TrimSuffix(clean, suffix))
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(clean, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("Invalid size %q, expected something like 500MB or 50GB.", text)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+func DirectorySize(directory string) int64 {
+	total := int64(0)
+	Walk(directory, IgnoreNothing, func(fullpath, relative string, details os.FileInfo) {
+		total += details.Size()
+	})
+	return total
+}
+
 func HumaneSize(pathname string) string {
 	rawsize, ok := Size(pathname)
 	if !ok {
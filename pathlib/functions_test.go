@@ -28,3 +28,24 @@ func TestCanTestIfSomethingIsDirectory(t *testing.T) {
 	must.True(pathlib.IsDir("testdata"))
 	wont.True(pathlib.IsDir("functions_test.go"))
 }
+
+func TestCanParseHumaneSizes(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	for text, expected := range map[string]int64{
+		"1234":   1234,
+		"10K":    10 * 1024,
+		"500MB":  500 * 1024 * 1024,
+		"50 GB":  50 * 1024 * 1024 * 1024,
+		"1.5GiB": 1536 * 1024 * 1024,
+		"2t":     2 * 1024 * 1024 * 1024 * 1024,
+	} {
+		size, err := pathlib.ParseSize(text)
+		must.Nil(err)
+		must.Equal(expected, size)
+	}
+	for _, text := range []string{"", "GB", "lots", "-5MB"} {
+		_, err := pathlib.ParseSize(text)
+		wont.Nil(err)
+	}
+}
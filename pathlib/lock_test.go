@@ -0,0 +1,61 @@
+package pathlib_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robocorp/rcc/hamlet"
+	"github.com/robocorp/rcc/pathlib"
+)
+
+func TestSharedLocksCoexistButExcludeExclusiveLock(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	lockfile := filepath.Join(t.TempDir(), "test.lck")
+	first, err := pathlib.SharedLocker(lockfile, 100, false)
+	must.Nil(err)
+	second, err := pathlib.SharedLocker(lockfile, 100, false)
+	must.Nil(err)
+
+	acquired := make(chan pathlib.Releaser)
+	go func() {
+		exclusive, err := pathlib.Locker(lockfile, 1000, false)
+		if err == nil {
+			acquired <- exclusive
+		}
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("exclusive lock acquired while shared locks are held")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	must.Nil(first.Release())
+	must.Nil(second.Release())
+
+	select {
+	case exclusive := <-acquired:
+		wont.Nil(exclusive)
+		must.Nil(exclusive.Release())
+	case <-time.After(5 * time.Second):
+		t.Fatal("exclusive lock was not acquired after shared locks were released")
+	}
+}
+
+func TestTryLockerDoesNotWaitForOtherHolders(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	lockfile := filepath.Join(t.TempDir(), "test.lck")
+	shared, err := pathlib.SharedLocker(lockfile, 100, false)
+	must.Nil(err)
+
+	_, err = pathlib.TryLocker(lockfile, false)
+	wont.Nil(err)
+
+	must.Nil(shared.Release())
+	exclusive, err := pathlib.TryLocker(lockfile, false)
+	must.Nil(err)
+	must.Nil(exclusive.Release())
+}
@@ -11,6 +11,18 @@ import (
 )
 
 func Locker(filename string, trycount int, sharedLocation bool) (Releaser, error) {
+	return locker(filename, sharedLocation, syscall.LOCK_EX)
+}
+
+func SharedLocker(filename string, trycount int, sharedLocation bool) (Releaser, error) {
+	return locker(filename, sharedLocation, syscall.LOCK_SH)
+}
+
+func TryLocker(filename string, sharedLocation bool) (Releaser, error) {
+	return locker(filename, sharedLocation, syscall.LOCK_EX|syscall.LOCK_NB)
+}
+
+func locker(filename string, sharedLocation bool, mode int) (Releaser, error) {
 	if common.WarrantyVoided() || Lockless {
 		return Fake(), nil
 	}
@@ -37,8 +49,9 @@ func Locker(filename string, trycount int, sharedLocation bool) (Releaser, error
 	if err != nil {
 		return nil, err
 	}
-	err = syscall.Flock(int(file.Fd()), int(syscall.LOCK_EX))
+	err = syscall.Flock(int(file.Fd()), mode)
 	if err != nil {
+		file.Close()
 		return nil, err
 	}
 	lockpid := LockpidFor(filename)
@@ -7,12 +7,14 @@ import (
 	"os"
 	"syscall"
 	"time"
+	"unsafe"
 
 	"github.com/robocorp/rcc/common"
 )
 
 const (
-	LOCKFILE_EXCLUSIVE_LOCK = 2
+	LOCKFILE_FAIL_IMMEDIATELY = 1
+	LOCKFILE_EXCLUSIVE_LOCK   = 2
 )
 
 // https://docs.microsoft.com/en-us/windows/win32/api/fileapi/nf-fileapi-lockfile
@@ -21,6 +23,7 @@ const (
 var (
 	kernel32, _   = syscall.LoadLibrary("kernel32.dll")
 	lockFile, _   = syscall.GetProcAddress(kernel32, "LockFile")
+	lockFileEx, _ = syscall.GetProcAddress(kernel32, "LockFileEx")
 	unlockFile, _ = syscall.GetProcAddress(kernel32, "UnlockFile")
 )
 
@@ -29,6 +32,22 @@ type filehandle interface {
 }
 
 func Locker(filename string, trycount int, sharedLocation bool) (Releaser, error) {
+	return locker(filename, trycount, sharedLocation, func(file *os.File) (bool, error) {
+		return trylock(lockFile, file)
+	})
+}
+
+func SharedLocker(filename string, trycount int, sharedLocation bool) (Releaser, error) {
+	return locker(filename, trycount, sharedLocation, trysharedlock)
+}
+
+func TryLocker(filename string, sharedLocation bool) (Releaser, error) {
+	return locker(filename, 0, sharedLocation, func(file *os.File) (bool, error) {
+		return trylock(lockFile, file)
+	})
+}
+
+func locker(filename string, trycount int, sharedLocation bool, attempt func(*os.File) (bool, error)) (Releaser, error) {
 	if common.WarrantyVoided() || Lockless {
 		return Fake(), nil
 	}
@@ -69,8 +88,9 @@ func Locker(filename string, trycount int, sharedLocation bool) (Releaser, error
 	}
 	for {
 		trycount -= 1
-		success, err := trylock(lockFile, file)
+		success, err := attempt(file)
 		if err != nil && trycount < 0 {
+			file.Close()
 			return nil, err
 		}
 		if success {
@@ -106,3 +126,21 @@ func trylock(tool uintptr, identity filehandle) (bool, error) {
 	}
 	return true, nil
 }
+
+func trysharedlock(identity *os.File) (bool, error) {
+	handle := syscall.Handle(identity.Fd())
+	overlapped := new(syscall.Overlapped)
+	primary, _, err := syscall.Syscall6(
+		lockFileEx,
+		6,
+		uintptr(handle),
+		uintptr(LOCKFILE_FAIL_IMMEDIATELY),
+		uintptr(0),
+		uintptr(1),
+		uintptr(0),
+		uintptr(unsafe.Pointer(overlapped)))
+	if primary == 0 {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,170 @@
+package remotree
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/htfs"
+)
+
+type (
+	CatalogSummary struct {
+		Name      string     `json:"name"`
+		Blueprint string     `json:"blueprint"`
+		Platform  string     `json:"platform"`
+		Files     uint64     `json:"files"`
+		Bytes     uint64     `json:"bytes"`
+		Modified  time.Time  `json:"modified"`
+		LastUsed  *time.Time `json:"last_used,omitempty"`
+	}
+
+	summaryCache struct {
+		sync.Mutex
+		known map[string]*CatalogSummary
+	}
+)
+
+var (
+	summaries = &summaryCache{known: make(map[string]*CatalogSummary)}
+)
+
+func catalogBlueprint(catalog string) string {
+	parts := strings.SplitN(catalog, "v", 2)
+	return parts[0]
+}
+
+func treeSize(tree *htfs.Dir) (files uint64, bytes uint64) {
+	for _, dir := range tree.Dirs {
+		subfiles, subbytes := treeSize(dir)
+		files += subfiles
+		bytes += subbytes
+	}
+	for _, file := range tree.Files {
+		files += 1
+		bytes += uint64(file.Size)
+	}
+	return files, bytes
+}
+
+func lastUsedTimes() map[string]time.Time {
+	result := make(map[string]time.Time)
+	entries, err := os.ReadDir(common.HololibUsageLocation())
+	if err != nil {
+		return result
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		name := entry.Name()
+		base := name[:len(name)-len(filepath.Ext(name))]
+		previous, ok := result[base]
+		if !ok || info.ModTime().After(previous) {
+			result[base] = info.ModTime()
+		}
+	}
+	return result
+}
+
+func (it *summaryCache) summary(catalog string) (*CatalogSummary, bool) {
+	stat, err := os.Stat(filepath.Join(common.HololibCatalogLocation(), catalog))
+	if err != nil {
+		return nil, false
+	}
+	it.Lock()
+	known, ok := it.known[catalog]
+	it.Unlock()
+	if ok && known.Modified.Equal(stat.ModTime()) {
+		return known, true
+	}
+	root, err := loadSingleCatalog(catalog)
+	if err != nil {
+		common.Debug("Catalog summary for %q failed, reason: %v", catalog, err)
+		return nil, false
+	}
+	files, bytes := treeSize(root.Tree)
+	created := &CatalogSummary{
+		Name:      catalog,
+		Blueprint: catalogBlueprint(catalog),
+		Platform:  CatalogPlatform(catalog),
+		Files:     files,
+		Bytes:     bytes,
+		Modified:  stat.ModTime(),
+	}
+	it.Lock()
+	it.known[catalog] = created
+	it.Unlock()
+	return created, true
+}
+
+func CatalogSummaries(visible func(string) bool) []*CatalogSummary {
+	used := lastUsedTimes()
+	result := make([]*CatalogSummary, 0, 20)
+	for _, catalog := range htfs.CatalogNames() {
+		if !visible(catalog) {
+			continue
+		}
+		known, ok := summaries.summary(catalog)
+		if !ok {
+			continue
+		}
+		entry := *known
+		lastUsed, ok := used[entry.Blueprint]
+		if ok {
+			entry.LastUsed = &lastUsed
+		}
+		result = append(result, &entry)
+	}
+	return result
+}
+
+func makeCatalogsHandler(keeper Gatekeeper) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		defer common.Stopwatch("Catalog listing took").Debug()
+		if request.Method != http.MethodGet {
+			response.WriteHeader(http.StatusMethodNotAllowed)
+			common.Trace("Catalogs: rejecting request %q.", request.Method)
+			return
+		}
+		status := keeper.Authorize(request, ScopeRead, "")
+		if status != http.StatusOK {
+			deny(response, status)
+			return
+		}
+		visible := func(catalog string) bool {
+			return keeper.Permits(request, ScopeRead, catalog)
+		}
+		content, err := json.MarshalIndent(CatalogSummaries(visible), "", "  ")
+		if err != nil {
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		response.Header().Add("Content-Type", "application/json")
+		response.WriteHeader(http.StatusOK)
+		response.Write(content)
+	}
+}
+
+func makeHealthHandler() http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		status, state := http.StatusOK, "ok"
+		_, err := os.Stat(common.HololibCatalogLocation())
+		if err != nil {
+			status, state = http.StatusServiceUnavailable, "hololib catalog location is not available"
+		}
+		content, _ := json.Marshal(map[string]string{
+			"status":  state,
+			"version": common.Version,
+		})
+		response.Header().Add("Content-Type", "application/json")
+		response.WriteHeader(status)
+		response.Write(content)
+	}
+}
@@ -23,7 +23,19 @@ func isSelfRequest(request *http.Request) bool {
 	return ok && len(identity) > 0 && identity[0] == common.RandomIdentifier()
 }
 
-func makeDeltaHandler(queries Partqueries) http.HandlerFunc {
+type lenientWriter struct {
+	sink   io.Writer
+	failed error
+}
+
+func (it *lenientWriter) Write(blob []byte) (int, error) {
+	if it.failed == nil {
+		_, it.failed = it.sink.Write(blob)
+	}
+	return len(blob), nil
+}
+
+func makeDeltaHandler(keeper Gatekeeper, queries Partqueries, deltas *DeltaCache, stream bool) http.HandlerFunc {
 	return func(response http.ResponseWriter, request *http.Request) {
 		catalog := filepath.Base(request.URL.Path)
 		defer common.Stopwatch("Delta of catalog %q took", catalog).Debug()
@@ -32,6 +44,11 @@ func makeDeltaHandler(queries Partqueries) http.HandlerFunc {
 			common.Trace("Delta: rejecting request %q for catalog %q.", request.Method, catalog)
 			return
 		}
+		status := keeper.Authorize(request, ScopeRead, catalog)
+		if status != http.StatusOK {
+			deny(response, status)
+			return
+		}
 		if isSelfRequest(request) {
 			response.WriteHeader(http.StatusConflict)
 			common.Trace("Delta: rejecting /SELF/ request for catalog %q.", catalog)
@@ -78,10 +95,30 @@ func makeDeltaHandler(queries Partqueries) http.HandlerFunc {
 			}
 		}
 
-		partfile, err := exportMissing(catalog, approved)
+		var streamed *lenientWriter
+		builder := func(filename string) error {
+			if !stream {
+				return exportMissingToFile(catalog, approved, filename, nil)
+			}
+			metrics.Add(metricDeltaCache, Labels("result", "stream"), 1)
+			response.Header().Set("Content-Type", "application/zip")
+			response.WriteHeader(http.StatusOK)
+			streamed = &lenientWriter{sink: response}
+			return exportMissingToFile(catalog, approved, filename, streamed)
+		}
+		partfile, release, err := deltas.Acquire(deltaIdentity(catalog, approved), builder)
+		if streamed != nil && streamed.failed != nil {
+			common.Log("DELTA: streaming %q failed, reason: %v", catalog, streamed.failed)
+		}
 		if err != nil {
 			common.Debug("DELTA: error %v", err)
-			response.WriteHeader(http.StatusInternalServerError)
+			if streamed == nil {
+				response.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+		defer release()
+		if streamed != nil {
 			return
 		}
 
@@ -99,49 +136,49 @@ func tempDir() (string, bool) {
 	return fullpath, true
 }
 
-func exportMissing(catalog string, missing []string) (result string, err error) {
-	defer fail.Around(&err)
-
-	tempdir, _ := tempDir()
-	identity := common.Digest(strings.Join(missing, "\n"))
-	filename := filepath.Join(tempdir, fmt.Sprintf("%s_parts.zip", identity))
-	if pathlib.IsFile(filename) {
-		common.Debug("Using existing cache file %q [size: %s]", filename, pathlib.HumaneSize(filename))
-		return filename, nil
+func deltaIdentity(catalog string, missing []string) string {
+	stamp := "missing"
+	stat, err := os.Stat(filepath.Join(common.HololibCatalogLocation(), catalog))
+	if err == nil {
+		stamp = fmt.Sprintf("%d:%d", stat.Size(), stat.ModTime().UnixNano())
 	}
-
-	tempfile := filepath.Join(tempdir, fmt.Sprintf("%s_%x_build.zip", identity, os.Getppid()))
-	err = exportMissingToFile(catalog, missing, tempfile)
-	fail.On(err != nil, "%v", err)
-
-	err = os.Rename(tempfile, filename)
-	fail.On(err != nil, "%v", err)
-
-	common.Debug("Created cache file %q [size: %s]", filename, pathlib.HumaneSize(filename))
-	return filename, nil
+	return common.Digest(catalog + "\n" + stamp + "\n" + strings.Join(missing, "\n"))
 }
 
-func exportMissingToFile(catalog string, missing []string, filename string) (err error) {
+func exportMissingToFile(catalog string, missing []string, filename string, tee io.Writer) (err error) {
 	defer fail.Around(&err)
 
 	handle, err := pathlib.Create(filename)
 	fail.On(err != nil, "Could not create export file %q, reason: %v", filename, err)
 	defer handle.Close()
 
-	sink := zip.NewWriter(handle)
-	defer sink.Close()
+	var sink io.Writer = handle
+	if tee != nil {
+		sink = io.MultiWriter(handle, tee)
+	}
+	err = exportMissingTo(catalog, missing, sink)
+	fail.On(err != nil, "%v", err)
+
+	return handle.Sync()
+}
+
+func exportMissingTo(catalog string, missing []string, sink io.Writer) (err error) {
+	defer fail.Around(&err)
+
+	zipper := zip.NewWriter(sink)
+	defer zipper.Close()
 
 	for _, member := range missing {
 		relative := htfs.RelativeDefaultLocation(member)
 		fullpath := htfs.ExactDefaultLocation(member)
-		err = operations.ZipAppend(sink, fullpath, relative)
+		err = operations.ZipAppend(zipper, fullpath, relative)
 		fail.On(err != nil, "Could not zip file %q, reason: %v", fullpath, err)
 	}
 
 	fullpath := filepath.Join(common.HololibCatalogLocation(), catalog)
 	relative, err := filepath.Rel(common.HololibLocation(), fullpath)
 	fail.On(err != nil, "Could not get relative path for catalog %q, reason: %v", fullpath, err)
-	err = operations.ZipAppend(sink, fullpath, relative)
+	err = operations.ZipAppend(zipper, fullpath, relative)
 	fail.On(err != nil, "Could not zip catalog %q, reason: %v", fullpath, err)
-	return nil
+	return zipper.Close()
 }
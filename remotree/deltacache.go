@@ -0,0 +1,170 @@
+package remotree
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/pathlib"
+)
+
+type (
+	Builder func(filename string) error
+	Release func()
+
+	deltaEntry struct {
+		identity string
+		filename string
+		size     int64
+		refs     int
+		element  *list.Element
+	}
+
+	deltaBuild struct {
+		done    chan bool
+		waiters int
+		entry   *deltaEntry
+		err     error
+	}
+
+	DeltaCache struct {
+		sync.Mutex
+		folder   string
+		budget   int64
+		used     int64
+		entries  map[string]*deltaEntry
+		recent   *list.List
+		building map[string]*deltaBuild
+	}
+)
+
+func NewDeltaCache(folder string, budget int64) *DeltaCache {
+	return &DeltaCache{
+		folder:   folder,
+		budget:   budget,
+		entries:  make(map[string]*deltaEntry),
+		recent:   list.New(),
+		building: make(map[string]*deltaBuild),
+	}
+}
+
+func (it *DeltaCache) Used() int64 {
+	it.Lock()
+	defer it.Unlock()
+	return it.used
+}
+
+func (it *DeltaCache) Len() int {
+	it.Lock()
+	defer it.Unlock()
+	return len(it.entries)
+}
+
+func (it *DeltaCache) releaser(entry *deltaEntry) Release {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			it.Lock()
+			defer it.Unlock()
+			entry.refs -= 1
+			it.evict()
+		})
+	}
+}
+
+func (it *DeltaCache) evict() {
+	cursor := it.recent.Back()
+	for it.used > it.budget && cursor != nil {
+		entry := cursor.Value.(*deltaEntry)
+		cursor = cursor.Prev()
+		if entry.refs > 0 {
+			continue
+		}
+		err := pathlib.TryRemove("delta", entry.filename)
+		if err != nil {
+			common.Debug("Could not evict delta %q, reason: %v", entry.filename, err)
+			continue
+		}
+		it.recent.Remove(entry.element)
+		delete(it.entries, entry.identity)
+		it.used -= entry.size
+		metrics.Add(metricDeltaCache, Labels("result", "evict"), 1)
+		common.Debug("Evicted delta cache file %q [size: %d]", entry.filename, entry.size)
+	}
+	metrics.Set(metricDeltaCacheBytes, "", float64(it.used))
+}
+
+func (it *DeltaCache) Acquire(identity string, builder Builder) (string, Release, error) {
+	it.Lock()
+	entry, ok := it.entries[identity]
+	if ok {
+		entry.refs += 1
+		it.recent.MoveToFront(entry.element)
+		it.Unlock()
+		metrics.Add(metricDeltaCache, Labels("result", "hit"), 1)
+		common.Debug("Using existing cache file %q [size: %d]", entry.filename, entry.size)
+		return entry.filename, it.releaser(entry), nil
+	}
+	build, ok := it.building[identity]
+	if ok {
+		build.waiters += 1
+		it.Unlock()
+		metrics.Add(metricDeltaCache, Labels("result", "coalesced"), 1)
+		<-build.done
+		if build.err != nil {
+			return "", nil, build.err
+		}
+		return build.entry.filename, it.releaser(build.entry), nil
+	}
+	build = &deltaBuild{done: make(chan bool)}
+	it.building[identity] = build
+	it.Unlock()
+
+	metrics.Add(metricDeltaCache, Labels("result", "miss"), 1)
+	filename, size, err := it.build(identity, builder)
+
+	it.Lock()
+	defer it.Unlock()
+	defer close(build.done)
+	delete(it.building, identity)
+	if err != nil {
+		build.err = err
+		return "", nil, err
+	}
+	entry = &deltaEntry{
+		identity: identity,
+		filename: filename,
+		size:     size,
+		refs:     1 + build.waiters,
+	}
+	entry.element = it.recent.PushFront(entry)
+	it.entries[identity] = entry
+	it.used += size
+	build.entry = entry
+	it.evict()
+	common.Debug("Created cache file %q [size: %d]", filename, size)
+	return filename, it.releaser(entry), nil
+}
+
+func (it *DeltaCache) build(identity string, builder Builder) (string, int64, error) {
+	filename := filepath.Join(it.folder, fmt.Sprintf("%s_parts.zip", identity))
+	tempfile := filepath.Join(it.folder, fmt.Sprintf("%s_%x_build.zip", identity, os.Getpid()))
+	err := builder(tempfile)
+	if err != nil {
+		pathlib.TryRemove("delta", tempfile)
+		return "", 0, err
+	}
+	err = os.Rename(tempfile, filename)
+	if err != nil {
+		pathlib.TryRemove("delta", tempfile)
+		return "", 0, err
+	}
+	stat, err := os.Stat(filename)
+	if err != nil {
+		return "", 0, err
+	}
+	return filename, stat.Size(), nil
+}
@@ -0,0 +1,135 @@
+package remotree_test
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/robocorp/rcc/hamlet"
+	"github.com/robocorp/rcc/remotree"
+)
+
+func sizedBuilder(size int, counter *int32) remotree.Builder {
+	return func(filename string) error {
+		atomic.AddInt32(counter, 1)
+		time.Sleep(20 * time.Millisecond)
+		return os.WriteFile(filename, make([]byte, size), 0o644)
+	}
+}
+
+func TestDeltaCacheBuildsOnlyOnceForIdenticalRequests(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	sut := remotree.NewDeltaCache(t.TempDir(), 1000)
+	counter := int32(0)
+	builder := sizedBuilder(100, &counter)
+
+	group := sync.WaitGroup{}
+	names := make([]string, 10)
+	for at := range names {
+		group.Add(1)
+		go func(at int) {
+			defer group.Done()
+			filename, release, err := sut.Acquire("cafebabe", builder)
+			if err == nil {
+				names[at] = filename
+				release()
+			}
+		}(at)
+	}
+	group.Wait()
+
+	must.Equal(int32(1), atomic.LoadInt32(&counter))
+	for _, name := range names {
+		must.Equal(names[0], name)
+	}
+	must.Equal(1, sut.Len())
+	must.Equal(int64(100), sut.Used())
+
+	_, release, err := sut.Acquire("cafebabe", builder)
+	must.Nil(err)
+	release()
+	must.Equal(int32(1), atomic.LoadInt32(&counter))
+}
+
+func TestDeltaCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	sut := remotree.NewDeltaCache(t.TempDir(), 250)
+	counter := int32(0)
+	builder := sizedBuilder(100, &counter)
+
+	first, release, err := sut.Acquire("first", builder)
+	must.Nil(err)
+	release()
+	second, release, err := sut.Acquire("second", builder)
+	must.Nil(err)
+	release()
+	_, release, err = sut.Acquire("first", builder)
+	must.Nil(err)
+	release()
+	must.Equal(2, sut.Len())
+
+	third, holding, err := sut.Acquire("third", builder)
+	must.Nil(err)
+	must.Equal(2, sut.Len())
+	must.Equal(int64(200), sut.Used())
+
+	_, err = os.Stat(second)
+	wont.Nil(err)
+	_, err = os.Stat(first)
+	must.Nil(err)
+	_, err = os.Stat(third)
+	must.Nil(err)
+	holding()
+	must.Equal(int32(3), atomic.LoadInt32(&counter))
+}
+
+func TestDeltaCacheKeepsFilesInUseOverBudget(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	sut := remotree.NewDeltaCache(t.TempDir(), 0)
+	counter := int32(0)
+
+	filename, release, err := sut.Acquire("big", sizedBuilder(100, &counter))
+	must.Nil(err)
+	_, err = os.Stat(filename)
+	must.Nil(err)
+	must.Equal(1, sut.Len())
+
+	release()
+	release()
+	must.Equal(0, sut.Len())
+	must.Equal(int64(0), sut.Used())
+}
+
+func TestDeltaCacheKeepsStreamedMissesForLaterHits(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	sut := remotree.NewDeltaCache(t.TempDir(), 1000)
+	streamed := bytes.NewBuffer(nil)
+	counter := int32(0)
+	teeing := func(filename string) error {
+		atomic.AddInt32(&counter, 1)
+		content := []byte("zip content")
+		streamed.Write(content)
+		return os.WriteFile(filename, content, 0o644)
+	}
+
+	filename, release, err := sut.Acquire("deadbeef", teeing)
+	must.Nil(err)
+	release()
+	must.Equal("zip content", streamed.String())
+
+	again, release, err := sut.Acquire("deadbeef", teeing)
+	must.Nil(err)
+	release()
+	must.Equal(filename, again)
+	must.Equal(int32(1), atomic.LoadInt32(&counter))
+	content, err := os.ReadFile(again)
+	must.Nil(err)
+	must.Equal("zip content", string(content))
+}
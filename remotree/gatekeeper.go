@@ -0,0 +1,269 @@
+package remotree
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/fail"
+	"github.com/robocorp/rcc/journal"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	ScopeRead    = "read"
+	ScopeTrigger = "trigger"
+	ScopeUpload  = "upload"
+
+	bearerPrefix = "bearer "
+	anonymous    = "<anonymous>"
+)
+
+type (
+	Token struct {
+		Name      string   `yaml:"name"`
+		Secret    string   `yaml:"token,omitempty"`
+		Digest    string   `yaml:"sha256,omitempty"`
+		Scopes    []string `yaml:"scopes"`
+		Catalogs  []string `yaml:"catalogs,omitempty"`
+		Platforms []string `yaml:"platforms,omitempty"`
+		digest    []byte
+	}
+
+	Tokens struct {
+		Tokens []*Token `yaml:"tokens"`
+	}
+
+	Gatekeeper interface {
+		Authorize(request *http.Request, scope, catalog string) int
+		Permits(request *http.Request, scope, catalog string) bool
+	}
+
+	openGate struct {
+		auditor *auditor
+	}
+
+	tokenGate struct {
+		tokens  []*Token
+		auditor *auditor
+	}
+
+	auditor struct {
+		sync.Mutex
+		filename string
+	}
+
+	auditEvent struct {
+		When     int64  `json:"when"`
+		Remote   string `json:"remote"`
+		Method   string `json:"method"`
+		Path     string `json:"path"`
+		Scope    string `json:"scope"`
+		Catalog  string `json:"catalog"`
+		Token    string `json:"token"`
+		Status   int    `json:"status"`
+		Decision string `json:"decision"`
+	}
+)
+
+func secretDigest(secret string) []byte {
+	digest := sha256.Sum256([]byte(secret))
+	return digest[:]
+}
+
+func (it *Token) prepare() (err error) {
+	defer fail.Around(&err)
+
+	fail.On(len(it.Name) == 0, "Token without name is not allowed.")
+	fail.On(len(it.Secret) > 0 && len(it.Digest) > 0, "Token %q has both 'token' and 'sha256' defined; use only one.", it.Name)
+	if len(it.Secret) > 0 {
+		it.digest = secretDigest(it.Secret)
+		return nil
+	}
+	fail.On(len(it.Digest) == 0, "Token %q has neither 'token' nor 'sha256' defined.", it.Name)
+	it.digest, err = hex.DecodeString(strings.TrimSpace(it.Digest))
+	fail.On(err != nil || len(it.digest) != sha256.Size, "Token %q has invalid 'sha256' value.", it.Name)
+	return nil
+}
+
+func (it *Token) Matches(candidate []byte) bool {
+	return subtle.ConstantTimeCompare(it.digest, candidate) == 1
+}
+
+func (it *Token) HasScope(scope string) bool {
+	for _, member := range it.Scopes {
+		if strings.EqualFold(strings.TrimSpace(member), scope) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyPattern(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		matched, err := filepath.Match(pattern, value)
+		if err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+func CatalogPlatform(catalog string) string {
+	parts := strings.SplitN(catalog, ".", 2)
+	if len(parts) != 2 {
+		return ""
+	}
+	return parts[1]
+}
+
+func (it *Token) AllowsCatalog(catalog string) bool {
+	if len(catalog) == 0 {
+		return true
+	}
+	return anyPattern(it.Catalogs, catalog) && anyPattern(it.Platforms, CatalogPlatform(catalog))
+}
+
+func LoadTokens(filename string) (tokens *Tokens, err error) {
+	defer fail.Around(&err)
+
+	content, err := os.ReadFile(filename)
+	fail.On(err != nil, "Could not read tokens file %q, reason: %v", filename, err)
+	tokens = &Tokens{}
+	err = yaml.Unmarshal(content, tokens)
+	fail.On(err != nil, "Could not parse tokens file %q, reason: %v", filename, err)
+	seen := make(map[string]bool)
+	for _, token := range tokens.Tokens {
+		fail.Fast(token.prepare())
+		fail.On(seen[token.Name], "Token name %q is used more than once in %q.", token.Name, filename)
+		seen[token.Name] = true
+	}
+	fail.On(len(tokens.Tokens) == 0, "Tokens file %q does not have any tokens defined.", filename)
+	return tokens, nil
+}
+
+func NewGatekeeper(tokenfile, auditfile string) (Gatekeeper, error) {
+	audit := &auditor{filename: auditfile}
+	if len(tokenfile) == 0 {
+		return &openGate{audit}, nil
+	}
+	tokens, err := LoadTokens(tokenfile)
+	if err != nil {
+		return nil, err
+	}
+	common.Log("Authorization enabled with %d tokens from %q.", len(tokens.Tokens), tokenfile)
+	return &tokenGate{tokens.Tokens, audit}, nil
+}
+
+func remoteAddress(request *http.Request) string {
+	forwarded := request.Header.Get("X-Forwarded-For")
+	if len(forwarded) > 0 {
+		return fmt.Sprintf("%s (via %s)", forwarded, request.RemoteAddr)
+	}
+	return request.RemoteAddr
+}
+
+func (it *auditor) record(request *http.Request, scope, catalog, token string, status int) {
+	decision := "allow"
+	if status != http.StatusOK {
+		decision = "deny"
+	}
+	event := &auditEvent{
+		When:     time.Now().Unix(),
+		Remote:   remoteAddress(request),
+		Method:   request.Method,
+		Path:     request.URL.Path,
+		Scope:    scope,
+		Catalog:  catalog,
+		Token:    token,
+		Status:   status,
+		Decision: decision,
+	}
+	common.Log("AUDIT: %s %s %s %s scope=%s catalog=%q token=%q status=%d", decision, event.Remote, event.Method, event.Path, scope, catalog, token, status)
+	if len(it.filename) == 0 {
+		return
+	}
+	blob, err := json.Marshal(event)
+	if err != nil {
+		common.Debug("AUDIT: could not serialize event, reason: %v", err)
+		return
+	}
+	it.Lock()
+	defer it.Unlock()
+	err = journal.AppendJournal(it.filename, blob)
+	if err != nil {
+		common.Log("AUDIT: could not write %q, reason: %v", it.filename, err)
+	}
+}
+
+func (it *openGate) Authorize(request *http.Request, scope, catalog string) int {
+	it.auditor.record(request, scope, catalog, anonymous, http.StatusOK)
+	return http.StatusOK
+}
+
+func (it *openGate) Permits(request *http.Request, scope, catalog string) bool {
+	return true
+}
+
+func candidateDigests(header string) [][]byte {
+	value := strings.TrimSpace(header)
+	result := [][]byte{secretDigest(value)}
+	if strings.HasPrefix(strings.ToLower(value), bearerPrefix) {
+		result = append(result, secretDigest(strings.TrimSpace(value[len(bearerPrefix):])))
+	}
+	return result
+}
+
+func (it *tokenGate) identify(header string) *Token {
+	if len(strings.TrimSpace(header)) == 0 {
+		return nil
+	}
+	var found *Token
+	for _, candidate := range candidateDigests(header) {
+		for _, token := range it.tokens {
+			if token.Matches(candidate) && found == nil {
+				found = token
+			}
+		}
+	}
+	return found
+}
+
+func (it *tokenGate) Authorize(request *http.Request, scope, catalog string) int {
+	token := it.identify(request.Header.Get("Authorization"))
+	if token == nil {
+		it.auditor.record(request, scope, catalog, anonymous, http.StatusUnauthorized)
+		return http.StatusUnauthorized
+	}
+	status := http.StatusOK
+	if !token.HasScope(scope) || !token.AllowsCatalog(catalog) {
+		status = http.StatusForbidden
+	}
+	it.auditor.record(request, scope, catalog, token.Name, status)
+	return status
+}
+
+func (it *tokenGate) Permits(request *http.Request, scope, catalog string) bool {
+	token := it.identify(request.Header.Get("Authorization"))
+	return token != nil && token.HasScope(scope) && token.AllowsCatalog(catalog)
+}
+
+func deny(response http.ResponseWriter, status int) {
+	if status == http.StatusUnauthorized {
+		response.Header().Set("WWW-Authenticate", `Bearer realm="rccremote"`)
+	}
+	response.WriteHeader(status)
+	response.Write([]byte(fmt.Sprintf("%d %s", status, http.StatusText(status))))
+}
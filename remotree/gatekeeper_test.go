@@ -0,0 +1,88 @@
+package remotree_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/robocorp/rcc/hamlet"
+	"github.com/robocorp/rcc/remotree"
+)
+
+const (
+	linuxCatalog   = "cafebabe12345678v12.linux_amd64"
+	windowsCatalog = "deadbeef12345678v12.windows_amd64"
+)
+
+func authorizedRequest(secret string) *http.Request {
+	request := httptest.NewRequest(http.MethodGet, "/parts/"+linuxCatalog, nil)
+	if len(secret) > 0 {
+		request.Header.Set("Authorization", secret)
+	}
+	return request
+}
+
+func TestCanLoadTokens(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	tokens, err := remotree.LoadTokens("testdata/tokens.yaml")
+	must.Nil(err)
+	wont.Nil(tokens)
+	must.Equal(3, len(tokens.Tokens))
+
+	_, err = remotree.LoadTokens("testdata/missing.yaml")
+	wont.Nil(err)
+}
+
+func TestCanSeeCatalogPlatform(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	must.Equal("linux_amd64", remotree.CatalogPlatform(linuxCatalog))
+	must.Equal("windows_amd64", remotree.CatalogPlatform(windowsCatalog))
+	must.Equal("", remotree.CatalogPlatform("nonsense"))
+}
+
+func TestOpenGatekeeperAllowsEverything(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	keeper, err := remotree.NewGatekeeper("", "")
+	must.Nil(err)
+	must.Equal(http.StatusOK, keeper.Authorize(authorizedRequest(""), remotree.ScopeRead, linuxCatalog))
+	must.Equal(http.StatusOK, keeper.Authorize(authorizedRequest(""), remotree.ScopeTrigger, windowsCatalog))
+}
+
+func TestTokenGatekeeperChecksTokensAndScopes(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	keeper, err := remotree.NewGatekeeper("testdata/tokens.yaml", "")
+	must.Nil(err)
+	wont.Nil(keeper)
+
+	must.Equal(http.StatusUnauthorized, keeper.Authorize(authorizedRequest(""), remotree.ScopeRead, linuxCatalog))
+	must.Equal(http.StatusUnauthorized, keeper.Authorize(authorizedRequest("wrong"), remotree.ScopeRead, linuxCatalog))
+
+	must.Equal(http.StatusOK, keeper.Authorize(authorizedRequest("reader-secret"), remotree.ScopeRead, linuxCatalog))
+	must.Equal(http.StatusOK, keeper.Authorize(authorizedRequest("Bearer reader-secret"), remotree.ScopeRead, windowsCatalog))
+	must.Equal(http.StatusForbidden, keeper.Authorize(authorizedRequest("reader-secret"), remotree.ScopeTrigger, linuxCatalog))
+
+	must.Equal(http.StatusOK, keeper.Authorize(authorizedRequest("linux-ci-secret"), remotree.ScopeTrigger, linuxCatalog))
+	must.Equal(http.StatusForbidden, keeper.Authorize(authorizedRequest("linux-ci-secret"), remotree.ScopeRead, windowsCatalog))
+
+	must.Equal(http.StatusOK, keeper.Authorize(authorizedRequest("pinned-secret"), remotree.ScopeRead, linuxCatalog))
+	must.Equal(http.StatusForbidden, keeper.Authorize(authorizedRequest("pinned-secret"), remotree.ScopeRead, windowsCatalog))
+}
+
+func TestGatekeeperPermitsWithoutAuditing(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	keeper, err := remotree.NewGatekeeper("testdata/tokens.yaml", "")
+	must.Nil(err)
+
+	wont.True(keeper.Permits(authorizedRequest(""), remotree.ScopeRead, linuxCatalog))
+	must.True(keeper.Permits(authorizedRequest("linux-ci-secret"), remotree.ScopeRead, linuxCatalog))
+	wont.True(keeper.Permits(authorizedRequest("linux-ci-secret"), remotree.ScopeRead, windowsCatalog))
+
+	open, err := remotree.NewGatekeeper("", "")
+	must.Nil(err)
+	must.True(open.Permits(authorizedRequest(""), remotree.ScopeRead, windowsCatalog))
+}
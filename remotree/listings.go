@@ -16,7 +16,7 @@ const (
 	partCacheSize = 20
 )
 
-func makeQueryHandler(queries Partqueries, triggers chan string) http.HandlerFunc {
+func makeQueryHandler(keeper Gatekeeper, queries Partqueries, triggers chan string) http.HandlerFunc {
 	return func(response http.ResponseWriter, request *http.Request) {
 		catalog := filepath.Base(request.URL.Path)
 		defer common.Stopwatch("Query of catalog %q took", catalog).Debug()
@@ -25,6 +25,11 @@ func makeQueryHandler(queries Partqueries, triggers chan string) http.HandlerFun
 			common.Trace("Query: rejecting request %q for catalog %q.", request.Method, catalog)
 			return
 		}
+		status := keeper.Authorize(request, ScopeRead, catalog)
+		if status != http.StatusOK {
+			deny(response, status)
+			return
+		}
 		if isSelfRequest(request) {
 			response.WriteHeader(http.StatusConflict)
 			common.Trace("Query: rejecting /SELF/ request for catalog %q.", catalog)
@@ -38,7 +43,9 @@ func makeQueryHandler(queries Partqueries, triggers chan string) http.HandlerFun
 		content, ok := <-reply
 		common.Debug("query handler: %q -> %v", catalog, ok)
 		if !ok {
-			triggers <- catalog
+			if keeper.Permits(request, ScopeTrigger, catalog) {
+				triggers <- catalog
+			}
 			response.WriteHeader(http.StatusNotFound)
 			response.Write([]byte("404 not found, sorry"))
 			return
@@ -93,6 +100,12 @@ loop:
 		if !ok {
 			break loop
 		}
+		if query.Refresh {
+			delete(cache, query.Catalog)
+			common.Debug("Part listing of catalog %q refreshed.", query.Catalog)
+			close(query.Reply)
+			continue
+		}
 		known, ok := cache[query.Catalog]
 		if ok {
 			query.Reply <- known
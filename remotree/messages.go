@@ -3,6 +3,7 @@ package remotree
 type (
 	Partquery struct {
 		Catalog string
+		Refresh bool
 		Reply   chan string
 	}
 	Partqueries chan *Partquery
@@ -0,0 +1,165 @@
+package remotree
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robocorp/rcc/common"
+)
+
+const (
+	metricRequests        = "rccremote_requests_total"
+	metricDeltaBytes      = "rccremote_delta_bytes_total"
+	metricDeltaCache      = "rccremote_delta_cache_total"
+	metricDeltaCacheBytes = "rccremote_delta_cache_bytes"
+	metricPulls           = "rccremote_upstream_pulls_total"
+	metricStartSeconds    = "rccremote_start_time_seconds"
+)
+
+type (
+	metricFamily struct {
+		help   string
+		kind   string
+		values map[string]float64
+	}
+
+	Metrics struct {
+		sync.Mutex
+		families map[string]*metricFamily
+	}
+
+	countingWriter struct {
+		http.ResponseWriter
+		status int
+		bytes  uint64
+	}
+)
+
+var (
+	metrics = NewMetrics()
+)
+
+func NewMetrics() *Metrics {
+	result := &Metrics{families: make(map[string]*metricFamily)}
+	result.define(metricRequests, "counter", "Requests handled, by handler and status code.")
+	result.define(metricDeltaBytes, "counter", "Bytes sent as /delta/ responses.")
+	result.define(metricDeltaCache, "counter", "Delta zip cache events, by result (hit, miss, coalesced, evict, or stream).")
+	result.define(metricDeltaCacheBytes, "gauge", "Bytes currently used by delta zip cache.")
+	result.define(metricPulls, "counter", "Pulls from upstream RCC_REMOTE_ORIGIN, by outcome.")
+	result.define(metricStartSeconds, "gauge", "Start time of this rccremote, in unix epoch seconds.")
+	result.Set(metricStartSeconds, "", float64(time.Now().Unix()))
+	return result
+}
+
+func (it *Metrics) define(name, kind, help string) {
+	it.families[name] = &metricFamily{
+		help:   help,
+		kind:   kind,
+		values: make(map[string]float64),
+	}
+}
+
+func Labels(pairs ...string) string {
+	parts := make([]string, 0, len(pairs)/2)
+	for at := 0; at+1 < len(pairs); at += 2 {
+		parts = append(parts, fmt.Sprintf("%s=%q", pairs[at], pairs[at+1]))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (it *Metrics) Add(name, labels string, delta float64) {
+	it.Lock()
+	defer it.Unlock()
+	family, ok := it.families[name]
+	if !ok {
+		common.Debug("Metrics: unknown metric %q.", name)
+		return
+	}
+	family.values[labels] += delta
+}
+
+func (it *Metrics) Set(name, labels string, value float64) {
+	it.Lock()
+	defer it.Unlock()
+	family, ok := it.families[name]
+	if !ok {
+		common.Debug("Metrics: unknown metric %q.", name)
+		return
+	}
+	family.values[labels] = value
+}
+
+func (it *Metrics) WriteTo(sink io.Writer) (int64, error) {
+	it.Lock()
+	defer it.Unlock()
+	total := int64(0)
+	names := make([]string, 0, len(it.families))
+	for name, _ := range it.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		family := it.families[name]
+		lines := make([]string, 0, len(family.values)+2)
+		lines = append(lines, fmt.Sprintf("# HELP %s %s", name, family.help))
+		lines = append(lines, fmt.Sprintf("# TYPE %s %s", name, family.kind))
+		series := make([]string, 0, len(family.values))
+		for labels, _ := range family.values {
+			series = append(series, labels)
+		}
+		sort.Strings(series)
+		for _, labels := range series {
+			if len(labels) > 0 {
+				lines = append(lines, fmt.Sprintf("%s{%s} %v", name, labels, family.values[labels]))
+			} else {
+				lines = append(lines, fmt.Sprintf("%s %v", name, family.values[labels]))
+			}
+		}
+		size, err := io.WriteString(sink, strings.Join(lines, "\n")+"\n")
+		total += int64(size)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func (it *countingWriter) WriteHeader(status int) {
+	it.status = status
+	it.ResponseWriter.WriteHeader(status)
+}
+
+func (it *countingWriter) Write(content []byte) (int, error) {
+	size, err := it.ResponseWriter.Write(content)
+	it.bytes += uint64(size)
+	return size, err
+}
+
+func instrumented(handler string, delegate http.HandlerFunc) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		counter := &countingWriter{ResponseWriter: response, status: http.StatusOK}
+		delegate(counter, request)
+		metrics.Add(metricRequests, Labels("handler", handler, "code", fmt.Sprintf("%d", counter.status)), 1)
+		if handler == "delta" {
+			metrics.Add(metricDeltaBytes, "", float64(counter.bytes))
+		}
+	}
+}
+
+func makeMetricsHandler(keeper Gatekeeper) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		status := keeper.Authorize(request, ScopeRead, "")
+		if status != http.StatusOK {
+			deny(response, status)
+			return
+		}
+		response.Header().Add("Content-Type", "text/plain; version=0.0.4")
+		response.WriteHeader(http.StatusOK)
+		metrics.WriteTo(response)
+	}
+}
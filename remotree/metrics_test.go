@@ -0,0 +1,38 @@
+package remotree_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/robocorp/rcc/hamlet"
+	"github.com/robocorp/rcc/remotree"
+)
+
+func TestCanFormatLabels(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	must.Equal("", remotree.Labels())
+	must.Equal(`handler="delta"`, remotree.Labels("handler", "delta"))
+	must.Equal(`handler="delta",code="200"`, remotree.Labels("handler", "delta", "code", "200"))
+}
+
+func TestCanWriteMetricsInPrometheusTextFormat(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	sut := remotree.NewMetrics()
+	sut.Add("rccremote_requests_total", remotree.Labels("handler", "parts", "code", "200"), 1)
+	sut.Add("rccremote_requests_total", remotree.Labels("handler", "parts", "code", "200"), 2)
+	sut.Add("rccremote_delta_bytes_total", "", 1234)
+	sut.Add("rccremote_unknown_total", "", 1)
+
+	sink := &strings.Builder{}
+	_, err := sut.WriteTo(sink)
+	must.Nil(err)
+	output := sink.String()
+
+	must.True(strings.Contains(output, "# TYPE rccremote_requests_total counter\n"))
+	must.True(strings.Contains(output, "rccremote_requests_total{handler=\"parts\",code=\"200\"} 3\n"))
+	must.True(strings.Contains(output, "rccremote_delta_bytes_total 1234\n"))
+	must.True(strings.Contains(output, "# TYPE rccremote_start_time_seconds gauge\n"))
+	wont.True(strings.Contains(output, "rccremote_unknown_total"))
+}
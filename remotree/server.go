@@ -2,6 +2,7 @@ package remotree
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"os"
@@ -10,16 +11,50 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/robocorp/rcc/common"
 	"github.com/robocorp/rcc/pathlib"
 )
 
-func Serve(address string, port int, domain, storage string) error {
+type Options struct {
+	Hostname    string
+	Port        int
+	Domain      string
+	Holding     string
+	Tokens      string
+	Auditlog    string
+	Certificate string
+	Key         string
+	ClientCA    string
+	DeltaCache  int64
+	Stream      bool
+}
+
+func Serve(options *Options) error {
 	// we need
 	// - query handler (for just catalog hashes)
 	// - partial content sender (for sending delta catalog)
-	// - webserver
-	holding := filepath.Join(storage, "hold")
-	err := cleanupHoldStorage(holding)
+	// - upload receiver (for importing pushed catalogs)
+	// - catalog listing, health and metrics (for operations)
+	// - gatekeeper (for authorization of all above)
+	// - webserver (optionally with TLS and client certificates)
+	keeper, err := NewGatekeeper(options.Tokens, options.Auditlog)
+	if err != nil {
+		return err
+	}
+
+	if len(options.ClientCA) > 0 && !options.Secure() {
+		return fmt.Errorf("Client CA %q requires also server certificate and key to be given.", options.ClientCA)
+	}
+	var config *tls.Config
+	if options.Secure() {
+		config, err = TlsConfig(options.Certificate, options.Key, options.ClientCA)
+		if err != nil {
+			return err
+		}
+	}
+
+	holding := filepath.Join(options.Holding, "hold")
+	err = cleanupHoldStorage(holding)
 	if err != nil {
 		return err
 	}
@@ -31,6 +66,10 @@ func Serve(address string, port int, domain, storage string) error {
 		defer pathlib.TryRemoveAll("remotree.Serve[defer]", tempdir)
 	}
 
+	tempdir, _ = tempDir()
+	deltas := NewDeltaCache(tempdir, options.DeltaCache)
+	common.Log("Delta cache at %q with budget of %d bytes [streaming misses: %v].", tempdir, options.DeltaCache, options.Stream)
+
 	triggers := make(chan string, 20)
 	defer close(triggers)
 
@@ -40,25 +79,48 @@ func Serve(address string, port int, domain, storage string) error {
 	go listProvider(partqueries)
 	go pullProcess(triggers)
 
-	listen := fmt.Sprintf("%s:%d", address, port)
+	listen := fmt.Sprintf("%s:%d", options.Hostname, options.Port)
 	mux := http.NewServeMux()
 	server := &http.Server{
-		Addr:           listen,
-		Handler:        mux,
-		ReadTimeout:    2 * time.Minute,
-		WriteTimeout:   30 * time.Minute,
-		MaxHeaderBytes: 1 << 14,
+		Addr:              listen,
+		Handler:           mux,
+		ReadTimeout:       30 * time.Minute,
+		ReadHeaderTimeout: 2 * time.Minute,
+		WriteTimeout:      30 * time.Minute,
+		MaxHeaderBytes:    1 << 14,
+		TLSConfig:         config,
 	}
 
-	mux.HandleFunc("/parts/", makeQueryHandler(partqueries, triggers))
-	mux.HandleFunc("/delta/", makeDeltaHandler(partqueries))
-	mux.HandleFunc("/force/", makeTriggerHandler(triggers))
+	mux.HandleFunc("/parts/", instrumented("parts", makeQueryHandler(keeper, partqueries, triggers)))
+	mux.HandleFunc("/delta/", instrumented("delta", makeDeltaHandler(keeper, partqueries, deltas, options.Stream)))
+	mux.HandleFunc("/force/", instrumented("force", makeTriggerHandler(keeper, triggers)))
+	mux.HandleFunc("/catalogs", instrumented("catalogs", makeCatalogsHandler(keeper)))
+	mux.HandleFunc("/healthz", instrumented("healthz", makeHealthHandler()))
+	mux.HandleFunc("/metrics", makeMetricsHandler(keeper))
+	if len(options.Tokens) > 0 {
+		mux.HandleFunc("/upload/", instrumented("upload", makeUploadHandler(keeper, partqueries)))
+	} else {
+		common.Log("Uploads are disabled, since they require authorization tokens.")
+	}
 
-	go server.ListenAndServe()
+	go listenAndServe(server, options.Secure())
+	common.Log("Serving %s://%s/ for domain %q.", options.Scheme(), listen, options.Domain)
 
 	return runTillSignal(server)
 }
 
+func listenAndServe(server *http.Server, secure bool) {
+	var err error
+	if secure {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		common.Log("Server at %q stopped, reason: %v", server.Addr, err)
+	}
+}
+
 func runTillSignal(server *http.Server) error {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGHUP, syscall.SIGTERM)
@@ -0,0 +1,45 @@
+package remotree
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/fail"
+)
+
+func (it *Options) Secure() bool {
+	return len(it.Certificate) > 0 || len(it.Key) > 0
+}
+
+func (it *Options) Scheme() string {
+	if it.Secure() {
+		return "https"
+	}
+	return "http"
+}
+
+func TlsConfig(certfile, keyfile, clientca string) (config *tls.Config, err error) {
+	defer fail.Around(&err)
+
+	fail.On(len(certfile) == 0 || len(keyfile) == 0, "Both certificate and key are needed for TLS, got certificate %q and key %q.", certfile, keyfile)
+	pair, err := tls.LoadX509KeyPair(certfile, keyfile)
+	fail.On(err != nil, "Could not load server certificate %q and key %q, reason: %v", certfile, keyfile, err)
+	config = &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{pair},
+		ClientAuth:   tls.NoClientCert,
+	}
+	if len(clientca) == 0 {
+		return config, nil
+	}
+	content, err := os.ReadFile(clientca)
+	fail.On(err != nil, "Could not read client CA file %q, reason: %v", clientca, err)
+	pool := x509.NewCertPool()
+	fail.On(!pool.AppendCertsFromPEM(content), "Client CA file %q does not contain any PEM certificates.", clientca)
+	config.ClientCAs = pool
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	common.Log("Mutual TLS enabled; client certificates must be signed by CA from %q.", clientca)
+	return config, nil
+}
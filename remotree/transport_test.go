@@ -0,0 +1,161 @@
+package remotree_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robocorp/rcc/hamlet"
+	"github.com/robocorp/rcc/remotree"
+)
+
+type authority struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	pem         []byte
+}
+
+func newAuthority(t *testing.T, name string) *authority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	blob, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &authority{certificate, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: blob})}
+}
+
+func (it *authority) issue(t *testing.T, name string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	blob, err := x509.CreateCertificate(rand.Reader, template, it.certificate, &key.PublicKey, it.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyblob, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: blob}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyblob})
+}
+
+func writeFile(t *testing.T, folder, name string, content []byte) string {
+	fullpath := filepath.Join(folder, name)
+	err := os.WriteFile(fullpath, content, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fullpath
+}
+
+func clientFor(t *testing.T, roots *x509.CertPool, certificate, key []byte) *http.Client {
+	config := &tls.Config{RootCAs: roots}
+	if certificate != nil {
+		pair, err := tls.X509KeyPair(certificate, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config.Certificates = []tls.Certificate{pair}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+}
+
+func TestTlsConfigNeedsCertificateAndKey(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	_, err := remotree.TlsConfig("", "", "")
+	wont.Nil(err)
+
+	_, err = remotree.TlsConfig("testdata/missing.pem", "testdata/missing.key", "")
+	wont.Nil(err)
+
+	options := &remotree.Options{}
+	wont.True(options.Secure())
+	must.Equal("http", options.Scheme())
+
+	options.Certificate = "server.pem"
+	must.True(options.Secure())
+	must.Equal("https", options.Scheme())
+}
+
+func TestCanServeWithMutualTls(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	folder := t.TempDir()
+	trusted := newAuthority(t, "trusted test CA")
+	rogue := newAuthority(t, "rogue test CA")
+
+	servercert, serverkey := trusted.issue(t, "rccremote", x509.ExtKeyUsageServerAuth)
+	clientcert, clientkey := trusted.issue(t, "rcc", x509.ExtKeyUsageClientAuth)
+	roguecert, roguekey := rogue.issue(t, "intruder", x509.ExtKeyUsageClientAuth)
+
+	config, err := remotree.TlsConfig(
+		writeFile(t, folder, "server.pem", servercert),
+		writeFile(t, folder, "server.key", serverkey),
+		writeFile(t, folder, "clientca.pem", trusted.pem))
+	must.Nil(err)
+	wont.Nil(config)
+	must.Equal(tls.RequireAndVerifyClientCert, config.ClientAuth)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		response.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = config
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	must.True(roots.AppendCertsFromPEM(trusted.pem))
+
+	response, err := clientFor(t, roots, clientcert, clientkey).Get(server.URL)
+	must.Nil(err)
+	must.Equal(http.StatusOK, response.StatusCode)
+	response.Body.Close()
+
+	_, err = clientFor(t, roots, nil, nil).Get(server.URL)
+	wont.Nil(err)
+
+	_, err = clientFor(t, roots, roguecert, roguekey).Get(server.URL)
+	wont.Nil(err)
+
+	_, err = clientFor(t, x509.NewCertPool(), clientcert, clientkey).Get(server.URL)
+	wont.Nil(err)
+}
@@ -3,16 +3,23 @@ package remotree
 import (
 	"net/http"
 	"path/filepath"
+	"strings"
 
 	"github.com/robocorp/rcc/common"
 	"github.com/robocorp/rcc/operations"
 	"github.com/robocorp/rcc/pretty"
+	"github.com/robocorp/rcc/settings"
 )
 
-func makeTriggerHandler(requests chan string) http.HandlerFunc {
+func makeTriggerHandler(keeper Gatekeeper, requests chan string) http.HandlerFunc {
 	return func(response http.ResponseWriter, request *http.Request) {
 		catalog := filepath.Base(request.URL.Path)
 		defer common.Stopwatch("Trigger of catalog %q took", catalog).Debug()
+		status := keeper.Authorize(request, ScopeTrigger, catalog)
+		if status != http.StatusOK {
+			deny(response, status)
+			return
+		}
 		requests <- catalog
 	}
 }
@@ -22,14 +29,16 @@ func pullOperation(counter int, catalog, remoteOrigin string) {
 	common.Log("#%d: Trying to pull %q from %q ...", counter, catalog, remoteOrigin)
 	err := operations.PullCatalog(remoteOrigin, catalog, true)
 	if err != nil {
+		metrics.Add(metricPulls, Labels("outcome", "failure"), 1)
 		pretty.Warning("#%d: Failed to pull %q from %q, reason: %v", counter, catalog, remoteOrigin, err)
 	} else {
+		metrics.Add(metricPulls, Labels("outcome", "success"), 1)
 		common.Log("#%d: Pull %q from %q completed.", counter, catalog, remoteOrigin)
 	}
 }
 
 func pullProcess(requests chan string) {
-	remoteOrigin := common.RccRemoteOrigin()
+	remoteOrigin := strings.Join(settings.Global.RemoteOrigins(), ",")
 	disabled := len(remoteOrigin) == 0
 	if disabled {
 		pretty.Note("Wont pull anything since RCC_REMOTE_ORIGIN is not defined.")
@@ -0,0 +1,164 @@
+package remotree
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/fail"
+	"github.com/robocorp/rcc/operations"
+	"github.com/robocorp/rcc/pathlib"
+)
+
+const (
+	uploadSizeLimit = 8 << 30
+)
+
+func receiveUpload(request *http.Request, response http.ResponseWriter) (filename string, err error) {
+	defer fail.Around(&err)
+
+	tempdir, _ := tempDir()
+	sink, err := os.CreateTemp(tempdir, "upload_*.zip")
+	fail.On(err != nil, "Could not create upload file, reason: %v", err)
+	filename = sink.Name()
+	defer sink.Close()
+
+	source := http.MaxBytesReader(response, request.Body, uploadSizeLimit)
+	size, err := io.Copy(sink, source)
+	if err != nil {
+		pathlib.TryRemove("upload", filename)
+	}
+	fail.On(err != nil, "Receiving upload failed, reason: %v", err)
+	common.Debug("UPLOAD: received %d bytes into %q.", size, filename)
+	return filename, nil
+}
+
+func verifyUpload(filename string) (catalogs []string, err error) {
+	defer fail.Around(&err)
+
+	errors := operations.VerifyZip(filename, operations.HololibZipShape)
+	for _, problem := range errors {
+		common.Debug("UPLOAD: %v", problem)
+	}
+	fail.On(len(errors) > 0, "Upload is not valid hololib zip, it has %d problems, first: %v", len(errors), firstError(errors))
+	catalogs, err = operations.ZipCatalogs(filename)
+	fail.On(err != nil, "Could not list catalogs of upload, reason: %v", err)
+	fail.On(len(catalogs) == 0, "Upload does not contain any catalogs.")
+	return catalogs, nil
+}
+
+func firstError(errors []error) error {
+	if len(errors) == 0 {
+		return nil
+	}
+	return errors[0]
+}
+
+func declaredCatalogs(request *http.Request) []string {
+	result := []string{}
+	for _, catalog := range strings.Split(request.Header.Get(operations.X_RCC_CATALOGS), ",") {
+		flat := filepath.Base(strings.TrimSpace(catalog))
+		if len(flat) > 1 {
+			result = append(result, flat)
+		}
+	}
+	return result
+}
+
+func refreshListings(queries Partqueries, catalogs []string) {
+	for _, catalog := range catalogs {
+		reply := make(chan string)
+		queries <- &Partquery{
+			Catalog: catalog,
+			Refresh: true,
+			Reply:   reply,
+		}
+		<-reply
+	}
+}
+
+func makeUploadHandler(keeper Gatekeeper, queries Partqueries) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		defer common.Stopwatch("Upload from %q took", request.RemoteAddr).Debug()
+		if request.Method != http.MethodPost {
+			response.WriteHeader(http.StatusMethodNotAllowed)
+			common.Trace("Upload: rejecting request %q.", request.Method)
+			return
+		}
+		status := keeper.Authorize(request, ScopeUpload, "")
+		if status != http.StatusOK {
+			deny(response, status)
+			return
+		}
+		if isSelfRequest(request) {
+			response.WriteHeader(http.StatusConflict)
+			common.Trace("Upload: rejecting /SELF/ request.")
+			return
+		}
+		if request.ContentLength > uploadSizeLimit {
+			response.WriteHeader(http.StatusRequestEntityTooLarge)
+			common.Trace("Upload: rejecting %d bytes, limit is %d bytes.", request.ContentLength, uploadSizeLimit)
+			return
+		}
+		authorized := make(map[string]bool)
+		for _, catalog := range declaredCatalogs(request) {
+			status = keeper.Authorize(request, ScopeUpload, catalog)
+			if status != http.StatusOK {
+				deny(response, status)
+				return
+			}
+			authorized[catalog] = true
+		}
+
+		filename, err := receiveUpload(request, response)
+		if err != nil {
+			common.Log("UPLOAD: %v", err)
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(fmt.Sprintf("%v", err)))
+			return
+		}
+		defer pathlib.TryRemove("upload", filename)
+
+		catalogs, err := verifyUpload(filename)
+		if err != nil {
+			common.Log("UPLOAD: %v", err)
+			response.WriteHeader(http.StatusUnprocessableEntity)
+			response.Write([]byte(fmt.Sprintf("%v", err)))
+			return
+		}
+
+		for _, catalog := range catalogs {
+			if authorized[catalog] {
+				continue
+			}
+			status = keeper.Authorize(request, ScopeUpload, catalog)
+			if status != http.StatusOK {
+				deny(response, status)
+				return
+			}
+		}
+
+		err = operations.ProtectedImport(filename)
+		if err != nil {
+			common.Log("UPLOAD: import failed, reason: %v", err)
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		refreshListings(queries, catalogs)
+		common.Log("UPLOAD: imported catalogs %q from %q.", catalogs, remoteAddress(request))
+
+		content, err := json.Marshal(catalogs)
+		if err != nil {
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		response.Header().Add("Content-Type", "application/json")
+		response.WriteHeader(http.StatusOK)
+		response.Write(content)
+	}
+}
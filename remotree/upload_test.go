@@ -0,0 +1,125 @@
+package remotree
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robocorp/rcc/common"
+	"github.com/robocorp/rcc/hamlet"
+	"github.com/robocorp/rcc/operations"
+)
+
+const testCatalog = "0123456789abcdefv12.linux_amd64"
+
+func answerQueries(queries Partqueries) {
+	for query := range queries {
+		query.Reply <- "0123456789abcdef0123456789abcdef"
+	}
+}
+
+func catalogFromDelta(t *testing.T, handler http.HandlerFunc) string {
+	must, _ := hamlet.Specifications(t)
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodPost, "/delta/"+testCatalog, bytes.NewReader(nil)))
+	must.Equal(http.StatusOK, recorder.Code)
+	body := recorder.Body.Bytes()
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	must.Nil(err)
+	for _, entry := range archive.File {
+		if filepath.Base(entry.Name) != testCatalog {
+			continue
+		}
+		reader, err := entry.Open()
+		must.Nil(err)
+		defer reader.Close()
+		content, err := io.ReadAll(reader)
+		must.Nil(err)
+		return string(content)
+	}
+	return ""
+}
+
+func TestUploadedCatalogReplacesCachedDelta(t *testing.T) {
+	must, _ := hamlet.Specifications(t)
+
+	t.Setenv(common.Product.HomeVariable(), t.TempDir())
+	catalogs := common.HololibCatalogLocation()
+	must.Nil(os.MkdirAll(catalogs, 0o755))
+	must.Nil(os.WriteFile(filepath.Join(catalogs, testCatalog), []byte("old catalog"), 0o644))
+
+	keeper := &openGate{&auditor{}}
+	queries := make(Partqueries)
+	defer close(queries)
+	go answerQueries(queries)
+	deltas := NewDeltaCache(t.TempDir(), 1<<20)
+	delta := makeDeltaHandler(keeper, queries, deltas, false)
+	upload := makeUploadHandler(keeper, queries)
+
+	must.Equal("old catalog", catalogFromDelta(t, delta))
+	must.Equal("old catalog", catalogFromDelta(t, delta))
+	must.Equal(1, deltas.Len())
+
+	buffer := bytes.NewBuffer(nil)
+	zipper := zip.NewWriter(buffer)
+	sink, err := zipper.Create("catalog/" + testCatalog)
+	must.Nil(err)
+	_, err = sink.Write([]byte("new uploaded catalog"))
+	must.Nil(err)
+	must.Nil(zipper.Close())
+
+	recorder := httptest.NewRecorder()
+	upload(recorder, httptest.NewRequest(http.MethodPost, "/upload/", buffer))
+	must.Equal(http.StatusOK, recorder.Code)
+
+	must.Equal("new uploaded catalog", catalogFromDelta(t, delta))
+}
+
+type watchedBody struct {
+	read bool
+}
+
+func (it *watchedBody) Read(blob []byte) (int, error) {
+	it.read = true
+	return 0, io.EOF
+}
+
+func TestUploadIsDeniedBeforeBodyIsRead(t *testing.T) {
+	must, wont := hamlet.Specifications(t)
+
+	token := &Token{Name: "uploader", Secret: "upload-secret", Scopes: []string{ScopeUpload}, Catalogs: []string{"cafebabe*"}}
+	must.Nil(token.prepare())
+	upload := makeUploadHandler(&tokenGate{[]*Token{token}, &auditor{}}, make(Partqueries))
+
+	body := &watchedBody{}
+	request := httptest.NewRequest(http.MethodPost, "/upload/", body)
+	request.Header.Set("Authorization", "upload-secret")
+	request.Header.Set(operations.X_RCC_CATALOGS, "cafebabe12345678v12.linux_amd64,"+testCatalog)
+	recorder := httptest.NewRecorder()
+	upload(recorder, request)
+	must.Equal(http.StatusForbidden, recorder.Code)
+	wont.True(body.read)
+
+	body = &watchedBody{}
+	request = httptest.NewRequest(http.MethodPost, "/upload/", body)
+	request.Header.Set("Authorization", "upload-secret")
+	request.ContentLength = uploadSizeLimit + 1
+	recorder = httptest.NewRecorder()
+	upload(recorder, request)
+	must.Equal(http.StatusRequestEntityTooLarge, recorder.Code)
+	wont.True(body.read)
+
+	body = &watchedBody{}
+	request = httptest.NewRequest(http.MethodPost, "/upload/", body)
+	request.Header.Set("Authorization", "reader-secret")
+	recorder = httptest.NewRecorder()
+	upload(recorder, request)
+	must.Equal(http.StatusUnauthorized, recorder.Code)
+	wont.True(body.read)
+}
@@ -424,6 +424,10 @@ func (it *robot) availableEnvironmentConfigurations(marker string) []string {
 		if !pathlib.IsFile(fullpath) {
 			continue
 		}
+		if !conda.LockfileCovers(fullpath, marker) {
+			common.Trace("- %s does not cover %s", fullpath, marker)
+			continue
+		}
 		common.Trace("- %s", fullpath)
 		result = append(result, fullpath)
 	}
@@ -27,6 +27,16 @@ type Api interface {
 	CondaLink(page string) string
 	Hostnames() []string
 	ConfiguredHttpTransport() *http.Transport
+	RemoteHttpTransport() *http.Transport
+	HasClientCertificate() bool
+	RemoteOrigins() []string
+	FastestRemoteOrigin() bool
+	CanonicalBlueprints() bool
+	RestoreMode() string
+	Compression() string
+	CompressionLevel() int
+	DiskBudget() (int64, int64)
+	Policy() *Policy
 	NoProxy() string
 	HttpsProxy() string
 	HttpProxy() string
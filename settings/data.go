@@ -56,6 +56,8 @@ type Settings struct {
 	Branding     StringMap     `yaml:"branding,omitempty" json:"branding,omitempty"`
 	Certificates *Certificates `yaml:"certificates,omitempty" json:"certificates,omitempty"`
 	Network      *Network      `yaml:"network,omitempty" json:"network,omitempty"`
+	Holotree     *Holotree     `yaml:"holotree,omitempty" json:"holotree,omitempty"`
+	Policy       *Policy       `yaml:"policy,omitempty" json:"policy,omitempty"`
 	Endpoints    StringMap     `yaml:"endpoints,omitempty" json:"endpoints,omitempty"`
 	Hosts        []string      `yaml:"diagnostics-hosts,omitempty" json:"diagnostics-hosts,omitempty"`
 	Options      BoolMap       `yaml:"options,omitempty" json:"options,omitempty"`
@@ -110,6 +112,12 @@ func (it *Settings) onTopOf(target *Settings) {
 	if it.Network != nil {
 		it.Network.onTopOf(target)
 	}
+	if it.Holotree != nil {
+		it.Holotree.onTopOf(target)
+	}
+	if it.Policy != nil {
+		it.Policy.onTopOf(target)
+	}
 	if it.Meta != nil {
 		it.Meta.onTopOf(target)
 	}
@@ -183,6 +191,18 @@ func diagnoseOptionalUrl(link, label string, diagnose common.Diagnoser, correct
 	}
 }
 
+func diagnoseOptionalSize(size, label string, diagnose common.Diagnoser, correct bool) bool {
+	if len(strings.TrimSpace(size)) == 0 {
+		return correct
+	}
+	_, err := pathlib.ParseSize(size)
+	if err != nil {
+		diagnose.Warning(0, "", "settings.yaml: %s, %v", label, err)
+		return false
+	}
+	return correct
+}
+
 func (it *Settings) CriticalEnvironmentDiagnostics(target *common.DiagnosticStatus) {
 	diagnose := target.Diagnose("settings.yaml")
 	correct := true
@@ -221,6 +241,34 @@ func (it *Settings) Diagnostics(target *common.DiagnosticStatus) {
 		correct = diagnoseOptionalUrl(it.Endpoints["pypi"], "endpoints/pypi", diagnose, correct)
 		correct = diagnoseOptionalUrl(it.Endpoints["pypi-trusted"], "endpoints/pypi-trusted", diagnose, correct)
 	}
+	if it.Holotree != nil {
+		switch strings.ToLower(strings.TrimSpace(it.Holotree.RestoreMode)) {
+		case "", "copy", "reflink", "hardlink":
+		default:
+			diagnose.Warning(0, "", "settings.yaml: holotree/restore-mode %q is not one of: copy, reflink, hardlink", it.Holotree.RestoreMode)
+			correct = false
+		}
+		switch strings.ToLower(strings.TrimSpace(it.Holotree.Compression)) {
+		case "", "zstd", "gzip":
+		default:
+			diagnose.Warning(0, "", "settings.yaml: holotree/compression %q is not one of: zstd, gzip", it.Holotree.Compression)
+			correct = false
+		}
+		if it.Holotree.CompressionLevel < 0 || it.Holotree.CompressionLevel > 22 {
+			diagnose.Warning(0, "", "settings.yaml: holotree/compression-level %d is not between 1 and 22", it.Holotree.CompressionLevel)
+			correct = false
+		}
+		correct = diagnoseOptionalSize(it.Holotree.MaxSize, "holotree/max-size", diagnose, correct)
+		correct = diagnoseOptionalSize(it.Holotree.MinFree, "holotree/min-free", diagnose, correct)
+	}
+	if it.Policy != nil {
+		switch strings.ToLower(strings.TrimSpace(it.Policy.Mode)) {
+		case "", PolicyEnforce, PolicyAudit:
+		default:
+			diagnose.Warning(0, "", "settings.yaml: policy/mode %q is not one of: enforce, audit", it.Policy.Mode)
+			correct = false
+		}
+	}
 	if it.Meta == nil {
 		diagnose.Warning(0, "", "settings.yaml: meta section is totally missing")
 		correct = false
@@ -235,6 +283,8 @@ type Certificates struct {
 	SslNoRevoke         bool   `yaml:"ssl-no-revoke" json:"ssl-no-revoke"`
 	LegacyRenegotiation bool   `yaml:"legacy-renegotiation-allowed" json:"legacy-renegotiation-allowed"`
 	CaBundle            string `yaml:"ca-bundle,omitempty" json:"ca-bundle,omitempty"`
+	ClientCert          string `yaml:"client-cert,omitempty" json:"client-cert,omitempty"`
+	ClientKey           string `yaml:"client-key,omitempty" json:"client-key,omitempty"`
 }
 
 func (it *Certificates) onTopOf(target *Settings) {
@@ -247,6 +297,12 @@ func (it *Certificates) onTopOf(target *Settings) {
 	if pathlib.IsFile(common.CaBundleFile()) {
 		target.Certificates.CaBundle = common.CaBundleFile()
 	}
+	if len(it.ClientCert) > 0 {
+		target.Certificates.ClientCert = common.ExpandPath(it.ClientCert)
+	}
+	if len(it.ClientKey) > 0 {
+		target.Certificates.ClientKey = common.ExpandPath(it.ClientKey)
+	}
 }
 
 func justHostAndPort(link string) string {
@@ -297,6 +353,8 @@ type Network struct {
 	NoProxy    string `yaml:"no-proxy" json:"no-proxy"`
 	HttpsProxy string `yaml:"https-proxy" json:"https-proxy"`
 	HttpProxy  string `yaml:"http-proxy" json:"http-proxy"`
+
+	RemoteOrigins []string `yaml:"rcc-remote-origins,omitempty" json:"rcc-remote-origins,omitempty"`
 }
 
 func (it *Network) onTopOf(target *Settings) {
@@ -312,4 +370,80 @@ func (it *Network) onTopOf(target *Settings) {
 	if len(it.HttpProxy) > 0 {
 		target.Network.HttpProxy = it.HttpProxy
 	}
+	if len(it.RemoteOrigins) > 0 {
+		target.Network.RemoteOrigins = it.RemoteOrigins
+	}
+}
+
+type Holotree struct {
+	RestoreMode      string `yaml:"restore-mode,omitempty" json:"restore-mode,omitempty"`
+	Compression      string `yaml:"compression,omitempty" json:"compression,omitempty"`
+	CompressionLevel int    `yaml:"compression-level,omitempty" json:"compression-level,omitempty"`
+	MaxSize          string `yaml:"max-size,omitempty" json:"max-size,omitempty"`
+	MinFree          string `yaml:"min-free,omitempty" json:"min-free,omitempty"`
+}
+
+func (it *Holotree) onTopOf(target *Settings) {
+	if target.Holotree == nil {
+		target.Holotree = &Holotree{}
+	}
+	if len(it.RestoreMode) > 0 {
+		target.Holotree.RestoreMode = it.RestoreMode
+	}
+	if len(it.Compression) > 0 {
+		target.Holotree.Compression = it.Compression
+	}
+	if it.CompressionLevel > 0 {
+		target.Holotree.CompressionLevel = it.CompressionLevel
+	}
+	if len(it.MaxSize) > 0 {
+		target.Holotree.MaxSize = it.MaxSize
+	}
+	if len(it.MinFree) > 0 {
+		target.Holotree.MinFree = it.MinFree
+	}
+}
+
+const (
+	PolicyEnforce = "enforce"
+	PolicyAudit   = "audit"
+)
+
+type Policy struct {
+	Mode          string   `yaml:"mode,omitempty" json:"mode,omitempty"`
+	Channels      []string `yaml:"allowed-channels,omitempty" json:"allowed-channels,omitempty"`
+	Indexes       []string `yaml:"allowed-indexes,omitempty" json:"allowed-indexes,omitempty"`
+	Banned        []string `yaml:"banned,omitempty" json:"banned,omitempty"`
+	RequirePinned bool     `yaml:"require-pinned,omitempty" json:"require-pinned,omitempty"`
+	Licenses      []string `yaml:"allowed-licenses,omitempty" json:"allowed-licenses,omitempty"`
+}
+
+func (it *Policy) onTopOf(target *Settings) {
+	if target.Policy == nil {
+		target.Policy = &Policy{}
+	}
+	if len(it.Mode) > 0 {
+		target.Policy.Mode = it.Mode
+	}
+	if len(it.Channels) > 0 {
+		target.Policy.Channels = it.Channels
+	}
+	if len(it.Indexes) > 0 {
+		target.Policy.Indexes = it.Indexes
+	}
+	if len(it.Banned) > 0 {
+		target.Policy.Banned = it.Banned
+	}
+	if len(it.Licenses) > 0 {
+		target.Policy.Licenses = it.Licenses
+	}
+	target.Policy.RequirePinned = target.Policy.RequirePinned || it.RequirePinned
+}
+
+func (it *Policy) IsEmpty() bool {
+	return it == nil || (len(it.Channels)+len(it.Indexes)+len(it.Banned)+len(it.Licenses) == 0 && !it.RequirePinned)
+}
+
+func (it *Policy) AuditOnly() bool {
+	return it != nil && strings.EqualFold(strings.TrimSpace(it.Mode), PolicyAudit)
 }
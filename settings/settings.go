@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 
 	"github.com/robocorp/rcc/blobs"
 	"github.com/robocorp/rcc/common"
@@ -242,10 +243,94 @@ func (it gateway) NoBuild() bool {
 	return nobuild || common.NoBuild || it.Option("no-build")
 }
 
+func (it gateway) RemoteOrigins() []string {
+	origins := common.RccRemoteOrigins()
+	if len(origins) > 0 {
+		return origins
+	}
+	network := it.settings().Network
+	if network == nil {
+		return origins
+	}
+	return common.SplitOrigins(strings.Join(network.RemoteOrigins, ","))
+}
+
+func (it gateway) FastestRemoteOrigin() bool {
+	return it.Option("fastest-remote-origin")
+}
+
+func (it gateway) CanonicalBlueprints() bool {
+	canonical := len(os.Getenv("RCC_CANONICAL_BLUEPRINTS")) > 0
+	return canonical || it.Option("canonical-blueprints")
+}
+
+func (it gateway) RestoreMode() string {
+	holotree := it.settings().Holotree
+	if holotree == nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(holotree.RestoreMode))
+}
+
+func (it gateway) Compression() string {
+	holotree := it.settings().Holotree
+	if holotree == nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(holotree.Compression))
+}
+
+func (it gateway) CompressionLevel() int {
+	holotree := it.settings().Holotree
+	if holotree == nil {
+		return 0
+	}
+	return holotree.CompressionLevel
+}
+
+func (it gateway) DiskBudget() (maxSize int64, minFree int64) {
+	holotree := it.settings().Holotree
+	if holotree == nil {
+		return 0, 0
+	}
+	if len(strings.TrimSpace(holotree.MaxSize)) > 0 {
+		maxSize, _ = pathlib.ParseSize(holotree.MaxSize)
+	}
+	if len(strings.TrimSpace(holotree.MinFree)) > 0 {
+		minFree, _ = pathlib.ParseSize(holotree.MinFree)
+	}
+	return maxSize, minFree
+}
+
+func (it gateway) Policy() *Policy {
+	return it.settings().Policy
+}
+
 func (it gateway) ConfiguredHttpTransport() *http.Transport {
 	return httpTransport.Clone()
 }
 
+func (it gateway) HasClientCertificate() bool {
+	certificates := it.settings().Certificates
+	return len(certificates.ClientCert) > 0 && len(certificates.ClientKey) > 0
+}
+
+func (it gateway) RemoteHttpTransport() *http.Transport {
+	transport := httpTransport.Clone()
+	if !it.HasClientCertificate() {
+		return transport
+	}
+	certificates := it.settings().Certificates
+	pair, err := tls.LoadX509KeyPair(certificates.ClientCert, certificates.ClientKey)
+	if err != nil {
+		common.Log("Warning! Problem loading client certificate %q, reason: %v", certificates.ClientCert, err)
+		return transport
+	}
+	common.Debug("Using client certificate %q for rccremote.", certificates.ClientCert)
+	transport.TLSClientConfig.Certificates = []tls.Certificate{pair}
+	return transport
+}
+
 func (it gateway) loadRootCAs() *x509.CertPool {
 	roots, err := x509.SystemCertPool()
 	if err != nil {